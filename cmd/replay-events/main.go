@@ -0,0 +1,83 @@
+// Command replay-events republishes the events in the jetstream events
+// stream from a sequence number or a time, so that a downstream projection
+// can be rebuilt from the replayed events.
+//
+//	go run ./cmd/replay-events -to replay.search -from-time 2021-11-01T00:00:00Z
+//
+// Every replayed event is published on its subject prefixed by the -to
+// prefix, e.g. products.v1.created is replayed on
+// replay.search.products.v1.created.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/config"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/stream"
+)
+
+func main() {
+	log := logrus.New()
+	log.SetFormatter(&logrus.JSONFormatter{PrettyPrint: true})
+	log.SetOutput(os.Stdout)
+
+	// only the nats and stream settings of the service are loaded, the
+	// rest of its configuration is not needed to replay events.
+	natsCfg, streamCfg := config.NATSConfig{}, config.StreamConfig{}
+	err := config.LoadSections(os.LookupEnv, &natsCfg, &streamCfg)
+	if err != nil {
+		log.WithError(err).Fatal("an error occured while loading the configuration")
+	}
+
+	streamName := flag.String("stream", streamCfg.Name, "name of the jetstream stream to replay")
+	subject := flag.String("subject", "", "only replay the events on this subject, wildcards are allowed")
+	fromSeq := flag.Uint64("from-seq", 0, "stream sequence number of the first replayed event")
+	fromTime := flag.String("from-time", "", "RFC 3339 time of the first replayed event")
+	prefix := flag.String("to", "", "subject prefix the events are replayed on (required)")
+	flag.Parse()
+
+	if *prefix == "" {
+		log.Fatal("the -to subject prefix is required")
+	}
+	opts := stream.ReplayOptions{Subject: *subject, StartSequence: *fromSeq}
+	if *fromTime != "" {
+		opts.StartTime, err = time.Parse(time.RFC3339, *fromTime)
+		if err != nil {
+			log.WithField("from-time", *fromTime).WithError(err).Fatal("invalid -from-time, expected an RFC 3339 time")
+		}
+	}
+
+	natsConn, err := nats.Connect(natsCfg.URI)
+	if err != nil {
+		log.WithError(err).Fatal("an error occured while connecting to nats")
+	}
+	defer natsConn.Close()
+	js, err := natsConn.JetStream()
+	if err != nil {
+		log.WithError(err).Fatal("an error occured while getting jetstream context")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	replayed, err := stream.Replay(ctx, js, *streamName, opts, func(msg *nats.Msg) error {
+		return natsConn.PublishMsg(&nats.Msg{
+			Subject: *prefix + "." + msg.Subject,
+			Header:  msg.Header,
+			Data:    msg.Data,
+		})
+	})
+	if err == nil {
+		err = natsConn.Flush()
+	}
+	entry := log.WithField("stream", *streamName).WithField("replayed", replayed)
+	if err != nil {
+		entry.WithError(err).Fatal("an error occured while replaying events")
+	}
+	entry.Info("events replayed")
+}
@@ -0,0 +1,60 @@
+package serviceservers
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the ErrorInfo domain of the errors returned by the
+// product service.
+const errorDomain = "product-service"
+
+// serviceErrorCodes maps service error codes to their grpc equivalent.
+var serviceErrorCodes = map[services.ErrorCode]codes.Code{
+	services.ErrorCodeNotFound:           codes.NotFound,
+	services.ErrorCodeUnauthenticated:    codes.Unauthenticated,
+	services.ErrorCodePermissionDenied:   codes.PermissionDenied,
+	services.ErrorCodeInvalidArgument:    codes.InvalidArgument,
+	services.ErrorCodeFailedPrecondition: codes.FailedPrecondition,
+	services.ErrorCodeUnavailable:        codes.Unavailable,
+	services.ErrorCodeInternal:           codes.Internal,
+}
+
+// toStatusError converts err to a grpc status error, service errors keep
+// their reason and field violations as status details.
+func toStatusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var serviceErr *services.Error
+	if !errors.As(err, &serviceErr) {
+		return status.Error(codes.Internal, "an unexpected error occured, please try again later")
+	}
+	code, ok := serviceErrorCodes[serviceErr.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+	details := []proto.Message{
+		&errdetails.ErrorInfo{Reason: serviceErr.Reason, Domain: errorDomain},
+	}
+	if len(serviceErr.Violations) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, violation := range serviceErr.Violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       violation.Field,
+				Description: violation.Description,
+			})
+		}
+		details = append(details, badRequest)
+	}
+	st, detailsErr := status.New(code, serviceErr.Message).WithDetails(details...)
+	if detailsErr != nil {
+		return status.Error(code, serviceErr.Message)
+	}
+	return st.Err()
+}
@@ -0,0 +1,107 @@
+package serviceservers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_toStatusError(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		wantCode        codes.Code
+		wantMessage     string
+		wantReason      string
+		wantViolations  []*errdetails.BadRequest_FieldViolation
+		wantWithDetails bool
+	}{
+		{
+			name:        "status error",
+			err:         status.Error(codes.Aborted, "aborted"),
+			wantCode:    codes.Aborted,
+			wantMessage: "aborted",
+		},
+		{
+			name:        "unknown error",
+			err:         errors.New("database password is wrong"),
+			wantCode:    codes.Internal,
+			wantMessage: "an unexpected error occured, please try again later",
+		},
+		{
+			name:            "not found error",
+			err:             services.NewNotFoundError("PRODUCT_NOT_FOUND", "product does not exist"),
+			wantCode:        codes.NotFound,
+			wantMessage:     "product does not exist",
+			wantReason:      "PRODUCT_NOT_FOUND",
+			wantWithDetails: true,
+		},
+		{
+			name:            "unavailable error",
+			err:             services.NewUnavailableError("try again later", errors.New("connection refused")),
+			wantCode:        codes.Unavailable,
+			wantMessage:     "try again later",
+			wantReason:      "DEPENDENCY_UNAVAILABLE",
+			wantWithDetails: true,
+		},
+		{
+			name:            "failed precondition error",
+			err:             services.NewFailedPreconditionError("INSUFFICIENT_STOCK", "not enough stock"),
+			wantCode:        codes.FailedPrecondition,
+			wantMessage:     "not enough stock",
+			wantReason:      "INSUFFICIENT_STOCK",
+			wantWithDetails: true,
+		},
+		{
+			name: "invalid argument error",
+			err: services.NewInvalidArgumentError("invalid product",
+				services.FieldViolation{Field: "name", Description: "name is required"},
+				services.FieldViolation{Field: "price", Description: "price must be positive"},
+			),
+			wantCode:    codes.InvalidArgument,
+			wantMessage: "invalid product",
+			wantReason:  "INVALID_ARGUMENT",
+			wantViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "name", Description: "name is required"},
+				{Field: "price", Description: "price must be positive"},
+			},
+			wantWithDetails: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(toStatusError(tt.err))
+			if st.Code() != tt.wantCode || st.Message() != tt.wantMessage {
+				t.Errorf("toStatusError() = %v %q, want %v %q", st.Code(), st.Message(), tt.wantCode, tt.wantMessage)
+			}
+			if !tt.wantWithDetails {
+				return
+			}
+			var gotReason string
+			var gotViolations []*errdetails.BadRequest_FieldViolation
+			for _, detail := range st.Details() {
+				switch detail := detail.(type) {
+				case *errdetails.ErrorInfo:
+					gotReason = detail.Reason
+				case *errdetails.BadRequest:
+					gotViolations = detail.FieldViolations
+				}
+			}
+			if gotReason != tt.wantReason {
+				t.Errorf("toStatusError() reason = %v, want %v", gotReason, tt.wantReason)
+			}
+			if len(gotViolations) != len(tt.wantViolations) {
+				t.Fatalf("toStatusError() violations = %v, want %v", gotViolations, tt.wantViolations)
+			}
+			for i := range gotViolations {
+				if gotViolations[i].Field != tt.wantViolations[i].Field || gotViolations[i].Description != tt.wantViolations[i].Description {
+					t.Errorf("toStatusError() violation = %v, want %v", gotViolations[i], tt.wantViolations[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package serviceservers
+
+import (
+	"context"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+)
+
+type InventoryServer struct {
+	proto.UnimplementedInventoryServiceServer
+	inventoryService services.InventoryService
+}
+
+// NewInventoryServer returns a new inventory server object.
+func NewInventoryServer(inventoryService services.InventoryService) *InventoryServer {
+	return &InventoryServer{
+		inventoryService: inventoryService,
+	}
+}
+
+func (s *InventoryServer) AdjustStock(ctx context.Context, input *proto.AdjustStockInput) (*proto.StockLevel, error) {
+	ctx, span := tracer.Start(ctx, "AdjustStock")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	jwtToken, err := extractJWTFromContext(ctx, span)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	level, err := s.inventoryService.AdjustStock(
+		ctx, jwtToken, input.Sku, ProtoStockAdjustmentKindToInternal(input.Kind), input.Quantity, input.Reason,
+	)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return InternalStockLevelToProto(level), nil
+}
+
+func (s *InventoryServer) GetStock(ctx context.Context, input *proto.GetStockInput) (*proto.StockLevel, error) {
+	ctx, span := tracer.Start(ctx, "GetStock")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	level, err := s.inventoryService.GetStock(ctx, input.Sku)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return InternalStockLevelToProto(level), nil
+}
+
+func (s *InventoryServer) ReserveStock(ctx context.Context, input *proto.ReserveStockInput) (*proto.Reservation, error) {
+	ctx, span := tracer.Start(ctx, "ReserveStock")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	jwtToken, err := extractJWTFromContext(ctx, span)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	reservation, err := s.inventoryService.ReserveStock(ctx, jwtToken, input.OrderId, ProtoReservationItemsToInternal(input.Items))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return InternalReservationToProto(reservation), nil
+}
+
+func (s *InventoryServer) CommitReservation(ctx context.Context, input *proto.CommitReservationInput) (*proto.Reservation, error) {
+	ctx, span := tracer.Start(ctx, "CommitReservation")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	jwtToken, err := extractJWTFromContext(ctx, span)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	reservation, err := s.inventoryService.CommitReservation(ctx, jwtToken, input.OrderId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return InternalReservationToProto(reservation), nil
+}
+
+func (s *InventoryServer) ReleaseReservation(ctx context.Context, input *proto.ReleaseReservationInput) (*proto.Reservation, error) {
+	ctx, span := tracer.Start(ctx, "ReleaseReservation")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	jwtToken, err := extractJWTFromContext(ctx, span)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	reservation, err := s.inventoryService.ReleaseReservation(ctx, jwtToken, input.OrderId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return InternalReservationToProto(reservation), nil
+}
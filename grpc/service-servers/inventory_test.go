@@ -0,0 +1,175 @@
+package serviceservers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/inventory"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/mocks"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestInventoryServer_AdjustStock(t *testing.T) {
+	inventoryService := &mocks.InventoryService{}
+	inventoryService.On("AdjustStock", mock.Anything, "jwtToken", "sku.invalid", inventory.KindSell, int64(2), "sold in store").
+		Return(nil, errors.New("an error occured"))
+	inventoryService.On("AdjustStock", mock.Anything, "jwtToken", "sku.valid", inventory.KindReceive, int64(5), "restock").
+		Return(&inventory.StockLevel{Sku: "sku.valid", OnHand: 7, Reserved: 2}, nil)
+
+	ctxWithMetadata := metadata.NewIncomingContext(context.TODO(), metadata.New(map[string]string{
+		"Authorization": "jwtToken",
+	}))
+
+	type args struct {
+		ctx   context.Context
+		input *proto.AdjustStockInput
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *proto.StockLevel
+		wantErr bool
+	}{
+		{
+			name: "request without metadata",
+			args: args{ctx: context.Background(), input: &proto.AdjustStockInput{
+				Sku: "sku.valid", Kind: proto.StockAdjustmentKind_RECEIVE, Quantity: 5, Reason: "restock",
+			}},
+			wantErr: true,
+		},
+		{
+			name: "AdjustStock service implementation with error",
+			args: args{ctx: ctxWithMetadata, input: &proto.AdjustStockInput{
+				Sku: "sku.invalid", Kind: proto.StockAdjustmentKind_SELL, Quantity: 2, Reason: "sold in store",
+			}},
+			wantErr: true,
+		},
+		{
+			name: "AdjustStock service implementation without error",
+			args: args{ctx: ctxWithMetadata, input: &proto.AdjustStockInput{
+				Sku: "sku.valid", Kind: proto.StockAdjustmentKind_RECEIVE, Quantity: 5, Reason: "restock",
+			}},
+			want: &proto.StockLevel{Sku: "sku.valid", OnHand: 7, Reserved: 2, Available: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewInventoryServer(inventoryService)
+			got, err := s.AdjustStock(tt.args.ctx, tt.args.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InventoryServer.AdjustStock() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InventoryServer.AdjustStock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryServer_GetStock(t *testing.T) {
+	inventoryService := &mocks.InventoryService{}
+	inventoryService.On("GetStock", mock.Anything, "sku.invalid").Return(nil, errors.New("an error occured"))
+	inventoryService.On("GetStock", mock.Anything, "sku.valid").Return(&inventory.StockLevel{Sku: "sku.valid", OnHand: 3}, nil)
+
+	tests := []struct {
+		name    string
+		input   *proto.GetStockInput
+		want    *proto.StockLevel
+		wantErr bool
+	}{
+		{
+			name:    "GetStock service implementation with error",
+			input:   &proto.GetStockInput{Sku: "sku.invalid"},
+			wantErr: true,
+		},
+		{
+			name:  "GetStock service implementation without error",
+			input: &proto.GetStockInput{Sku: "sku.valid"},
+			want:  &proto.StockLevel{Sku: "sku.valid", OnHand: 3, Available: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewInventoryServer(inventoryService)
+			got, err := s.GetStock(context.Background(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InventoryServer.GetStock() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InventoryServer.GetStock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryServer_ReserveStock(t *testing.T) {
+	expiresAt := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	inventoryService := &mocks.InventoryService{}
+	inventoryService.On("ReserveStock", mock.Anything, "jwtToken", "order.invalid", []inventory.ReservationItem{{Sku: "sku.1", Quantity: 2}}).
+		Return(nil, errors.New("an error occured"))
+	inventoryService.On("ReserveStock", mock.Anything, "jwtToken", "order.valid", []inventory.ReservationItem{{Sku: "sku.1", Quantity: 2}}).
+		Return(&inventory.Reservation{
+			OrderID:   "order.valid",
+			Status:    inventory.ReservationPending,
+			Items:     []inventory.ReservationItem{{Sku: "sku.1", Quantity: 2}},
+			ExpiresAt: expiresAt,
+		}, nil)
+
+	ctxWithMetadata := metadata.NewIncomingContext(context.TODO(), metadata.New(map[string]string{
+		"Authorization": "jwtToken",
+	}))
+	items := []*proto.ReservationItem{{Sku: "sku.1", Quantity: 2}}
+
+	type args struct {
+		ctx   context.Context
+		input *proto.ReserveStockInput
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *proto.Reservation
+		wantErr bool
+	}{
+		{
+			name:    "request without metadata",
+			args:    args{ctx: context.Background(), input: &proto.ReserveStockInput{OrderId: "order.valid", Items: items}},
+			wantErr: true,
+		},
+		{
+			name:    "ReserveStock service implementation with error",
+			args:    args{ctx: ctxWithMetadata, input: &proto.ReserveStockInput{OrderId: "order.invalid", Items: items}},
+			wantErr: true,
+		},
+		{
+			name: "ReserveStock service implementation without error",
+			args: args{ctx: ctxWithMetadata, input: &proto.ReserveStockInput{OrderId: "order.valid", Items: items}},
+			want: &proto.Reservation{
+				OrderId:   "order.valid",
+				Status:    proto.ReservationStatus_PENDING,
+				Items:     []*proto.ReservationItem{{Sku: "sku.1", Quantity: 2}},
+				ExpiresAt: timestamppb.New(expiresAt),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewInventoryServer(inventoryService)
+			got, err := s.ReserveStock(tt.args.ctx, tt.args.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InventoryServer.ReserveStock() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InventoryServer.ReserveStock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
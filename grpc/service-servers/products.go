@@ -4,14 +4,19 @@ import (
 	"context"
 	"errors"
 
-	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/ext"
-	"github.com/opentracing/opentracing-go/log"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// tracer starts the spans of the grpc servers with the global tracer
+// provider, the otelgrpc interceptors start their parent server spans.
+var tracer = otel.Tracer("github.com/wisdommatt/ecommerce-microservice-product-service/grpc/service-servers")
+
 type ProductServer struct {
 	proto.UnimplementedProductServiceServer
 	productService services.ProductService
@@ -25,45 +30,191 @@ func NewProductServer(productService services.ProductService) *ProductServer {
 }
 
 func (s *ProductServer) AddProduct(ctx context.Context, req *proto.NewProduct) (*proto.Product, error) {
-	span, _ := opentracing.StartSpanFromContext(ctx, "AddProduct")
-	defer span.Finish()
-	ext.SpanKindRPCServer.Set(span)
-	span.SetTag("request.body", req)
+	ctx, span := tracer.Start(ctx, "AddProduct")
+	defer span.End()
+	tracing.SetAttribute(span, "request.body", req)
 
-	metaData, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		ext.Error.Set(span, true)
-		span.LogFields(log.Error(errors.New("no meta data in grpc context")))
-		return nil, errors.New("no metadata sent, please try again later")
-	}
-	jwtToken := extractAuthorizationFromMetaData(metaData)
-	if jwtToken == "" {
-		ext.Error.Set(span, true)
-		span.LogFields(log.Error(errors.New("no authorization token in metadata")))
-		return nil, errors.New("no authorization token found in metadata")
+	jwtToken, err := extractJWTFromContext(ctx, span)
+	if err != nil {
+		return nil, toStatusError(err)
 	}
-	ctx = opentracing.ContextWithSpan(ctx, span)
 	newProduct, err := s.productService.AddProduct(ctx, jwtToken, ProtoNewProductToInternal(req))
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 	return InternalProductToProto(newProduct), nil
 }
 
 func (s *ProductServer) GetProduct(ctx context.Context, input *proto.GetProductInput) (*proto.Product, error) {
-	span, _ := opentracing.StartSpanFromContext(ctx, "GetProduct")
-	defer span.Finish()
-	ext.SpanKindRPCServer.Set(span)
-	span.SetTag("param.input", input)
+	ctx, span := tracer.Start(ctx, "GetProduct")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	// only admins and the merchant of a deleted product can retrieve it,
+	// the other requests do not need to be authenticated.
+	var jwtToken string
+	if input.IncludeDeleted {
+		var err error
+		jwtToken, err = extractJWTFromContext(ctx, span)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+	}
+	product, err := s.productService.GetProduct(ctx, jwtToken, input.Sku, input.IncludeDeleted)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return InternalProductToProto(product), nil
+}
+
+func (s *ProductServer) GetProducts(ctx context.Context, input *proto.GetProductsInput) (*proto.GetProductsResponse, error) {
+	ctx, span := tracer.Start(ctx, "GetProducts")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	productList, err := s.productService.GetProducts(ctx, input.Skus)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	response := &proto.GetProductsResponse{
+		Results: make([]*proto.GetProductsResult, 0, len(productList)),
+	}
+	for i, product := range productList {
+		result := &proto.GetProductsResult{Sku: input.Skus[i]}
+		if product == nil {
+			result.Error = "product does not exist"
+		} else {
+			result.Product = InternalProductToProto(product)
+		}
+		response.Results = append(response.Results, result)
+	}
+	return response, nil
+}
+
+func (s *ProductServer) UpdateProduct(ctx context.Context, input *proto.UpdateProductInput) (*proto.Product, error) {
+	ctx, span := tracer.Start(ctx, "UpdateProduct")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	jwtToken, err := extractJWTFromContext(ctx, span)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if input.Product == nil {
+		return nil, toStatusError(services.NewInvalidArgumentError("product must be provided", services.FieldViolation{
+			Field: "product", Description: "product must be provided",
+		}))
+	}
+	fields, err := ProtoUpdateMaskToInternal(input.UpdateMask)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	product, err := s.productService.UpdateProduct(ctx, jwtToken, input.Sku, ProtoNewProductToInternal(input.Product), fields)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return InternalProductToProto(product), nil
+}
+
+func (s *ProductServer) DeleteProduct(ctx context.Context, input *proto.DeleteProductInput) (*proto.Product, error) {
+	ctx, span := tracer.Start(ctx, "DeleteProduct")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	jwtToken, err := extractJWTFromContext(ctx, span)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	product, err := s.productService.DeleteProduct(ctx, jwtToken, input.Sku)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return InternalProductToProto(product), nil
+}
+
+func (s *ProductServer) RestoreProduct(ctx context.Context, input *proto.RestoreProductInput) (*proto.Product, error) {
+	ctx, span := tracer.Start(ctx, "RestoreProduct")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	jwtToken, err := extractJWTFromContext(ctx, span)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	product, err := s.productService.RestoreProduct(ctx, jwtToken, input.Sku)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return InternalProductToProto(product), nil
+}
+
+func (s *ProductServer) PurgeProduct(ctx context.Context, input *proto.PurgeProductInput) (*emptypb.Empty, error) {
+	ctx, span := tracer.Start(ctx, "PurgeProduct")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	jwtToken, err := extractJWTFromContext(ctx, span)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	err = s.productService.PurgeProduct(ctx, jwtToken, input.Sku)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
 
-	ctx = opentracing.ContextWithSpan(ctx, span)
-	product, err := s.productService.GetProduct(ctx, input.Sku)
+func (s *ProductServer) ListProducts(ctx context.Context, input *proto.ListProductsInput) (*proto.ListProductsResponse, error) {
+	ctx, span := tracer.Start(ctx, "ListProducts")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	productList, nextCursor, err := s.productService.ListProducts(ctx, ProtoListProductsInputToFilter(input), input.After)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	response := &proto.ListProductsResponse{
+		Products:   make([]*proto.Product, 0, len(productList)),
+		NextCursor: nextCursor,
+	}
+	for _, product := range productList {
+		response.Products = append(response.Products, InternalProductToProto(product))
+	}
+	return response, nil
+}
+
+func (s *ProductServer) GenerateVariants(ctx context.Context, input *proto.GenerateVariantsInput) (*proto.Product, error) {
+	ctx, span := tracer.Start(ctx, "GenerateVariants")
+	defer span.End()
+	tracing.SetAttribute(span, "param.input", input)
+
+	jwtToken, err := extractJWTFromContext(ctx, span)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	product, err := s.productService.GenerateVariants(ctx, jwtToken, input.Sku, ProtoOptionsToInternal(input.Options))
 	if err != nil {
-		return nil, err
+		return nil, toStatusError(err)
 	}
 	return InternalProductToProto(product), nil
 }
 
+// extractJWTFromContext retrieves the authorization token sent in the grpc
+// metadata of ctx.
+func extractJWTFromContext(ctx context.Context, span trace.Span) (string, error) {
+	metaData, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		tracing.RecordError(span, errors.New("no meta data in grpc context"), "extracting jwt from metadata")
+		return "", services.NewUnauthenticatedError("no metadata sent, please try again later", nil)
+	}
+	jwtToken := extractAuthorizationFromMetaData(metaData)
+	if jwtToken == "" {
+		tracing.RecordError(span, errors.New("no authorization token in metadata"), "extracting jwt from metadata")
+		return "", services.NewUnauthenticatedError("no authorization token found in metadata", nil)
+	}
+	return jwtToken, nil
+}
+
 func extractAuthorizationFromMetaData(md metadata.MD) string {
 	values := md.Get("Authorization")
 	if len(values) == 0 {
@@ -11,22 +11,25 @@ import (
 	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/mocks"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"gorm.io/gorm"
 )
 
 func TestProductServer_AddProduct(t *testing.T) {
 	productService := &mocks.ProductService{}
 	productService.On("AddProduct", mock.Anything, "jwtToken", ProtoNewProductToInternal(&proto.NewProduct{
 		Name:  "Product 1",
-		Price: 10000,
+		Price: &proto.Money{Amount: 10000, CurrencyCode: "USD"},
 	})).Return(nil, errors.New("an error occured"))
 
 	productService.On("AddProduct", mock.Anything, "jwtToken", ProtoNewProductToInternal(&proto.NewProduct{
 		Name:  "Product 2",
-		Price: 20000,
+		Price: &proto.Money{Amount: 20000, CurrencyCode: "USD"},
 	})).Return(&products.Product{
 		Sku:   "sku.123",
 		Name:  "Product 2",
-		Price: 20000,
+		Price: products.Money{Amount: 20000, Currency: "USD"},
 	}, nil)
 
 	authMetaData := metadata.New(map[string]string{
@@ -60,7 +63,7 @@ func TestProductServer_AddProduct(t *testing.T) {
 			name: "AddProduct service implementation with error",
 			args: args{ctx: ctxWithMetadata, req: &proto.NewProduct{
 				Name:  "Product 1",
-				Price: 10000,
+				Price: &proto.Money{Amount: 10000, CurrencyCode: "USD"},
 			}},
 			wantErr: true,
 		},
@@ -68,12 +71,12 @@ func TestProductServer_AddProduct(t *testing.T) {
 			name: "AddProduct service implementation with error",
 			args: args{ctx: ctxWithMetadata, req: &proto.NewProduct{
 				Name:  "Product 2",
-				Price: 20000,
+				Price: &proto.Money{Amount: 20000, CurrencyCode: "USD"},
 			}},
 			want: &proto.Product{
 				Sku:   "sku.123",
 				Name:  "Product 2",
-				Price: 20000,
+				Price: &proto.Money{Amount: 20000, CurrencyCode: "USD"},
 			},
 		},
 	}
@@ -94,12 +97,19 @@ func TestProductServer_AddProduct(t *testing.T) {
 
 func TestProductServer_GetProduct(t *testing.T) {
 	productService := &mocks.ProductService{}
-	productService.On("GetProduct", mock.Anything, "sku.invalid").Return(nil, errors.New("an error occured"))
-	productService.On("GetProduct", mock.Anything, "sku.valid").Return(&products.Product{
+	productService.On("GetProduct", mock.Anything, "", "sku.invalid", false).Return(nil, errors.New("an error occured"))
+	productService.On("GetProduct", mock.Anything, "", "sku.valid", false).Return(&products.Product{
 		Sku: "sku.valid", Name: "HP 2223", Description: "Slim HP laptop",
 	}, nil)
+	productService.On("GetProduct", mock.Anything, "jwtToken", "sku.deleted", true).Return(&products.Product{
+		Sku: "sku.deleted", Name: "HP 2223",
+	}, nil)
+	ctxWithMetadata := metadata.NewIncomingContext(context.TODO(), metadata.New(map[string]string{
+		"Authorization": "jwtToken",
+	}))
 
 	type args struct {
+		ctx   context.Context
 		input *proto.GetProductInput
 	}
 	tests := []struct {
@@ -110,19 +120,29 @@ func TestProductServer_GetProduct(t *testing.T) {
 	}{
 		{
 			name:    "GetProduct service implementation with error",
-			args:    args{input: &proto.GetProductInput{Sku: "sku.invalid"}},
+			args:    args{ctx: context.TODO(), input: &proto.GetProductInput{Sku: "sku.invalid"}},
 			wantErr: true,
 		},
 		{
 			name: "GetProduct service implementation without error",
-			args: args{input: &proto.GetProductInput{Sku: "sku.valid"}},
+			args: args{ctx: context.TODO(), input: &proto.GetProductInput{Sku: "sku.valid"}},
 			want: &proto.Product{Sku: "sku.valid", Name: "HP 2223", Description: "Slim HP laptop"},
 		},
+		{
+			name:    "deleted product without authorization",
+			args:    args{ctx: context.TODO(), input: &proto.GetProductInput{Sku: "sku.deleted", IncludeDeleted: true}},
+			wantErr: true,
+		},
+		{
+			name: "deleted product with authorization",
+			args: args{ctx: ctxWithMetadata, input: &proto.GetProductInput{Sku: "sku.deleted", IncludeDeleted: true}},
+			want: &proto.Product{Sku: "sku.deleted", Name: "HP 2223"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := NewProductServer(productService)
-			got, err := s.GetProduct(context.TODO(), tt.args.input)
+			got, err := s.GetProduct(tt.args.ctx, tt.args.input)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ProductServer.GetProduct() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -133,3 +153,402 @@ func TestProductServer_GetProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestProductServer_GetProducts(t *testing.T) {
+	productService := &mocks.ProductService{}
+	productService.On("GetProducts", mock.Anything, []string{"sku.invalid"}).Return(nil, errors.New("an error occured"))
+	productService.On("GetProducts", mock.Anything, []string{"sku.1", "sku.missing"}).Return([]*products.Product{
+		{Sku: "sku.1", Name: "HP 2223"}, nil,
+	}, nil)
+
+	type args struct {
+		input *proto.GetProductsInput
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *proto.GetProductsResponse
+		wantErr bool
+	}{
+		{
+			name:    "GetProducts service implementation with error",
+			args:    args{input: &proto.GetProductsInput{Skus: []string{"sku.invalid"}}},
+			wantErr: true,
+		},
+		{
+			name: "GetProducts service implementation without error",
+			args: args{input: &proto.GetProductsInput{Skus: []string{"sku.1", "sku.missing"}}},
+			want: &proto.GetProductsResponse{
+				Results: []*proto.GetProductsResult{
+					{Sku: "sku.1", Product: &proto.Product{Sku: "sku.1", Name: "HP 2223"}},
+					{Sku: "sku.missing", Error: "product does not exist"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductServer(productService)
+			got, err := s.GetProducts(context.TODO(), tt.args.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServer.GetProducts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServer.GetProducts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServer_UpdateProduct(t *testing.T) {
+	productService := &mocks.ProductService{}
+	productService.On("UpdateProduct", mock.Anything, "jwtToken", "sku.invalid", mock.Anything, []string{"Name"}).
+		Return(nil, errors.New("an error occured"))
+	productService.On("UpdateProduct", mock.Anything, "jwtToken", "sku.valid", &products.Product{Name: "HP 2224"}, []string{"Name"}).
+		Return(&products.Product{Sku: "sku.valid", Name: "HP 2224", Description: "Slim HP laptop"}, nil)
+
+	ctxWithMetadata := metadata.NewIncomingContext(context.TODO(), metadata.New(map[string]string{
+		"Authorization": "jwtToken",
+	}))
+
+	type args struct {
+		ctx   context.Context
+		input *proto.UpdateProductInput
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *proto.Product
+		wantErr bool
+	}{
+		{
+			name:    "request without metadata",
+			args:    args{ctx: context.Background(), input: &proto.UpdateProductInput{}},
+			wantErr: true,
+		},
+		{
+			name: "request without product",
+			args: args{ctx: ctxWithMetadata, input: &proto.UpdateProductInput{
+				Sku:        "sku.valid",
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "request with invalid update mask",
+			args: args{ctx: ctxWithMetadata, input: &proto.UpdateProductInput{
+				Sku:        "sku.valid",
+				Product:    &proto.NewProduct{Name: "HP 2224"},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"merchantId"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "UpdateProduct service implementation with error",
+			args: args{ctx: ctxWithMetadata, input: &proto.UpdateProductInput{
+				Sku:        "sku.invalid",
+				Product:    &proto.NewProduct{Name: "HP 2224"},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "UpdateProduct service implementation without error",
+			args: args{ctx: ctxWithMetadata, input: &proto.UpdateProductInput{
+				Sku:        "sku.valid",
+				Product:    &proto.NewProduct{Name: "HP 2224"},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+			}},
+			want: &proto.Product{Sku: "sku.valid", Name: "HP 2224", Description: "Slim HP laptop"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductServer(productService)
+			got, err := s.UpdateProduct(tt.args.ctx, tt.args.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServer.UpdateProduct() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServer.UpdateProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServer_DeleteProduct(t *testing.T) {
+	productService := &mocks.ProductService{}
+	productService.On("DeleteProduct", mock.Anything, "jwtToken", "sku.invalid").Return(nil, errors.New("an error occured"))
+	productService.On("DeleteProduct", mock.Anything, "jwtToken", "sku.valid").Return(&products.Product{
+		Sku: "sku.valid", Name: "HP 2223", DeletedAt: gorm.DeletedAt{Valid: true},
+	}, nil)
+
+	ctxWithMetadata := metadata.NewIncomingContext(context.TODO(), metadata.New(map[string]string{
+		"Authorization": "jwtToken",
+	}))
+
+	type args struct {
+		ctx   context.Context
+		input *proto.DeleteProductInput
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *proto.Product
+		wantErr bool
+	}{
+		{
+			name:    "request without metadata",
+			args:    args{ctx: context.Background(), input: &proto.DeleteProductInput{Sku: "sku.valid"}},
+			wantErr: true,
+		},
+		{
+			name:    "DeleteProduct service implementation with error",
+			args:    args{ctx: ctxWithMetadata, input: &proto.DeleteProductInput{Sku: "sku.invalid"}},
+			wantErr: true,
+		},
+		{
+			name: "DeleteProduct service implementation without error",
+			args: args{ctx: ctxWithMetadata, input: &proto.DeleteProductInput{Sku: "sku.valid"}},
+			want: &proto.Product{Sku: "sku.valid", Name: "HP 2223", Deleted: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductServer(productService)
+			got, err := s.DeleteProduct(tt.args.ctx, tt.args.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServer.DeleteProduct() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServer.DeleteProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServer_RestoreProduct(t *testing.T) {
+	productService := &mocks.ProductService{}
+	productService.On("RestoreProduct", mock.Anything, "jwtToken", "sku.invalid").Return(nil, errors.New("an error occured"))
+	productService.On("RestoreProduct", mock.Anything, "jwtToken", "sku.valid").Return(&products.Product{
+		Sku: "sku.valid", Name: "HP 2223",
+	}, nil)
+
+	ctxWithMetadata := metadata.NewIncomingContext(context.TODO(), metadata.New(map[string]string{
+		"Authorization": "jwtToken",
+	}))
+
+	type args struct {
+		ctx   context.Context
+		input *proto.RestoreProductInput
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *proto.Product
+		wantErr bool
+	}{
+		{
+			name:    "request without metadata",
+			args:    args{ctx: context.Background(), input: &proto.RestoreProductInput{Sku: "sku.valid"}},
+			wantErr: true,
+		},
+		{
+			name:    "RestoreProduct service implementation with error",
+			args:    args{ctx: ctxWithMetadata, input: &proto.RestoreProductInput{Sku: "sku.invalid"}},
+			wantErr: true,
+		},
+		{
+			name: "RestoreProduct service implementation without error",
+			args: args{ctx: ctxWithMetadata, input: &proto.RestoreProductInput{Sku: "sku.valid"}},
+			want: &proto.Product{Sku: "sku.valid", Name: "HP 2223"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductServer(productService)
+			got, err := s.RestoreProduct(tt.args.ctx, tt.args.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServer.RestoreProduct() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServer.RestoreProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServer_PurgeProduct(t *testing.T) {
+	productService := &mocks.ProductService{}
+	productService.On("PurgeProduct", mock.Anything, "jwtToken", "sku.invalid").Return(errors.New("an error occured"))
+	productService.On("PurgeProduct", mock.Anything, "jwtToken", "sku.valid").Return(nil)
+
+	ctxWithMetadata := metadata.NewIncomingContext(context.TODO(), metadata.New(map[string]string{
+		"Authorization": "jwtToken",
+	}))
+
+	type args struct {
+		ctx   context.Context
+		input *proto.PurgeProductInput
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *emptypb.Empty
+		wantErr bool
+	}{
+		{
+			name:    "request without metadata",
+			args:    args{ctx: context.Background(), input: &proto.PurgeProductInput{Sku: "sku.valid"}},
+			wantErr: true,
+		},
+		{
+			name:    "PurgeProduct service implementation with error",
+			args:    args{ctx: ctxWithMetadata, input: &proto.PurgeProductInput{Sku: "sku.invalid"}},
+			wantErr: true,
+		},
+		{
+			name: "PurgeProduct service implementation without error",
+			args: args{ctx: ctxWithMetadata, input: &proto.PurgeProductInput{Sku: "sku.valid"}},
+			want: &emptypb.Empty{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductServer(productService)
+			got, err := s.PurgeProduct(tt.args.ctx, tt.args.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServer.PurgeProduct() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServer.PurgeProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServer_ListProducts(t *testing.T) {
+	productService := &mocks.ProductService{}
+	productService.On("ListProducts", mock.Anything, products.ListFilter{
+		Category: "invalid", SortBy: products.SortByTimeAdded,
+	}, "").Return(nil, "", errors.New("an error occured"))
+	productService.On("ListProducts", mock.Anything, products.ListFilter{
+		Category: "laptops", SortBy: products.SortByPrice, Limit: 2,
+	}, "cursor.1").Return([]*products.Product{
+		{Sku: "sku.1", Name: "HP 2223"},
+		{Sku: "sku.2", Name: "HP 2224"},
+	}, "cursor.2", nil)
+
+	type args struct {
+		input *proto.ListProductsInput
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *proto.ListProductsResponse
+		wantErr bool
+	}{
+		{
+			name:    "ListProducts service implementation with error",
+			args:    args{input: &proto.ListProductsInput{Category: "invalid"}},
+			wantErr: true,
+		},
+		{
+			name: "ListProducts service implementation without error",
+			args: args{input: &proto.ListProductsInput{
+				Category: "laptops", SortBy: proto.ProductSortField_PRICE, Limit: 2, After: "cursor.1",
+			}},
+			want: &proto.ListProductsResponse{
+				Products: []*proto.Product{
+					{Sku: "sku.1", Name: "HP 2223"},
+					{Sku: "sku.2", Name: "HP 2224"},
+				},
+				NextCursor: "cursor.2",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductServer(productService)
+			got, err := s.ListProducts(context.TODO(), tt.args.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServer.ListProducts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServer.ListProducts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServer_GenerateVariants(t *testing.T) {
+	sizes := []products.Option{{Name: "size", Values: products.StringList{"S", "M"}}}
+	productService := &mocks.ProductService{}
+	productService.On("GenerateVariants", mock.Anything, "jwtToken", "sku.invalid", sizes).Return(nil, errors.New("an error occured"))
+	productService.On("GenerateVariants", mock.Anything, "jwtToken", "sku.valid", sizes).Return(&products.Product{
+		Sku:     "sku.valid",
+		Options: sizes,
+		Variants: []products.Variant{
+			{Sku: "variant.s", Options: products.OptionValues{"size": "S"}},
+			{Sku: "variant.m", Options: products.OptionValues{"size": "M"}},
+		},
+	}, nil)
+
+	ctxWithMetadata := metadata.NewIncomingContext(context.TODO(), metadata.New(map[string]string{
+		"Authorization": "jwtToken",
+	}))
+	protoSizes := []*proto.ProductOption{{Name: "size", Values: []string{"S", "M"}}}
+
+	type args struct {
+		ctx   context.Context
+		input *proto.GenerateVariantsInput
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *proto.Product
+		wantErr bool
+	}{
+		{
+			name:    "request without metadata",
+			args:    args{ctx: context.Background(), input: &proto.GenerateVariantsInput{Sku: "sku.valid", Options: protoSizes}},
+			wantErr: true,
+		},
+		{
+			name:    "GenerateVariants service implementation with error",
+			args:    args{ctx: ctxWithMetadata, input: &proto.GenerateVariantsInput{Sku: "sku.invalid", Options: protoSizes}},
+			wantErr: true,
+		},
+		{
+			name: "GenerateVariants service implementation without error",
+			args: args{ctx: ctxWithMetadata, input: &proto.GenerateVariantsInput{Sku: "sku.valid", Options: protoSizes}},
+			want: &proto.Product{
+				Sku:     "sku.valid",
+				Options: protoSizes,
+				Variants: []*proto.Variant{
+					{Sku: "variant.s", Options: map[string]string{"size": "S"}},
+					{Sku: "variant.m", Options: map[string]string{"size": "M"}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductServer(productService)
+			got, err := s.GenerateVariants(tt.args.ctx, tt.args.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServer.GenerateVariants() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServer.GenerateVariants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,18 +1,37 @@
 package serviceservers
 
 import (
+	"fmt"
+
 	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/inventory"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// productUpdateMaskFields maps the NewProduct field mask paths to their
+// internal product field names.
+var productUpdateMaskFields = map[string]string{
+	"name":        "Name",
+	"description": "Description",
+	"category":    "Category",
+	"brand":       "Brand",
+	"price":       "Price",
+	"imageUrl":    "ImageURL",
+}
+
 func ProtoNewProductToInternal(newProduct *proto.NewProduct) *products.Product {
 	return &products.Product{
 		Name:        newProduct.Name,
 		Description: newProduct.Description,
 		Category:    newProduct.Category,
 		Brand:       newProduct.Brand,
-		Price:       newProduct.Price,
+		Price:       ProtoMoneyToInternal(newProduct.Price),
 		ImageURL:    newProduct.ImageUrl,
+		Options:     ProtoOptionsToInternal(newProduct.Options),
+		Variants:    ProtoNewVariantsToInternal(newProduct.Variants),
 	}
 }
 
@@ -23,8 +42,206 @@ func InternalProductToProto(product *products.Product) *proto.Product {
 		Description: product.Description,
 		Category:    product.Category,
 		Brand:       product.Brand,
-		Price:       product.Price,
+		Price:       InternalMoneyToProto(product.Price),
 		ImageUrl:    product.ImageURL,
 		MerchantId:  product.MerchantID,
+		Deleted:     product.DeletedAt.Valid,
+		Options:     InternalOptionsToProto(product.Options),
+		Variants:    InternalVariantsToProto(product.Variants),
+	}
+}
+
+// ProtoOptionsToInternal returns nil for a product without options.
+func ProtoOptionsToInternal(options []*proto.ProductOption) []products.Option {
+	if len(options) == 0 {
+		return nil
+	}
+	internalOptions := make([]products.Option, 0, len(options))
+	for _, option := range options {
+		internalOptions = append(internalOptions, products.Option{
+			Name:   option.Name,
+			Values: option.Values,
+		})
+	}
+	return internalOptions
+}
+
+// ProtoNewVariantsToInternal returns nil for a product without variants.
+func ProtoNewVariantsToInternal(variants []*proto.NewVariant) []products.Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+	internalVariants := make([]products.Variant, 0, len(variants))
+	for _, variant := range variants {
+		internalVariants = append(internalVariants, products.Variant{
+			Options:  variant.Options,
+			Price:    ProtoMoneyToInternal(variant.Price),
+			ImageURL: variant.ImageUrl,
+		})
+	}
+	return internalVariants
+}
+
+// InternalOptionsToProto returns nil for a product without options.
+func InternalOptionsToProto(options []products.Option) []*proto.ProductOption {
+	if len(options) == 0 {
+		return nil
+	}
+	protoOptions := make([]*proto.ProductOption, 0, len(options))
+	for _, option := range options {
+		protoOptions = append(protoOptions, &proto.ProductOption{
+			Name:   option.Name,
+			Values: option.Values,
+		})
+	}
+	return protoOptions
+}
+
+// InternalVariantsToProto returns nil for a product without variants.
+func InternalVariantsToProto(variants []products.Variant) []*proto.Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+	protoVariants := make([]*proto.Variant, 0, len(variants))
+	for _, variant := range variants {
+		protoVariants = append(protoVariants, &proto.Variant{
+			Sku:      variant.Sku,
+			Options:  variant.Options,
+			Price:    InternalMoneyToProto(variant.Price),
+			ImageUrl: variant.ImageURL,
+		})
+	}
+	return protoVariants
+}
+
+func ProtoMoneyToInternal(money *proto.Money) products.Money {
+	return products.Money{
+		Amount:   money.GetAmount(),
+		Currency: money.GetCurrencyCode(),
+	}
+}
+
+// InternalMoneyToProto returns nil for a price that is not set.
+func InternalMoneyToProto(money products.Money) *proto.Money {
+	if money == (products.Money{}) {
+		return nil
+	}
+	return &proto.Money{
+		Amount:       money.Amount,
+		CurrencyCode: money.Currency,
+	}
+}
+
+func ProtoUpdateMaskToInternal(updateMask *fieldmaskpb.FieldMask) ([]string, error) {
+	fields := []string{}
+	for _, path := range updateMask.GetPaths() {
+		field, ok := productUpdateMaskFields[path]
+		if !ok {
+			message := fmt.Sprintf("%s is not an updatable product field", path)
+			return nil, services.NewInvalidArgumentError(message, services.FieldViolation{
+				Field: "updateMask.paths", Description: message,
+			})
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// productSortFields maps the proto sort fields to their internal
+// equivalent.
+var productSortFields = map[proto.ProductSortField]products.SortField{
+	proto.ProductSortField_TIME_ADDED: products.SortByTimeAdded,
+	proto.ProductSortField_PRICE:      products.SortByPrice,
+	proto.ProductSortField_NAME:       products.SortByName,
+}
+
+func ProtoListProductsInputToFilter(input *proto.ListProductsInput) products.ListFilter {
+	filter := products.ListFilter{
+		MerchantID: input.MerchantId,
+		Category:   input.Category,
+		Brand:      input.Brand,
+		Currency:   input.CurrencyCode,
+		SortBy:     productSortFields[input.SortBy],
+		Descending: input.Descending,
+		Limit:      int(input.Limit),
+	}
+	if input.MinPrice != nil {
+		minPrice := input.MinPrice.Value
+		filter.MinPrice = &minPrice
+	}
+	if input.MaxPrice != nil {
+		maxPrice := input.MaxPrice.Value
+		filter.MaxPrice = &maxPrice
+	}
+	if input.AddedAfter != nil {
+		addedAfter := input.AddedAfter.AsTime()
+		filter.AddedAfter = &addedAfter
+	}
+	if input.AddedBefore != nil {
+		addedBefore := input.AddedBefore.AsTime()
+		filter.AddedBefore = &addedBefore
+	}
+	return filter
+}
+
+var stockAdjustmentKinds = map[proto.StockAdjustmentKind]inventory.AdjustmentKind{
+	proto.StockAdjustmentKind_RECEIVE:    inventory.KindReceive,
+	proto.StockAdjustmentKind_SELL:       inventory.KindSell,
+	proto.StockAdjustmentKind_RETURN:     inventory.KindReturn,
+	proto.StockAdjustmentKind_CORRECTION: inventory.KindCorrection,
+}
+
+// ProtoStockAdjustmentKindToInternal returns an empty kind for an
+// unspecified or unknown kind.
+func ProtoStockAdjustmentKindToInternal(kind proto.StockAdjustmentKind) inventory.AdjustmentKind {
+	return stockAdjustmentKinds[kind]
+}
+
+func InternalStockLevelToProto(level *inventory.StockLevel) *proto.StockLevel {
+	protoLevel := &proto.StockLevel{
+		Sku:       level.Sku,
+		OnHand:    level.OnHand,
+		Reserved:  level.Reserved,
+		Available: level.Available(),
+	}
+	if !level.UpdatedAt.IsZero() {
+		protoLevel.UpdatedAt = timestamppb.New(level.UpdatedAt)
+	}
+	return protoLevel
+}
+
+var reservationStatuses = map[inventory.ReservationStatus]proto.ReservationStatus{
+	inventory.ReservationPending:   proto.ReservationStatus_PENDING,
+	inventory.ReservationCommitted: proto.ReservationStatus_COMMITTED,
+	inventory.ReservationReleased:  proto.ReservationStatus_RELEASED,
+	inventory.ReservationExpired:   proto.ReservationStatus_EXPIRED,
+}
+
+func ProtoReservationItemsToInternal(items []*proto.ReservationItem) []inventory.ReservationItem {
+	internalItems := make([]inventory.ReservationItem, 0, len(items))
+	for _, item := range items {
+		internalItems = append(internalItems, inventory.ReservationItem{
+			Sku:      item.Sku,
+			Quantity: item.Quantity,
+		})
+	}
+	return internalItems
+}
+
+func InternalReservationToProto(reservation *inventory.Reservation) *proto.Reservation {
+	protoReservation := &proto.Reservation{
+		OrderId: reservation.OrderID,
+		Items:   make([]*proto.ReservationItem, 0, len(reservation.Items)),
+		Status:  reservationStatuses[reservation.Status],
+	}
+	for _, item := range reservation.Items {
+		protoReservation.Items = append(protoReservation.Items, &proto.ReservationItem{
+			Sku:      item.Sku,
+			Quantity: item.Quantity,
+		})
+	}
+	if !reservation.ExpiresAt.IsZero() {
+		protoReservation.ExpiresAt = timestamppb.New(reservation.ExpiresAt)
 	}
+	return protoReservation
 }
@@ -3,9 +3,13 @@ package serviceservers
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestProtoNewProductToInternal(t *testing.T) {
@@ -35,14 +39,35 @@ func TestProtoNewProductToInternal(t *testing.T) {
 				Description: "Cute pink slippers",
 				Category:    "slippers",
 				Brand:       "Nike",
-				Price:       100000,
+				Price:       &proto.Money{Amount: 100000, CurrencyCode: "USD"},
 			}},
 			want: &products.Product{
 				Name:        "Pink Slippers",
 				Description: "Cute pink slippers",
 				Category:    "slippers",
 				Brand:       "Nike",
-				Price:       100000,
+				Price:       products.Money{Amount: 100000, Currency: "USD"},
+			},
+		},
+		{
+			name: "product with variants",
+			args: args{newProduct: &proto.NewProduct{
+				Name:    "T-Shirt",
+				Price:   &proto.Money{Amount: 1500, CurrencyCode: "USD"},
+				Options: []*proto.ProductOption{{Name: "size", Values: []string{"S", "M"}}},
+				Variants: []*proto.NewVariant{
+					{Options: map[string]string{"size": "S"}},
+					{Options: map[string]string{"size": "M"}, Price: &proto.Money{Amount: 1700, CurrencyCode: "USD"}},
+				},
+			}},
+			want: &products.Product{
+				Name:    "T-Shirt",
+				Price:   products.Money{Amount: 1500, Currency: "USD"},
+				Options: []products.Option{{Name: "size", Values: products.StringList{"S", "M"}}},
+				Variants: []products.Variant{
+					{Options: products.OptionValues{"size": "S"}},
+					{Options: products.OptionValues{"size": "M"}, Price: products.Money{Amount: 1700, Currency: "USD"}},
+				},
 			},
 		},
 	}
@@ -83,7 +108,7 @@ func TestInternalProductToProto(t *testing.T) {
 				Description: "This is a nice blue canvas",
 				Category:    "Fashion",
 				Brand:       "Addidas",
-				Price:       9009999,
+				Price:       products.Money{Amount: 9009999, Currency: "USD"},
 				MerchantID:  "helloWooo",
 			}},
 			want: &proto.Product{
@@ -92,7 +117,7 @@ func TestInternalProductToProto(t *testing.T) {
 				Description: "This is a nice blue canvas",
 				Category:    "Fashion",
 				Brand:       "Addidas",
-				Price:       9009999,
+				Price:       &proto.Money{Amount: 9009999, CurrencyCode: "USD"},
 				MerchantId:  "helloWooo",
 			},
 		},
@@ -105,3 +130,96 @@ func TestInternalProductToProto(t *testing.T) {
 		})
 	}
 }
+
+func TestProtoUpdateMaskToInternal(t *testing.T) {
+	type args struct {
+		updateMask *fieldmaskpb.FieldMask
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "nil update mask",
+			args: args{updateMask: nil},
+			want: []string{},
+		},
+		{
+			name:    "path that cannot be updated",
+			args:    args{updateMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "sku"}}},
+			wantErr: true,
+		},
+		{
+			name: "valid paths",
+			args: args{updateMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "imageUrl", "price"}}},
+			want: []string{"Name", "ImageURL", "Price"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProtoUpdateMaskToInternal(tt.args.updateMask)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProtoUpdateMaskToInternal() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProtoUpdateMaskToInternal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtoListProductsInputToFilter(t *testing.T) {
+	minPrice, maxPrice := int64(1000), int64(5000)
+	addedAfter := time.Date(2021, time.November, 1, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		input *proto.ListProductsInput
+	}
+	tests := []struct {
+		name string
+		args args
+		want products.ListFilter
+	}{
+		{
+			name: "empty input",
+			args: args{input: &proto.ListProductsInput{}},
+			want: products.ListFilter{SortBy: products.SortByTimeAdded},
+		},
+		{
+			name: "complete fields",
+			args: args{input: &proto.ListProductsInput{
+				MerchantId:   "merchant.1",
+				Category:     "shoes",
+				Brand:        "Nike",
+				CurrencyCode: "USD",
+				MinPrice:     wrapperspb.Int64(minPrice),
+				MaxPrice:     wrapperspb.Int64(maxPrice),
+				AddedAfter:   timestamppb.New(addedAfter),
+				SortBy:       proto.ProductSortField_NAME,
+				Descending:   true,
+				Limit:        50,
+			}},
+			want: products.ListFilter{
+				MerchantID: "merchant.1",
+				Category:   "shoes",
+				Brand:      "Nike",
+				Currency:   "USD",
+				MinPrice:   &minPrice,
+				MaxPrice:   &maxPrice,
+				AddedAfter: &addedAfter,
+				SortBy:     products.SortByName,
+				Descending: true,
+				Limit:      50,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProtoListProductsInputToFilter(tt.args.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProtoListProductsInputToFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Package config loads the configuration of the product service from
+// defaults, an optional dotenv file, environment variables and command
+// line flags, in increasing order of precedence, and validates it.
+//
+// Every setting has an environment variable and a flag named after it,
+// e.g NATS_URI can be set with --nats-uri.
+package config
+
+import (
+	"time"
+)
+
+// Config is the configuration of the product service.
+type Config struct {
+	// PrintConfig is set by the --print-config flag, the effective
+	// configuration is printed with secrets masked instead of serving.
+	PrintConfig bool
+
+	Port        string `env:"PORT" default:"2424" usage:"port of the grpc server"`
+	MetricsAddr string `env:"METRICS_ADDR" default:":9090" usage:"address of the prometheus /metrics endpoint"`
+
+	Shutdown    ShutdownConfig
+	Health      HealthConfig
+	MySQL       MySQLConfig
+	UserService UserServiceConfig
+	Products    ProductsConfig
+	Inventory   InventoryConfig
+	Outbox      OutboxConfig
+	Events      EventsConfig
+	NATS        NATSConfig
+	Stream      StreamConfig
+	Publish     PublishConfig
+	Subscriber  SubscriberConfig
+	Tracing     TracingConfig
+}
+
+// ShutdownConfig is the configuration of the graceful shutdown.
+type ShutdownConfig struct {
+	Delay        time.Duration `env:"SHUTDOWN_DELAY" default:"5s" usage:"time the service is NOT_SERVING before it stops accepting requests, so that load balancers stop sending requests"`
+	Timeout      time.Duration `env:"SHUTDOWN_TIMEOUT" default:"25s" usage:"time the service has to shut down, less than the kubernetes termination grace period"`
+	DrainTimeout time.Duration `env:"SHUTDOWN_DRAIN_TIMEOUT" default:"15s" usage:"time the in-flight requests have to finish before they are canceled"`
+}
+
+// HealthConfig is the configuration of the dependency checks of the grpc
+// health service.
+type HealthConfig struct {
+	CheckInterval       time.Duration `env:"HEALTH_CHECK_INTERVAL" default:"1s" usage:"interval the expired dependency checks are run"`
+	MySQLTimeout        time.Duration `env:"HEALTH_MYSQL_TIMEOUT" default:"1s" usage:"timeout of the mysql ping"`
+	MySQLCacheTTL       time.Duration `env:"HEALTH_MYSQL_CACHE_TTL" default:"5s" usage:"time the result of the mysql ping is reused"`
+	NATSTimeout         time.Duration `env:"HEALTH_NATS_TIMEOUT" default:"1s" usage:"timeout of the nats connection check"`
+	NATSCacheTTL        time.Duration `env:"HEALTH_NATS_CACHE_TTL" default:"1s" usage:"time the result of the nats connection check is reused"`
+	UserServiceTimeout  time.Duration `env:"HEALTH_USER_SERVICE_TIMEOUT" default:"2s" usage:"timeout of the user service reachability check"`
+	UserServiceCacheTTL time.Duration `env:"HEALTH_USER_SERVICE_CACHE_TTL" default:"10s" usage:"time the result of the user service reachability check is reused"`
+}
+
+// MySQLConfig is the configuration of the database and its connection
+// pool.
+type MySQLConfig struct {
+	Connection      string        `env:"MYSQL_CONNECTION" default:"root:root@tcp(127.0.0.1:3346)/product_service?charset=utf8&parseTime=true" secret:"password" usage:"mysql data source name"`
+	MaxOpenConns    int           `env:"MYSQL_MAX_OPEN_CONNS" default:"25" usage:"maximum number of open connections, 0 is unlimited"`
+	MaxIdleConns    int           `env:"MYSQL_MAX_IDLE_CONNS" default:"5" usage:"maximum number of idle connections"`
+	ConnMaxLifetime time.Duration `env:"MYSQL_CONN_MAX_LIFETIME" default:"30m" usage:"maximum time a connection is reused, 0 is forever"`
+	ConnMaxIdleTime time.Duration `env:"MYSQL_CONN_MAX_IDLE_TIME" default:"5m" usage:"maximum time a connection is idle, 0 is forever"`
+}
+
+// UserServiceConfig is the configuration of the user service client.
+type UserServiceConfig struct {
+	Addr    string        `env:"USER_SERVICE_ADDR" default:"localhost:2020" usage:"address of the user service"`
+	Timeout time.Duration `env:"USER_SERVICE_TIMEOUT" default:"5s" usage:"timeout of the user service calls"`
+}
+
+// ProductsConfig is the configuration of the product service.
+type ProductsConfig struct {
+	AdminUserIDs []string `env:"ADMIN_USER_IDS" usage:"ids of the users allowed to purge products"`
+	// CursorSecret signs the product list cursors, a random secret is
+	// generated when it is empty.
+	CursorSecret        string `env:"PRODUCT_CURSOR_SECRET" secret:"true" usage:"secret signing the product list cursors"`
+	LegacyPriceCurrency string `env:"LEGACY_PRICE_CURRENCY" default:"USD" usage:"currency of the prices saved before prices had a currency"`
+}
+
+// InventoryConfig is the configuration of the stock reservations.
+type InventoryConfig struct {
+	ReservationTTL           time.Duration `env:"STOCK_RESERVATION_TTL" default:"15m" usage:"time a stock reservation is held"`
+	ReservationSweepInterval time.Duration `env:"STOCK_RESERVATION_SWEEP_INTERVAL" default:"1m" usage:"interval of the expired reservations sweeper"`
+}
+
+// OutboxConfig is the configuration of the outbox relay.
+type OutboxConfig struct {
+	RelayInterval time.Duration `env:"OUTBOX_RELAY_INTERVAL" default:"1s" usage:"interval the outbox is relayed to nats"`
+	MaxAttempts   int           `env:"OUTBOX_MAX_ATTEMPTS" default:"10" usage:"failed attempts after which an outbox message is parked"`
+}
+
+// EventsConfig is the configuration of the published events.
+type EventsConfig struct {
+	Encoding       string   `env:"EVENT_ENCODING" default:"legacy" usage:"encoding of the events, legacy, cloudevents-structured or cloudevents-binary"`
+	Source         string   `env:"EVENT_SOURCE" default:"/product-service" usage:"cloudevents source of the events"`
+	LegacySubjects []string `env:"EVENT_LEGACY_SUBJECTS" default:"notification.>,products.ProductDeleted,products.ProductRestored" usage:"subjects always published with the legacy encoding"`
+}
+
+// NATSConfig is the configuration of the nats connection and the subjects
+// the service subscribes to.
+type NATSConfig struct {
+	URI                   string   `env:"NATS_URI" default:"nats://localhost:4222" secret:"password" usage:"url of the nats server"`
+	RPCSubjectPrefix      string   `env:"NATS_RPC_SUBJECT_PREFIX" default:"products.rpc" usage:"prefix of the nats request-reply subjects"`
+	UserRemovedSubjects   []string `env:"USER_REMOVED_SUBJECTS" default:"user.deleted,user.suspended" usage:"subjects of the user deleted and suspended events"`
+	OrderPlacedSubject    string   `env:"ORDER_PLACED_SUBJECT" default:"order.placed" usage:"subject of the order placed events"`
+	OrderCancelledSubject string   `env:"ORDER_CANCELLED_SUBJECT" default:"order.cancelled" usage:"subject of the order cancelled events"`
+}
+
+// StreamConfig is the configuration of the jetstream events stream.
+type StreamConfig struct {
+	Enabled         bool          `env:"NATS_JETSTREAM_ENABLED" default:"false" usage:"persist the events in a jetstream stream"`
+	Name            string        `env:"NATS_STREAM_NAME" default:"PRODUCT_EVENTS" usage:"name of the jetstream stream"`
+	Subjects        []string      `env:"NATS_STREAM_SUBJECTS" default:"products.>,inventory.>" usage:"subjects captured by the stream"`
+	Retention       string        `env:"NATS_STREAM_RETENTION" default:"limits" usage:"retention policy of the stream, limits, interest or workqueue"`
+	MaxAge          time.Duration `env:"NATS_STREAM_MAX_AGE" default:"720h" usage:"maximum age of the stream messages"`
+	MaxMsgs         int64         `env:"NATS_STREAM_MAX_MSGS" default:"0" usage:"maximum number of stream messages, 0 is unlimited"`
+	MaxBytes        int64         `env:"NATS_STREAM_MAX_BYTES" default:"0" usage:"maximum size of the stream, 0 is unlimited"`
+	DuplicateWindow time.Duration `env:"NATS_STREAM_DUPLICATE_WINDOW" default:"2m" usage:"window duplicate messages are dropped in"`
+	Replicas        int           `env:"NATS_STREAM_REPLICAS" default:"1" usage:"number of stream replicas"`
+}
+
+// PublishConfig is the configuration of the event publisher.
+type PublishConfig struct {
+	MaxAttempts      int           `env:"NATS_PUBLISH_MAX_ATTEMPTS" default:"3" usage:"attempts of a publish before the message is spooled"`
+	RetryDelay       time.Duration `env:"NATS_PUBLISH_RETRY_DELAY" default:"100ms" usage:"delay before the first publish retry"`
+	MaxRetryDelay    time.Duration `env:"NATS_PUBLISH_MAX_RETRY_DELAY" default:"2s" usage:"maximum delay between publish retries"`
+	SpoolDir         string        `env:"NATS_PUBLISH_SPOOL_DIR" default:"spool" usage:"directory of the messages that could not be published"`
+	SpoolInterval    time.Duration `env:"NATS_PUBLISH_SPOOL_INTERVAL" default:"5s" usage:"interval the spool is published"`
+	DeadLetterPrefix string        `env:"NATS_PUBLISH_DEAD_LETTER_PREFIX" default:"dlq.product-service.publish" usage:"subject prefix of the messages nats rejected"`
+}
+
+// SubscriberConfig is the configuration of the nats subscriber.
+type SubscriberConfig struct {
+	Queue                    string        `env:"NATS_SUBSCRIBER_QUEUE" default:"product-service" usage:"queue group of the subscriptions"`
+	DeadLetterPrefix         string        `env:"NATS_DEAD_LETTER_PREFIX" default:"dlq.product-service" usage:"subject prefix of the messages that could not be handled"`
+	MaxAttempts              int           `env:"NATS_SUBSCRIBER_MAX_ATTEMPTS" default:"3" usage:"attempts of a message before it is dead lettered"`
+	RetryDelay               time.Duration `env:"NATS_SUBSCRIBER_RETRY_DELAY" default:"1s" usage:"delay before the first retry of a message"`
+	HashDedupWindow          time.Duration `env:"NATS_SUBSCRIBER_HASH_DEDUP_WINDOW" default:"10m" usage:"time a message without a Nats-Msg-Id is not handled again when the same data is received"`
+	ProcessedRetention       time.Duration `env:"NATS_SUBSCRIBER_PROCESSED_RETENTION" default:"168h" usage:"time the ids of the handled messages are kept"`
+	ProcessedCleanupInterval time.Duration `env:"NATS_SUBSCRIBER_PROCESSED_CLEANUP_INTERVAL" default:"1h" usage:"interval the expired ids of the handled messages are deleted"`
+}
+
+// TracingConfig is the configuration of opentelemetry tracing.
+type TracingConfig struct {
+	ServiceName         string   `env:"TRACING_SERVICE_NAME" default:"product-service" usage:"service name of the spans"`
+	OTLPEndpoint        string   `env:"TRACING_OTLP_ENDPOINT" usage:"host:port of the OTLP gRPC collector, spans are not exported when it is empty"`
+	OTLPInsecure        bool     `env:"TRACING_OTLP_INSECURE" default:"false" usage:"connect to the collector without TLS"`
+	Sampler             string   `env:"TRACING_SAMPLER" default:"parentbased_always_on" usage:"sampler of the traces"`
+	SamplerArg          float64  `env:"TRACING_SAMPLER_ARG" default:"1" usage:"ratio of the ratio samplers"`
+	LegacyPropagation   bool     `env:"TRACING_LEGACY_PROPAGATION" default:"true" usage:"read the span context of legacy not.TraceMsg messages"`
+	AttributesAllow     []string `env:"TRACING_ATTRIBUTES_ALLOW" usage:"span attribute keys that are recorded, every key when empty"`
+	AttributesRedact    []string `env:"TRACING_ATTRIBUTES_REDACT" default:"password,token,jwtToken,authorization" usage:"span attribute fields that are redacted"`
+	AttributesHash      []string `env:"TRACING_ATTRIBUTES_HASH" default:"email,phone" usage:"span attribute fields that are hashed, they are redacted while TRACING_ATTRIBUTES_HASH_KEY is not set"`
+	AttributesHashKey   string   `env:"TRACING_ATTRIBUTES_HASH_KEY" secret:"true" usage:"HMAC key of the hashed span attribute fields, the fields are redacted when it is not set"`
+	AttributesMaxLength int      `env:"TRACING_ATTRIBUTES_MAX_LENGTH" default:"1024" usage:"maximum length of span attribute values, 0 is unlimited"`
+}
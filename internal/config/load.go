@@ -0,0 +1,294 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// DefaultFile is the dotenv file loaded when no --config flag is set, it
+// is optional.
+const DefaultFile = ".env"
+
+// maskedValue replaces secrets when the configuration is printed.
+const maskedValue = "********"
+
+// setting is a configuration field with an environment variable.
+type setting struct {
+	env      string
+	fallback string
+	usage    string
+	secret   string
+	value    reflect.Value
+}
+
+// Load returns the configuration of the service from args, the command
+// line arguments without the program name, and the environment variables
+// looked up with lookupEnv e.g os.LookupEnv. The configuration is returned
+// with the validation error when it is invalid, so it can still be
+// printed. flag.ErrHelp is returned when -h or --help is set.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := &Config{}
+	settings := cfg.settings()
+	flags := flag.NewFlagSet("product-service", flag.ContinueOnError)
+	file := flags.String("config", "", "dotenv file the configuration is loaded from, "+DefaultFile+" when it exists")
+	flags.BoolVar(&cfg.PrintConfig, "print-config", false, "print the effective configuration with secrets masked and exit")
+	flagSettings := map[string]*setting{}
+	for _, s := range settings {
+		name := flagName(s.env)
+		flagSettings[name] = s
+		flags.String(name, s.fallback, s.usage+" ("+s.env+")")
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := lookupValues(settings, *file, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if s, ok := flagSettings[f.Name]; ok {
+			values[s.env] = f.Value.String()
+		}
+	})
+
+	err = setValues(settings, values)
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// LoadSections loads sections, pointers to the structs of Config e.g
+// *NATSConfig, from DefaultFile and the environment variables looked up
+// with lookupEnv. Only the settings of sections are read and they are not
+// validated, so that commands that need a part of the configuration do
+// not need to configure the whole service.
+func LoadSections(lookupEnv func(string) (string, bool), sections ...interface{}) error {
+	settings := []*setting{}
+	for _, section := range sections {
+		settings = append(settings, collectSettings(reflect.ValueOf(section).Elem())...)
+	}
+	values, err := lookupValues(settings, "", lookupEnv)
+	if err != nil {
+		return err
+	}
+	return setValues(settings, values)
+}
+
+// lookupValues returns the values of settings by environment variable,
+// from their default, the dotenv file at path and lookupEnv, the last one
+// that is set wins.
+func lookupValues(settings []*setting, path string, lookupEnv func(string) (string, bool)) (map[string]string, error) {
+	values := map[string]string{}
+	for _, s := range settings {
+		values[s.env] = s.fallback
+	}
+	fileValues, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range settings {
+		if value, ok := fileValues[s.env]; ok {
+			values[s.env] = value
+		}
+		if value, ok := lookupEnv(s.env); ok {
+			values[s.env] = value
+		}
+	}
+	return values, nil
+}
+
+// setValues sets settings to their value in values, the values that
+// cannot be parsed are returned as a ValidationError.
+func setValues(settings []*setting, values map[string]string) error {
+	var errs ValidationError
+	for _, s := range settings {
+		err := s.set(values[s.env])
+		if err != nil {
+			errs = append(errs, FieldError{Key: s.env, Message: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// readFile reads the dotenv file at path, DefaultFile is read when path
+// is empty and it is not an error when it does not exist.
+func readFile(path string) (map[string]string, error) {
+	if path == "" {
+		values, err := godotenv.Read(DefaultFile)
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return values, err
+	}
+	return godotenv.Read(path)
+}
+
+// Print writes the configuration to w in the dotenv format, secrets are
+// masked.
+func (c *Config) Print(w io.Writer) error {
+	for _, s := range c.settings() {
+		_, err := fmt.Fprintf(w, "%s=%s\n", s.env, mask(s.secret, s.format()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settings returns the fields of c with an environment variable, in the
+// order they are declared.
+func (c *Config) settings() []*setting {
+	return collectSettings(reflect.ValueOf(c).Elem())
+}
+
+func collectSettings(v reflect.Value) []*setting {
+	settings := []*setting{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		env, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				settings = append(settings, collectSettings(v.Field(i))...)
+			}
+			continue
+		}
+		settings = append(settings, &setting{
+			env:      env,
+			fallback: field.Tag.Get("default"),
+			usage:    field.Tag.Get("usage"),
+			secret:   field.Tag.Get("secret"),
+			value:    v.Field(i),
+		})
+	}
+	return settings
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses value into the field of s, lists are comma separated.
+func (s *setting) set(value string) error {
+	switch {
+	case s.value.Type() == durationType:
+		if value == "" {
+			s.value.SetInt(0)
+			return nil
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, expected e.g 15m", value)
+		}
+		s.value.SetInt(int64(duration))
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(value)
+	case s.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q, expected true or false", value)
+		}
+		s.value.SetBool(b)
+	case s.value.Kind() == reflect.Int || s.value.Kind() == reflect.Int64:
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		s.value.SetInt(number)
+	case s.value.Kind() == reflect.Float64:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		s.value.SetFloat(number)
+	case s.value.Kind() == reflect.Slice:
+		s.value.Set(reflect.ValueOf(splitList(value)))
+	default:
+		return fmt.Errorf("unsupported type %s", s.value.Type())
+	}
+	return nil
+}
+
+// format returns the field of s in the format parsed by set.
+func (s *setting) format() string {
+	switch {
+	case s.value.Type() == durationType:
+		return time.Duration(s.value.Int()).String()
+	case s.value.Kind() == reflect.Slice:
+		return strings.Join(s.value.Interface().([]string), ",")
+	}
+	return fmt.Sprint(s.value.Interface())
+}
+
+// splitList returns the comma separated values of value, empty values are
+// skipped.
+func splitList(value string) []string {
+	values := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// flagName returns the flag of the environment variable env, e.g
+// --nats-uri for NATS_URI.
+func flagName(env string) string {
+	return strings.ToLower(strings.ReplaceAll(env, "_", "-"))
+}
+
+// mask hides value when it is a secret, secret is "true" for values that
+// are secrets and "password" for urls and data source names whose
+// password is a secret.
+func mask(secret, value string) string {
+	if value == "" {
+		return value
+	}
+	switch secret {
+	case "true":
+		return maskedValue
+	case "password":
+		if u, err := url.Parse(value); err == nil && u.User != nil && u.Scheme != "" {
+			if _, ok := u.User.Password(); ok {
+				// url escapes the * of the masked password.
+				u.User = url.UserPassword(u.User.Username(), maskedValue)
+				return strings.Replace(u.String(), strings.Repeat("%2A", len(maskedValue)), maskedValue, 1)
+			}
+			return value
+		}
+		return maskDSNPassword(value)
+	}
+	return value
+}
+
+// maskDSNPassword masks the password of the mysql data source name dsn,
+// user:password@protocol(address)/dbname?params, the password may contain
+// @ and the address is the part after the last @ before the last /.
+func maskDSNPassword(dsn string) string {
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return dsn
+	}
+	at := strings.LastIndex(dsn[:slash], "@")
+	if at < 0 {
+		return dsn
+	}
+	colon := strings.Index(dsn[:at], ":")
+	if colon < 0 {
+		return dsn
+	}
+	return dsn[:colon+1] + maskedValue + dsn[at:]
+}
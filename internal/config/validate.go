@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/stream"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+)
+
+// FieldError describes why the setting of an environment variable is
+// invalid.
+type FieldError struct {
+	Key     string
+	Message string
+}
+
+// ValidationError is the list of invalid settings of a configuration.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Key+": "+fieldErr.Message)
+	}
+	return "invalid configuration: " + strings.Join(messages, "; ")
+}
+
+// Validate checks every setting of c, it returns a ValidationError with
+// all the invalid settings.
+func (c *Config) Validate() error {
+	var errs ValidationError
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, FieldError{Key: key, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	required := func(value, key string) {
+		check(value != "", key, "must be set")
+	}
+	positive := func(value time.Duration, key string) {
+		check(value > 0, key, "must be a positive duration")
+	}
+	notNegative := func(value int64, key string) {
+		check(value >= 0, key, "must not be negative")
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	check(err == nil && port > 0 && port <= 65535, "PORT", "must be a port number between 1 and 65535")
+	required(c.MetricsAddr, "METRICS_ADDR")
+
+	positive(c.Shutdown.Timeout, "SHUTDOWN_TIMEOUT")
+	positive(c.Shutdown.DrainTimeout, "SHUTDOWN_DRAIN_TIMEOUT")
+	check(c.Shutdown.DrainTimeout <= c.Shutdown.Timeout, "SHUTDOWN_DRAIN_TIMEOUT", "must not be greater than SHUTDOWN_TIMEOUT")
+	notNegative(int64(c.Shutdown.Delay), "SHUTDOWN_DELAY")
+	check(c.Shutdown.Delay+c.Shutdown.DrainTimeout <= c.Shutdown.Timeout,
+		"SHUTDOWN_DELAY", "plus SHUTDOWN_DRAIN_TIMEOUT must not be greater than SHUTDOWN_TIMEOUT")
+
+	positive(c.Health.CheckInterval, "HEALTH_CHECK_INTERVAL")
+	positive(c.Health.MySQLTimeout, "HEALTH_MYSQL_TIMEOUT")
+	notNegative(int64(c.Health.MySQLCacheTTL), "HEALTH_MYSQL_CACHE_TTL")
+	positive(c.Health.NATSTimeout, "HEALTH_NATS_TIMEOUT")
+	notNegative(int64(c.Health.NATSCacheTTL), "HEALTH_NATS_CACHE_TTL")
+	positive(c.Health.UserServiceTimeout, "HEALTH_USER_SERVICE_TIMEOUT")
+	notNegative(int64(c.Health.UserServiceCacheTTL), "HEALTH_USER_SERVICE_CACHE_TTL")
+
+	required(c.MySQL.Connection, "MYSQL_CONNECTION")
+	notNegative(int64(c.MySQL.MaxOpenConns), "MYSQL_MAX_OPEN_CONNS")
+	notNegative(int64(c.MySQL.MaxIdleConns), "MYSQL_MAX_IDLE_CONNS")
+	check(c.MySQL.MaxOpenConns == 0 || c.MySQL.MaxIdleConns <= c.MySQL.MaxOpenConns,
+		"MYSQL_MAX_IDLE_CONNS", "must not be greater than MYSQL_MAX_OPEN_CONNS")
+	notNegative(int64(c.MySQL.ConnMaxLifetime), "MYSQL_CONN_MAX_LIFETIME")
+	notNegative(int64(c.MySQL.ConnMaxIdleTime), "MYSQL_CONN_MAX_IDLE_TIME")
+
+	required(c.UserService.Addr, "USER_SERVICE_ADDR")
+	positive(c.UserService.Timeout, "USER_SERVICE_TIMEOUT")
+
+	positive(c.Inventory.ReservationTTL, "STOCK_RESERVATION_TTL")
+	positive(c.Inventory.ReservationSweepInterval, "STOCK_RESERVATION_SWEEP_INTERVAL")
+	positive(c.Outbox.RelayInterval, "OUTBOX_RELAY_INTERVAL")
+	check(c.Outbox.MaxAttempts >= 1, "OUTBOX_MAX_ATTEMPTS", "must be at least 1")
+
+	_, err = outbox.NewEncoder(c.Events.Encoding, c.Events.Source)
+	check(err == nil, "EVENT_ENCODING", "must be %s, %s or %s",
+		outbox.EncodingLegacy, outbox.EncodingCloudEventsStructured, outbox.EncodingCloudEventsBinary)
+	check(c.Events.Encoding == outbox.EncodingLegacy || c.Events.Source != "", "EVENT_SOURCE", "must be set for cloudevents")
+
+	required(c.NATS.URI, "NATS_URI")
+	required(c.NATS.RPCSubjectPrefix, "NATS_RPC_SUBJECT_PREFIX")
+	required(c.NATS.OrderPlacedSubject, "ORDER_PLACED_SUBJECT")
+	required(c.NATS.OrderCancelledSubject, "ORDER_CANCELLED_SUBJECT")
+
+	if c.Stream.Enabled {
+		required(c.Stream.Name, "NATS_STREAM_NAME")
+		check(len(c.Stream.Subjects) > 0, "NATS_STREAM_SUBJECTS", "must be set when jetstream is enabled")
+		_, err = stream.ParseRetention(c.Stream.Retention)
+		check(err == nil, "NATS_STREAM_RETENTION", "must be limits, interest or workqueue")
+		positive(c.Stream.MaxAge, "NATS_STREAM_MAX_AGE")
+		notNegative(c.Stream.MaxMsgs, "NATS_STREAM_MAX_MSGS")
+		notNegative(c.Stream.MaxBytes, "NATS_STREAM_MAX_BYTES")
+		positive(c.Stream.DuplicateWindow, "NATS_STREAM_DUPLICATE_WINDOW")
+		check(c.Stream.Replicas >= 1 && c.Stream.Replicas <= 5, "NATS_STREAM_REPLICAS", "must be between 1 and 5")
+	}
+
+	check(c.Publish.MaxAttempts >= 1, "NATS_PUBLISH_MAX_ATTEMPTS", "must be at least 1")
+	positive(c.Publish.RetryDelay, "NATS_PUBLISH_RETRY_DELAY")
+	check(c.Publish.MaxRetryDelay >= c.Publish.RetryDelay, "NATS_PUBLISH_MAX_RETRY_DELAY", "must not be less than NATS_PUBLISH_RETRY_DELAY")
+	required(c.Publish.SpoolDir, "NATS_PUBLISH_SPOOL_DIR")
+	positive(c.Publish.SpoolInterval, "NATS_PUBLISH_SPOOL_INTERVAL")
+	required(c.Publish.DeadLetterPrefix, "NATS_PUBLISH_DEAD_LETTER_PREFIX")
+
+	required(c.Subscriber.Queue, "NATS_SUBSCRIBER_QUEUE")
+	required(c.Subscriber.DeadLetterPrefix, "NATS_DEAD_LETTER_PREFIX")
+	check(c.Subscriber.MaxAttempts >= 1, "NATS_SUBSCRIBER_MAX_ATTEMPTS", "must be at least 1")
+	positive(c.Subscriber.RetryDelay, "NATS_SUBSCRIBER_RETRY_DELAY")
+	positive(c.Subscriber.HashDedupWindow, "NATS_SUBSCRIBER_HASH_DEDUP_WINDOW")
+	check(c.Subscriber.ProcessedRetention >= c.Subscriber.HashDedupWindow,
+		"NATS_SUBSCRIBER_PROCESSED_RETENTION", "must not be less than NATS_SUBSCRIBER_HASH_DEDUP_WINDOW")
+	positive(c.Subscriber.ProcessedCleanupInterval, "NATS_SUBSCRIBER_PROCESSED_CLEANUP_INTERVAL")
+
+	required(c.Tracing.ServiceName, "TRACING_SERVICE_NAME")
+	_, err = tracing.NewSampler(c.Tracing.Sampler, c.Tracing.SamplerArg)
+	check(err == nil, "TRACING_SAMPLER", "must be always_on, always_off, traceidratio, parentbased_always_on, parentbased_always_off or parentbased_traceidratio")
+	check(c.Tracing.SamplerArg >= 0 && c.Tracing.SamplerArg <= 1, "TRACING_SAMPLER_ARG", "must be a ratio between 0 and 1")
+	notNegative(int64(c.Tracing.AttributesMaxLength), "TRACING_ATTRIBUTES_MAX_LENGTH")
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package events
+
+import (
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/inventory"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Subjects of the reservation events.
+const (
+	ReservationCommitted = "inventory.ReservationCommitted"
+	ReservationExpired   = "inventory.ReservationExpired"
+)
+
+// NewReservationEvent returns the event of reservation.
+func NewReservationEvent(reservation *inventory.Reservation) *proto.ReservationEvent {
+	event := &proto.ReservationEvent{
+		OrderId:   reservation.OrderID,
+		UserId:    reservation.UserID,
+		Status:    string(reservation.Status),
+		ExpiresAt: timestamppb.New(reservation.ExpiresAt),
+		CreatedAt: timestamppb.New(reservation.CreatedAt),
+		UpdatedAt: timestamppb.New(reservation.UpdatedAt),
+	}
+	for _, item := range reservation.Items {
+		event.Items = append(event.Items, &proto.ReservationEventItem{Sku: item.Sku, Quantity: item.Quantity})
+	}
+	return event
+}
@@ -0,0 +1,71 @@
+// Package events defines the domain events the product service publishes
+// to nats for other services to react to catalog changes.
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Subjects of the version 1 product events, the version is part of the
+// subject so that a breaking change can be published next to the old
+// version while consumers migrate.
+const (
+	ProductCreated      = "products.v1.created"
+	ProductUpdated      = "products.v1.updated"
+	ProductPriceChanged = "products.v1.price_changed"
+	ProductDeleted      = "products.v1.deleted"
+	ProductRestored     = "products.v1.restored"
+	ProductPurged       = "products.v1.purged"
+)
+
+// NewProductEvent returns a new product event of the provided type about
+// product.
+func NewProductEvent(eventType, actor string, product *products.Product) *proto.ProductEvent {
+	return &proto.ProductEvent{
+		Id:         uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: timestamppb.New(time.Now()),
+		Actor:      actor,
+		Product:    NewProductSnapshot(product),
+	}
+}
+
+// NewProductSnapshot returns the snapshot of product.
+func NewProductSnapshot(product *products.Product) *proto.ProductSnapshot {
+	snapshot := &proto.ProductSnapshot{
+		Sku:         product.Sku,
+		Name:        product.Name,
+		Description: product.Description,
+		Category:    product.Category,
+		Brand:       product.Brand,
+		MerchantId:  product.MerchantID,
+		Price:       NewMoney(product.Price),
+		ImageUrl:    product.ImageURL,
+		TimeAdded:   timestamppb.New(product.TimeAdded),
+		Deleted:     product.DeletedAt.Valid,
+	}
+	for _, option := range product.Options {
+		snapshot.Options = append(snapshot.Options, &proto.ProductSnapshotOption{
+			Name: option.Name, Values: option.Values,
+		})
+	}
+	for _, variant := range product.Variants {
+		snapshotVariant := &proto.ProductSnapshotVariant{
+			Sku: variant.Sku, Options: variant.Options, ImageUrl: variant.ImageURL,
+		}
+		if variant.Price != (products.Money{}) {
+			snapshotVariant.Price = NewMoney(variant.Price)
+		}
+		snapshot.Variants = append(snapshot.Variants, snapshotVariant)
+	}
+	return snapshot
+}
+
+func NewMoney(money products.Money) *proto.Money {
+	return &proto.Money{CurrencyCode: money.Currency, Amount: money.Amount}
+}
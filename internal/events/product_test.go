@@ -0,0 +1,73 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestNewProductSnapshot(t *testing.T) {
+	timeAdded := time.Date(2021, time.October, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		product *products.Product
+		want    *proto.ProductSnapshot
+	}{
+		{
+			name: "product without variants",
+			product: &products.Product{
+				ID:         1,
+				Sku:        "sku.1",
+				Name:       "Product 1",
+				Category:   "electronics",
+				MerchantID: "merchant.1",
+				Price:      products.Money{Amount: 10000, Currency: "USD"},
+				TimeAdded:  timeAdded,
+			},
+			want: &proto.ProductSnapshot{
+				Sku:        "sku.1",
+				Name:       "Product 1",
+				Category:   "electronics",
+				MerchantId: "merchant.1",
+				Price:      &proto.Money{CurrencyCode: "USD", Amount: 10000},
+				TimeAdded:  timestamppb.New(timeAdded),
+			},
+		},
+		{
+			name: "deleted product with variants",
+			product: &products.Product{
+				Sku:       "sku.2",
+				Price:     products.Money{Amount: 2000, Currency: "EUR"},
+				DeletedAt: gorm.DeletedAt{Time: timeAdded, Valid: true},
+				Options:   []products.Option{{Name: "size", Values: products.StringList{"S", "M"}}},
+				Variants: []products.Variant{
+					{Sku: "variant.s", Options: products.OptionValues{"size": "S"}},
+					{Sku: "variant.m", Options: products.OptionValues{"size": "M"}, Price: products.Money{Amount: 2500, Currency: "EUR"}},
+				},
+			},
+			want: &proto.ProductSnapshot{
+				Sku:       "sku.2",
+				Price:     &proto.Money{CurrencyCode: "EUR", Amount: 2000},
+				TimeAdded: timestamppb.New(time.Time{}),
+				Deleted:   true,
+				Options:   []*proto.ProductSnapshotOption{{Name: "size", Values: []string{"S", "M"}}},
+				Variants: []*proto.ProductSnapshotVariant{
+					{Sku: "variant.s", Options: map[string]string{"size": "S"}},
+					{Sku: "variant.m", Options: map[string]string{"size": "M"}, Price: &proto.Money{CurrencyCode: "EUR", Amount: 2500}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewProductSnapshot(tt.product); !protobuf.Equal(got, tt.want) {
+				t.Errorf("NewProductSnapshot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package events
+
+import (
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// SchemaVersion is the version of the event schemas in events.proto, it
+// changes when a schema changes in a way that breaks consumers.
+const SchemaVersion = "1"
+
+// marshalOptions emits zero values so that consumers always find every
+// field of the schema in the payload.
+var marshalOptions = protojson.MarshalOptions{EmitUnpopulated: true}
+
+// Marshal returns the protojson encoding of event.
+func Marshal(event protobuf.Message) ([]byte, error) {
+	return marshalOptions.Marshal(event)
+}
+
+// Schema returns the name of the schema of event.
+func Schema(event protobuf.Message) string {
+	return string(event.ProtoReflect().Descriptor().FullName())
+}
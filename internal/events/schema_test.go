@@ -0,0 +1,104 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var updateSchemas = flag.Bool("update-schemas", false, "update the golden event schemas")
+
+// goldenSchemas is the file with the published event schemas of the
+// current schema version, a breaking change needs a new version and so a
+// new golden file.
+var goldenSchemas = filepath.Join("testdata", "events.v"+SchemaVersion+".golden.json")
+
+// TestSchemaCompatibility fails when a field of a published event schema
+// is removed, renamed, renumbered or changes type. New fields are allowed,
+// run the test with -update-schemas to add them to the golden file.
+func TestSchemaCompatibility(t *testing.T) {
+	current := map[string]map[string]string{}
+	messages := proto.File_events_proto.Messages()
+	for i := 0; i < messages.Len(); i++ {
+		describeSchema(messages.Get(i), current)
+	}
+	if *updateSchemas {
+		var data bytes.Buffer
+		encoder := json.NewEncoder(&data)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		err := encoder.Encode(current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(goldenSchemas, data.Bytes(), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(goldenSchemas)
+	if err != nil {
+		t.Fatalf("reading golden schemas: %v", err)
+	}
+	golden := map[string]map[string]string{}
+	err = json.Unmarshal(data, &golden)
+	if err != nil {
+		t.Fatalf("decoding golden schemas: %v", err)
+	}
+	for message, fields := range golden {
+		currentFields, ok := current[message]
+		if !ok {
+			t.Errorf("message %s was removed", message)
+			continue
+		}
+		for field, want := range fields {
+			got, ok := currentFields[field]
+			if !ok {
+				t.Errorf("field %s.%s (%s) was removed or renamed", message, field, want)
+			} else if got != want {
+				t.Errorf("field %s.%s changed from %s to %s", message, field, want, got)
+			}
+		}
+	}
+}
+
+// describeSchema adds the fields of message and of the messages it uses to
+// schemas, a field is described by its number, cardinality and type.
+func describeSchema(message protoreflect.MessageDescriptor, schemas map[string]map[string]string) {
+	name := string(message.FullName())
+	if _, ok := schemas[name]; ok || strings.HasPrefix(name, "google.protobuf.") {
+		return
+	}
+	fields := map[string]string{}
+	schemas[name] = fields
+	for i := 0; i < message.Fields().Len(); i++ {
+		field := message.Fields().Get(i)
+		fields[string(field.Name())] = fmt.Sprintf("%d %s %s", field.Number(), field.Cardinality(), fieldType(field))
+		if field.IsMap() {
+			field = field.MapValue()
+		}
+		if field.Message() != nil {
+			describeSchema(field.Message(), schemas)
+		}
+	}
+}
+
+func fieldType(field protoreflect.FieldDescriptor) string {
+	if field.IsMap() {
+		return fmt.Sprintf("map<%s, %s>", fieldType(field.MapKey()), fieldType(field.MapValue()))
+	}
+	if field.Message() != nil {
+		return string(field.Message().FullName())
+	}
+	return field.Kind().String()
+}
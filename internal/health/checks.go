@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc/connectivity"
+)
+
+// Pinger is the interface that describes a database connection pool,
+// *sql.DB implements it.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck returns the check that pings db.
+func PingCheck(db Pinger) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// NATSConn is the interface that describes a nats connection, *nats.Conn
+// implements it.
+type NATSConn interface {
+	Status() nats.Status
+}
+
+// NATSCheck returns the check that fails when conn is not connected, e.g
+// while it is reconnecting.
+func NATSCheck(conn NATSConn) Check {
+	return func(ctx context.Context) error {
+		if status := conn.Status(); status != nats.CONNECTED {
+			return fmt.Errorf("nats connection is %s", status)
+		}
+		return nil
+	}
+}
+
+// ClientConn is the interface that describes a grpc client connection,
+// *grpc.ClientConn implements it.
+type ClientConn interface {
+	Connect()
+	GetState() connectivity.State
+	WaitForStateChange(ctx context.Context, sourceState connectivity.State) bool
+}
+
+// ClientConnCheck returns the check that fails when conn cannot connect
+// to its server before ctx is done.
+func ClientConnCheck(conn ClientConn) Check {
+	return func(ctx context.Context) error {
+		conn.Connect()
+		for {
+			state := conn.GetState()
+			if state == connectivity.Ready {
+				return nil
+			}
+			if !conn.WaitForStateChange(ctx, state) {
+				return fmt.Errorf("connection is %s", strings.ToLower(state.String()))
+			}
+		}
+	}
+}
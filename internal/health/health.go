@@ -0,0 +1,185 @@
+// Package health drives the serving status of the grpc.health.v1 service
+// from checks of the dependencies of the service, so that an orchestrator
+// can tell a service that cannot reach its database, nats or the user
+// service from a healthy one.
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// LivenessService is the health service that is SERVING as long as the
+// service runs, whatever the state of its dependencies, so that a liveness
+// probe does not restart the service when e.g the database is down.
+const LivenessService = "liveness"
+
+// Check returns an error when a dependency is not available.
+type Check func(ctx context.Context) error
+
+// Probe checks a dependency of the service.
+type Probe struct {
+	Name  string
+	Check Check
+	// Timeout is how long Check can take before the dependency is
+	// considered not available.
+	Timeout time.Duration
+	// TTL is how long the result of Check is reused before the dependency
+	// is checked again.
+	TTL time.Duration
+}
+
+// ProbeError is the error of a failed probe.
+type ProbeError struct {
+	Name string
+	Err  error
+}
+
+func (e ProbeError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+func (e ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// UnhealthyError is the list of the failed probes.
+type UnhealthyError []ProbeError
+
+func (e UnhealthyError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, probeErr := range e {
+		messages = append(messages, probeErr.Error())
+	}
+	return "unhealthy: " + strings.Join(messages, "; ")
+}
+
+type result struct {
+	err       error
+	checkedAt time.Time
+}
+
+// Monitor runs the probes and sets the serving status of the services on
+// a grpc health server, the overall "" service and services are SERVING
+// when every probe passed.
+type Monitor struct {
+	server   *health.Server
+	services []string
+	probes   []Probe
+	now      func() time.Time
+
+	mu       sync.Mutex
+	results  map[string]result
+	shutdown bool
+}
+
+// NewMonitor returns a new monitor object, services are NOT_SERVING until
+// the probes passed once.
+func NewMonitor(server *health.Server, services []string, probes ...Probe) *Monitor {
+	m := &Monitor{
+		server:   server,
+		services: append([]string{""}, services...),
+		probes:   probes,
+		now:      time.Now,
+		results:  map[string]result{},
+	}
+	for _, service := range m.services {
+		server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	server.SetServingStatus(LivenessService, healthpb.HealthCheckResponse_SERVING)
+	return m
+}
+
+// Run updates the serving status every interval until ctx is done.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// the failed probes are reported by the serving status.
+		m.Update(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update runs the probes whose result is older than their TTL, at the
+// same time, and sets the serving status of the services. It returns an
+// UnhealthyError with the failed probes.
+func (m *Monitor) Update(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, probe := range m.probes {
+		m.mu.Lock()
+		last, ok := m.results[probe.Name]
+		m.mu.Unlock()
+		if ok && m.now().Sub(last.checkedAt) < probe.TTL {
+			continue
+		}
+		wg.Add(1)
+		go func(probe Probe) {
+			defer wg.Done()
+			err := runCheck(ctx, probe)
+			m.mu.Lock()
+			m.results[probe.Name] = result{err: err, checkedAt: m.now()}
+			m.mu.Unlock()
+		}(probe)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs UnhealthyError
+	for _, probe := range m.probes {
+		if err := m.results[probe.Name].err; err != nil {
+			errs = append(errs, ProbeError{Name: probe.Name, Err: err})
+		}
+	}
+	if !m.shutdown {
+		status := healthpb.HealthCheckResponse_SERVING
+		if len(errs) > 0 {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		for _, service := range m.services {
+			m.server.SetServingStatus(service, status)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// runCheck returns the error of the check of probe, or a timeout error
+// when it does not return within the timeout of probe.
+func runCheck(ctx context.Context, probe Probe) error {
+	ctx, cancel := context.WithTimeout(ctx, probe.Timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- probe.Check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out after %s: %w", probe.Timeout, ctx.Err())
+	}
+}
+
+// Shutdown sets every service NOT_SERVING, the status does not change
+// anymore. It is called when the service starts shutting down so that it
+// stops receiving new requests.
+func (m *Monitor) Shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shutdown = true
+	m.server.Shutdown()
+}
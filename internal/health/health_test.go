@@ -0,0 +1,166 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// servingStatus returns the status of service on server.
+func servingStatus(t *testing.T, server *health.Server, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("Server.Check(%q) error = %v", service, err)
+	}
+	return resp.Status
+}
+
+func TestMonitor_Update(t *testing.T) {
+	server := health.NewServer()
+	var (
+		mysqlErr   error
+		mysqlCalls int
+		now        = time.Now()
+	)
+	m := NewMonitor(server, []string{"ProductService"},
+		Probe{
+			Name: "mysql", Timeout: time.Second, TTL: 10 * time.Second,
+			Check: func(ctx context.Context) error {
+				mysqlCalls++
+				return mysqlErr
+			},
+		},
+		Probe{
+			Name: "user-service", Timeout: 10 * time.Millisecond, TTL: 10 * time.Second,
+			Check: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+	)
+	m.now = func() time.Time { return now }
+	if got := servingStatus(t, server, "ProductService"); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status before the first update = %v, want NOT_SERVING", got)
+	}
+	if got := servingStatus(t, server, LivenessService); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("liveness status = %v, want SERVING", got)
+	}
+
+	err := m.Update(context.Background())
+	var unhealthy UnhealthyError
+	if !errors.As(err, &unhealthy) || len(unhealthy) != 1 || unhealthy[0].Name != "user-service" ||
+		!errors.Is(unhealthy[0], context.DeadlineExceeded) {
+		t.Fatalf("Monitor.Update() error = %v, want the user-service check timed out", err)
+	}
+	for _, service := range []string{"", "ProductService"} {
+		if got := servingStatus(t, server, service); got != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("status of %q = %v, want NOT_SERVING", service, got)
+		}
+	}
+
+	// the cached results are used until their TTL expired.
+	mysqlErr = errors.New("dial tcp: connection refused")
+	now = now.Add(5 * time.Second)
+	m.Update(context.Background())
+	if mysqlCalls != 1 {
+		t.Errorf("mysql checks = %v, want the cached result used", mysqlCalls)
+	}
+	now = now.Add(10 * time.Second)
+	err = m.Update(context.Background())
+	if mysqlCalls != 2 || !errors.As(err, &unhealthy) || len(unhealthy) != 2 || unhealthy[0].Name != "mysql" {
+		t.Errorf("Monitor.Update() error = %v after %v mysql checks, want mysql and user-service failed", err, mysqlCalls)
+	}
+	if got := servingStatus(t, server, LivenessService); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("liveness status = %v, want SERVING while dependencies fail", got)
+	}
+}
+
+func TestMonitor_Shutdown(t *testing.T) {
+	server := health.NewServer()
+	m := NewMonitor(server, []string{"ProductService"}, Probe{
+		Name: "nats", Timeout: time.Second, Check: func(ctx context.Context) error { return nil },
+	})
+	err := m.Update(context.Background())
+	if err != nil || servingStatus(t, server, "") != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Monitor.Update() error = %v, want SERVING", err)
+	}
+
+	m.Shutdown()
+	m.Update(context.Background())
+	for _, service := range []string{"", "ProductService", LivenessService} {
+		if got := servingStatus(t, server, service); got != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("status of %q after shutdown = %v, want NOT_SERVING", service, got)
+		}
+	}
+}
+
+type fakeNATSConn nats.Status
+
+func (c fakeNATSConn) Status() nats.Status { return nats.Status(c) }
+
+func TestNATSCheck(t *testing.T) {
+	tests := []struct {
+		status  nats.Status
+		wantErr bool
+	}{
+		{status: nats.CONNECTED},
+		{status: nats.RECONNECTING, wantErr: true},
+		{status: nats.CLOSED, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status.String(), func(t *testing.T) {
+			err := NATSCheck(fakeNATSConn(tt.status))(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NATSCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientConnCheck(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(lis)
+	defer server.Stop()
+	// nothing listens on the address of a closed listener.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr bool
+	}{
+		{name: "reachable", addr: lis.Addr().String()},
+		{name: "unreachable", addr: closed.Addr().String(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := grpc.Dial(tt.addr, grpc.WithInsecure())
+			if err != nil {
+				t.Fatalf("dialing: %v", err)
+			}
+			defer conn.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			err = ClientConnCheck(conn)(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ClientConnCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package inventory
+
+import "time"
+
+// StockLevel is the quantity of a sku that is on hand, reserved stock is
+// on hand but held for checkouts that have not been completed yet.
+type StockLevel struct {
+	Sku       string    `json:"sku" gorm:"primaryKey;size:64"`
+	OnHand    int64     `json:"onHand"`
+	Reserved  int64     `json:"reserved"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Available returns the quantity that can still be reserved or sold.
+func (l *StockLevel) Available() int64 {
+	return l.OnHand - l.Reserved
+}
+
+// AdjustmentKind is the reason the stock of a sku changed.
+type AdjustmentKind string
+
+const (
+	// KindReceive adds stock received from a supplier.
+	KindReceive AdjustmentKind = "receive"
+	// KindSell removes stock that has been sold.
+	KindSell AdjustmentKind = "sell"
+	// KindReturn adds stock returned by a customer.
+	KindReturn AdjustmentKind = "return"
+	// KindCorrection adds or removes stock to match a stock count.
+	KindCorrection AdjustmentKind = "correction"
+)
+
+// Valid reports whether k is a known adjustment kind.
+func (k AdjustmentKind) Valid() bool {
+	switch k {
+	case KindReceive, KindSell, KindReturn, KindCorrection:
+		return true
+	}
+	return false
+}
+
+// Delta returns the signed change in on hand quantity of an adjustment of
+// kind k with the provided quantity. Receive, sell and return adjustments
+// take a positive quantity while corrections take a signed quantity.
+func (k AdjustmentKind) Delta(quantity int64) int64 {
+	if k == KindSell {
+		return -quantity
+	}
+	return quantity
+}
+
+// Adjustment is an entry of the append only stock ledger, adjustments are
+// never updated or deleted.
+type Adjustment struct {
+	ID   int64          `json:"id" gorm:"autoIncrement,primaryKey"`
+	Sku  string         `json:"sku" gorm:"index;size:64"`
+	Kind AdjustmentKind `json:"kind" gorm:"size:20"`
+	// Quantity is the signed change in on hand quantity.
+	Quantity int64 `json:"quantity"`
+	// Balance is the on hand quantity after the adjustment.
+	Balance int64  `json:"balance"`
+	Reason  string `json:"reason"`
+	// Actor is the id of the user that made the adjustment.
+	Actor     string    `json:"actor" gorm:"size:64"`
+	CreatedAt time.Time `json:"createdAt"`
+}
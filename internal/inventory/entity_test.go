@@ -0,0 +1,54 @@
+package inventory
+
+import "testing"
+
+func TestAdjustmentKind_Valid(t *testing.T) {
+	tests := []struct {
+		name string
+		kind AdjustmentKind
+		want bool
+	}{
+		{name: "receive", kind: KindReceive, want: true},
+		{name: "sell", kind: KindSell, want: true},
+		{name: "return", kind: KindReturn, want: true},
+		{name: "correction", kind: KindCorrection, want: true},
+		{name: "empty kind", kind: "", want: false},
+		{name: "unknown kind", kind: "stolen", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.kind.Valid(); got != tt.want {
+				t.Errorf("AdjustmentKind.Valid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjustmentKind_Delta(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     AdjustmentKind
+		quantity int64
+		want     int64
+	}{
+		{name: "receive adds stock", kind: KindReceive, quantity: 5, want: 5},
+		{name: "sell removes stock", kind: KindSell, quantity: 5, want: -5},
+		{name: "return adds stock", kind: KindReturn, quantity: 2, want: 2},
+		{name: "positive correction", kind: KindCorrection, quantity: 3, want: 3},
+		{name: "negative correction", kind: KindCorrection, quantity: -3, want: -3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.kind.Delta(tt.quantity); got != tt.want {
+				t.Errorf("AdjustmentKind.Delta() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStockLevel_Available(t *testing.T) {
+	level := &StockLevel{OnHand: 10, Reserved: 4}
+	if got := level.Available(); got != 6 {
+		t.Errorf("StockLevel.Available() = %v, want %v", got, 6)
+	}
+}
@@ -0,0 +1,89 @@
+package inventory
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// variantStockMigrationReason is the reason of the ledger correction of a
+// migrated variant, it marks the variant as migrated.
+const variantStockMigrationReason = "migrated from variant stock"
+
+// legacyVariant is a variant with the stock column it had before stock was
+// tracked by the inventory.
+type legacyVariant struct {
+	Sku   string
+	Stock int64
+}
+
+func (legacyVariant) TableName() string {
+	return "variants"
+}
+
+// MigrateVariantStock moves the stock of variants created before stock was
+// tracked by the inventory to the stock levels and records it in the
+// ledger as a correction. The legacy stock column of variants is renamed
+// to legacy_stock instead of being dropped so that no data is lost, the
+// migration does nothing once it has been applied. The stock of every
+// variant is moved in its own transaction and the column is renamed last,
+// mysql commits the rename on its own, so a migration that stopped halfway
+// is completed by running it again without moving any stock twice.
+func MigrateVariantStock(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&legacyVariant{}, "stock") {
+		return nil
+	}
+	variants := []*legacyVariant{}
+	err := db.Where("stock > ?", 0).Order("sku").Find(&variants).Error
+	if err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return migrateVariantStock(tx, variant, time.Now())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return db.Exec("ALTER TABLE variants RENAME COLUMN stock TO legacy_stock").Error
+}
+
+// migrateVariantStock moves the stock of variant unless the ledger already
+// has its correction.
+func migrateVariantStock(tx *gorm.DB, variant *legacyVariant, now time.Time) error {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&StockLevel{Sku: variant.Sku, UpdatedAt: now}).Error
+	if err != nil {
+		return err
+	}
+	// the ledger is checked once the stock level is locked so that two
+	// replicas starting together cannot both move the stock.
+	levels, err := lockStockLevels(tx, []string{variant.Sku})
+	if err != nil {
+		return err
+	}
+	var count int64
+	err = tx.Model(&Adjustment{}).Where("sku = ? AND reason = ?", variant.Sku, variantStockMigrationReason).Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+	level := levels[variant.Sku]
+	level.OnHand += variant.Stock
+	level.UpdatedAt = now
+	err = tx.Model(level).Updates(map[string]interface{}{
+		"on_hand":    level.OnHand,
+		"updated_at": level.UpdatedAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Create(&Adjustment{
+		Sku:       variant.Sku,
+		Kind:      KindCorrection,
+		Quantity:  variant.Stock,
+		Balance:   level.OnHand,
+		Reason:    variantStockMigrationReason,
+		CreatedAt: now,
+	}).Error
+}
@@ -0,0 +1,56 @@
+package inventory
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestMigrateVariantStock(t *testing.T) {
+	tests := []struct {
+		name     string
+		failOnce string
+	}{
+		{name: "migrated twice"},
+		{name: "rename failed", failOnce: "ALTER TABLE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			store.tables["variants"] = []map[string]driver.Value{
+				{"id": int64(1), "sku": "sku.1", "stock": int64(3)},
+				{"id": int64(2), "sku": "sku.2", "stock": int64(0)},
+				{"id": int64(3), "sku": "sku.3", "stock": int64(4)},
+			}
+			store.tables["stock_levels"] = []map[string]driver.Value{
+				{"sku": "sku.1", "on_hand": int64(2), "reserved": int64(0), "updated_at": time.Now()},
+			}
+			store.failOnce = tt.failOnce
+			repo := newFakeStockRepo(t, store)
+
+			err := MigrateVariantStock(repo.db)
+			if (err != nil) != (tt.failOnce != "") {
+				t.Fatalf("MigrateVariantStock() error = %v, want error %v", err, tt.failOnce != "")
+			}
+			if err = MigrateVariantStock(repo.db); err != nil {
+				t.Fatalf("MigrateVariantStock() second run error = %v", err)
+			}
+			for sku, want := range map[string]int64{"sku.1": 5, "sku.3": 4} {
+				level, err := repo.GetStockLevel(context.Background(), sku)
+				if err != nil || level.OnHand != want {
+					t.Errorf("GetStockLevel(%s) = %v, %v, want on hand %d", sku, level, err, want)
+				}
+			}
+			if _, err = repo.GetStockLevel(context.Background(), "sku.2"); err != ErrStockLevelNotFound {
+				t.Errorf("GetStockLevel(sku.2) error = %v, want %v", err, ErrStockLevelNotFound)
+			}
+			if got := len(store.tables["adjustments"]); got != 2 {
+				t.Errorf("MigrateVariantStock() added %d adjustments, want 2", got)
+			}
+			if got := store.tables["variants"][0]; got["stock"] != nil || got["legacy_stock"] != int64(3) {
+				t.Errorf("MigrateVariantStock() variant = %v, want stock renamed to legacy_stock", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package inventory
+
+import (
+	"sort"
+	"time"
+)
+
+// OrderStatus is the state of an order of the checkout service.
+type OrderStatus string
+
+const (
+	// OrderPlaced is an order whose stock has been sold.
+	OrderPlaced OrderStatus = "placed"
+	// OrderCancelled is an order whose stock has been given back, or that
+	// was cancelled before it was placed.
+	OrderCancelled OrderStatus = "cancelled"
+)
+
+// Order is an order of the checkout service whose events have been applied
+// to the stock, the order id is the primary key so that every event of an
+// order is applied once however many times it is delivered.
+type Order struct {
+	ID string `json:"id" gorm:"primaryKey;size:64"`
+	// UserID is the id of the shopper that placed the order.
+	UserID    string      `json:"userId" gorm:"size:64"`
+	Status    OrderStatus `json:"status" gorm:"size:20"`
+	Items     []OrderItem `json:"items" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// OrderItem is the quantity of a sku sold by an order.
+type OrderItem struct {
+	ID      int64  `json:"-" gorm:"autoIncrement,primaryKey"`
+	OrderID string `json:"-" gorm:"index;size:64"`
+	Sku     string `json:"sku" gorm:"size:64"`
+	// ProductSku is the sku of the product of Sku, which is Sku itself for
+	// products without variants.
+	ProductSku string `json:"productSku" gorm:"size:64"`
+	Quantity   int64  `json:"quantity"`
+}
+
+// ProductSales is the number of units of a product that have been sold by
+// orders that were not cancelled, units of every variant of the product
+// are counted.
+type ProductSales struct {
+	ProductSku string    `json:"productSku" gorm:"primaryKey;size:64"`
+	UnitsSold  int64     `json:"unitsSold"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// MergeOrderItems merges the items of the same sku and sorts them by sku,
+// the order stock levels are locked in to avoid deadlocks.
+func MergeOrderItems(items []OrderItem) []OrderItem {
+	quantities := map[string]int64{}
+	merged := []OrderItem{}
+	for _, item := range items {
+		if _, ok := quantities[item.Sku]; !ok {
+			merged = append(merged, OrderItem{Sku: item.Sku, ProductSku: item.ProductSku})
+		}
+		quantities[item.Sku] += item.Quantity
+	}
+	for i := range merged {
+		merged[i].Quantity = quantities[merged[i].Sku]
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Sku < merged[j].Sku })
+	return merged
+}
+
+// orderItemSKUs returns the skus of items in order.
+func orderItemSKUs(items []OrderItem) []string {
+	skus := make([]string, 0, len(items))
+	for _, item := range items {
+		skus = append(skus, item.Sku)
+	}
+	return skus
+}
+
+// productSales returns the units of the products of items sorted by
+// product sku.
+func productSales(items []OrderItem) []*ProductSales {
+	units := map[string]int64{}
+	sales := []*ProductSales{}
+	for _, item := range items {
+		if _, ok := units[item.ProductSku]; !ok {
+			sales = append(sales, &ProductSales{ProductSku: item.ProductSku})
+		}
+		units[item.ProductSku] += item.Quantity
+	}
+	for _, productSales := range sales {
+		productSales.UnitsSold = units[productSales.ProductSku]
+	}
+	sort.Slice(sales, func(i, j int) bool { return sales[i].ProductSku < sales[j].ProductSku })
+	return sales
+}
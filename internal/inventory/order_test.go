@@ -0,0 +1,44 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeOrderItems(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []OrderItem
+		want  []OrderItem
+	}{
+		{
+			name:  "items are sorted by sku",
+			items: []OrderItem{{Sku: "c", ProductSku: "p", Quantity: 1}, {Sku: "a", ProductSku: "a", Quantity: 2}},
+			want:  []OrderItem{{Sku: "a", ProductSku: "a", Quantity: 2}, {Sku: "c", ProductSku: "p", Quantity: 1}},
+		},
+		{
+			name:  "items of the same sku are merged",
+			items: []OrderItem{{Sku: "b", ProductSku: "p", Quantity: 1}, {Sku: "b", ProductSku: "p", Quantity: 4}},
+			want:  []OrderItem{{Sku: "b", ProductSku: "p", Quantity: 5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeOrderItems(tt.items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeOrderItems() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_productSales(t *testing.T) {
+	items := []OrderItem{
+		{Sku: "p.red", ProductSku: "p", Quantity: 1},
+		{Sku: "a", ProductSku: "a", Quantity: 2},
+		{Sku: "p.blue", ProductSku: "p", Quantity: 3},
+	}
+	want := []*ProductSales{{ProductSku: "a", UnitsSold: 2}, {ProductSku: "p", UnitsSold: 4}}
+	if got := productSales(items); !reflect.DeepEqual(got, want) {
+		t.Errorf("productSales() = %v, want %v", got, want)
+	}
+}
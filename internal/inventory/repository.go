@@ -0,0 +1,707 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientStock is returned when an adjustment or reservation needs
+// more stock of a sku than is available.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrStockLevelNotFound is returned when a sku has never been stocked.
+var ErrStockLevelNotFound = errors.New("stock level does not exist")
+
+var (
+	// ErrReservationNotFound is returned when a reservation does not exist
+	// in the database.
+	ErrReservationNotFound = errors.New("reservation does not exist")
+	// ErrReservationExists is returned when an order already has a
+	// reservation.
+	ErrReservationExists = errors.New("reservation already exists")
+	// ErrReservationNotPending is returned when a reservation has already
+	// been committed, released or expired.
+	ErrReservationNotPending = errors.New("reservation is not pending")
+	// ErrReservationExpired is returned when a pending reservation is
+	// committed after it expired.
+	ErrReservationExpired = errors.New("reservation has expired")
+)
+
+var (
+	// ErrOrderExists is returned when an order has already been placed or
+	// cancelled.
+	ErrOrderExists = errors.New("order already exists")
+	// ErrOrderCancelled is returned when an order has already been
+	// cancelled.
+	ErrOrderCancelled = errors.New("order has already been cancelled")
+)
+
+// mysqlErrDuplicateEntry is the number of the mysql error of an insert
+// that violates a primary or unique key.
+const mysqlErrDuplicateEntry = 1062
+
+// InsufficientStockError is returned when there is not enough available
+// stock of a sku, it matches ErrInsufficientStock with errors.Is.
+type InsufficientStockError struct {
+	Sku       string
+	Available int64
+	Requested int64
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock of %s: %d available, %d requested", e.Sku, e.Available, e.Requested)
+}
+
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
+// StockRepository is the interface that describes an inventory repository
+// object.
+type StockRepository interface {
+	AdjustStock(ctx context.Context, adjustment *Adjustment) (*StockLevel, error)
+	GetStockLevel(ctx context.Context, sku string) (*StockLevel, error)
+	ReserveStock(ctx context.Context, reservation *Reservation) error
+	GetReservation(ctx context.Context, orderID string) (*Reservation, error)
+	CommitReservation(ctx context.Context, orderID string, messages []*outbox.Message) (*Reservation, error)
+	ReleaseReservation(ctx context.Context, orderID string, status ReservationStatus, messages []*outbox.Message) (*Reservation, error)
+	ListExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*Reservation, error)
+	PlaceOrder(ctx context.Context, order *Order) error
+	CancelOrder(ctx context.Context, orderID string) (*Order, error)
+}
+
+// StockRepo is the default implementation for StockRepository interface.
+type StockRepo struct {
+	db     *gorm.DB
+	tracer trace.Tracer
+}
+
+// NewRepository returns a new inventory repository object.
+func NewRepository(db *gorm.DB, tracer trace.Tracer) *StockRepo {
+	return &StockRepo{
+		db:     db,
+		tracer: tracer,
+	}
+}
+
+func (r *StockRepo) setMySqlComponentTags(span trace.Span, tableName string) {
+	span.SetAttributes(semconv.DBSystemMySQL, semconv.DBSQLTableKey.String(tableName))
+}
+
+// AdjustStock applies adjustment to the stock level of its sku and appends
+// it to the ledger in a single transaction. The stock level row is locked
+// for the duration of the transaction so concurrent adjustments of the
+// same sku are applied one after the other without lost updates, stock
+// held by reservations cannot be removed.
+func (r *StockRepo) AdjustStock(ctx context.Context, adjustment *Adjustment) (*StockLevel, error) {
+	_, span := r.tracer.Start(ctx, "AdjustStock", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "stock_levels")
+	tracing.SetAttribute(span, "param.adjustment", adjustment)
+
+	level := &StockLevel{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// the row is created first when it does not exist so that there is
+		// always a row to lock, even for the first adjustment of a sku.
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&StockLevel{Sku: adjustment.Sku, UpdatedAt: time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("sku = ?", adjustment.Sku).First(level).Error
+		if err != nil {
+			return err
+		}
+		balance := level.OnHand + adjustment.Quantity
+		if adjustment.Quantity < 0 && balance < level.Reserved {
+			return &InsufficientStockError{
+				Sku: adjustment.Sku, Available: level.Available(), Requested: -adjustment.Quantity,
+			}
+		}
+		level.OnHand = balance
+		level.UpdatedAt = time.Now()
+		err = tx.Model(level).Updates(map[string]interface{}{
+			"on_hand":    level.OnHand,
+			"updated_at": level.UpdatedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		adjustment.ID = 0
+		adjustment.Balance = balance
+		adjustment.CreatedAt = level.UpdatedAt
+		return tx.Create(adjustment).Error
+	})
+	if errors.Is(err, ErrInsufficientStock) {
+		span.AddEvent("insufficient stock")
+		return nil, err
+	}
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return nil, err
+	}
+	tracing.SetAttribute(span, "response.onHand", level.OnHand)
+	return level, nil
+}
+
+// GetStockLevel retrieves the stock level of the provided sku from the
+// database.
+func (r *StockRepo) GetStockLevel(ctx context.Context, sku string) (*StockLevel, error) {
+	_, span := r.tracer.Start(ctx, "GetStockLevel", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "stock_levels")
+	tracing.SetAttribute(span, "param.sku", sku)
+
+	level := &StockLevel{}
+	err := r.db.Where("sku = ?", sku).First(level).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		span.AddEvent("stock level not found")
+		return nil, ErrStockLevelNotFound
+	}
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Where.First")
+		return nil, err
+	}
+	return level, nil
+}
+
+// ReserveStock holds the stock of every item of reservation or none of
+// them. The stock levels of the items are locked in sku order so that
+// concurrent reservations of the same skus cannot both succeed when there
+// is only enough stock for one of them, and cannot deadlock each other.
+func (r *StockRepo) ReserveStock(ctx context.Context, reservation *Reservation) error {
+	_, span := r.tracer.Start(ctx, "ReserveStock", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "reservations")
+	tracing.SetAttribute(span, "param.orderID", reservation.OrderID)
+	tracing.SetAttribute(span, "param.items.count", len(reservation.Items))
+
+	reservation.Items = MergeReservationItems(reservation.Items)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&Reservation{}).Where("order_id = ?", reservation.OrderID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrReservationExists
+		}
+		levels, err := lockStockLevels(tx, reservationItemSKUs(reservation.Items))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, item := range reservation.Items {
+			level, ok := levels[item.Sku]
+			if !ok || level.Available() < item.Quantity {
+				shortage := &InsufficientStockError{Sku: item.Sku, Requested: item.Quantity}
+				if ok {
+					shortage.Available = level.Available()
+				}
+				return shortage
+			}
+			err = tx.Model(level).Updates(map[string]interface{}{
+				"reserved":   gorm.Expr("reserved + ?", item.Quantity),
+				"updated_at": now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		reservation.Status = ReservationPending
+		// an order can be reserved again by another replica between the
+		// count above and this insert, the primary key makes one of them
+		// fail and roll back.
+		err = tx.Create(reservation).Error
+		if isDuplicateEntry(err) {
+			return ErrReservationExists
+		}
+		return err
+	})
+	if errors.Is(err, ErrInsufficientStock) || errors.Is(err, ErrReservationExists) {
+		span.RecordError(err)
+		return err
+	}
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return err
+	}
+	return nil
+}
+
+// isDuplicateEntry reports whether err is a mysqlErrDuplicateEntry error.
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry
+}
+
+// lockStockLevels locks the stock levels of skus in sku order for the
+// duration of tx and returns them by sku, skus that have never been
+// stocked are not returned.
+func lockStockLevels(tx *gorm.DB, skus []string) (map[string]*StockLevel, error) {
+	levels := []*StockLevel{}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("sku IN ?", skus).Order("sku").Find(&levels).Error
+	if err != nil {
+		return nil, err
+	}
+	levelsBySKU := make(map[string]*StockLevel, len(levels))
+	for _, level := range levels {
+		levelsBySKU[level.Sku] = level
+	}
+	return levelsBySKU, nil
+}
+
+// GetReservation retrieves the reservation of the provided order with its
+// items from the database.
+func (r *StockRepo) GetReservation(ctx context.Context, orderID string) (*Reservation, error) {
+	_, span := r.tracer.Start(ctx, "GetReservation", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "reservations")
+	tracing.SetAttribute(span, "param.orderID", orderID)
+
+	reservation, err := getReservation(r.db, orderID)
+	if errors.Is(err, ErrReservationNotFound) {
+		span.AddEvent("reservation not found")
+		return nil, err
+	}
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Where.First")
+		return nil, err
+	}
+	return reservation, nil
+}
+
+func getReservation(db *gorm.DB, orderID string) (*Reservation, error) {
+	reservation := &Reservation{}
+	err := db.Where("order_id = ?", orderID).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("sku") }).
+		First(reservation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// CommitReservation sells the stock held by the pending reservation of the
+// provided order, the sale of every item is appended to the ledger and
+// messages are added to the outbox in the same transaction.
+func (r *StockRepo) CommitReservation(ctx context.Context, orderID string, messages []*outbox.Message) (*Reservation, error) {
+	_, span := r.tracer.Start(ctx, "CommitReservation", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "reservations")
+	tracing.SetAttribute(span, "param.orderID", orderID)
+
+	var reservation *Reservation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservation, err = lockPendingReservation(tx, orderID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !reservation.ExpiresAt.After(now) {
+			return ErrReservationExpired
+		}
+		levels, err := lockStockLevels(tx, reservationItemSKUs(reservation.Items))
+		if err != nil {
+			return err
+		}
+		for _, item := range reservation.Items {
+			level, ok := levels[item.Sku]
+			if !ok {
+				return fmt.Errorf("stock level of reserved sku %s does not exist", item.Sku)
+			}
+			level.OnHand -= item.Quantity
+			level.Reserved -= item.Quantity
+			level.UpdatedAt = now
+			err = tx.Model(level).Updates(map[string]interface{}{
+				"on_hand":    level.OnHand,
+				"reserved":   level.Reserved,
+				"updated_at": level.UpdatedAt,
+			}).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Create(&Adjustment{
+				Sku:       item.Sku,
+				Kind:      KindSell,
+				Quantity:  -item.Quantity,
+				Balance:   level.OnHand,
+				Reason:    "order " + orderID,
+				Actor:     reservation.UserID,
+				CreatedAt: now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		err = setReservationStatus(tx, reservation, ReservationCommitted, now)
+		if err != nil {
+			return err
+		}
+		return outbox.Save(tx, messages)
+	})
+	if err != nil {
+		return nil, r.reservationError(span, err)
+	}
+	return reservation, nil
+}
+
+// ReleaseReservation gives back the stock held by the pending reservation
+// of the provided order, status is either ReservationReleased or
+// ReservationExpired. messages are added to the outbox in the same
+// transaction.
+func (r *StockRepo) ReleaseReservation(ctx context.Context, orderID string, status ReservationStatus, messages []*outbox.Message) (*Reservation, error) {
+	_, span := r.tracer.Start(ctx, "ReleaseReservation", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "reservations")
+	tracing.SetAttribute(span, "param.orderID", orderID)
+	tracing.SetAttribute(span, "param.status", status)
+
+	var reservation *Reservation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservation, err = lockPendingReservation(tx, orderID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		_, err = lockStockLevels(tx, reservationItemSKUs(reservation.Items))
+		if err != nil {
+			return err
+		}
+		err = unreserveStock(tx, reservation, now)
+		if err != nil {
+			return err
+		}
+		err = setReservationStatus(tx, reservation, status, now)
+		if err != nil {
+			return err
+		}
+		return outbox.Save(tx, messages)
+	})
+	if err != nil {
+		return nil, r.reservationError(span, err)
+	}
+	return reservation, nil
+}
+
+// lockPendingReservation locks the reservation of the provided order for
+// the duration of tx, the reservation must be pending.
+func lockPendingReservation(tx *gorm.DB, orderID string) (*Reservation, error) {
+	reservation, err := getReservation(tx.Clauses(clause.Locking{Strength: "UPDATE"}), orderID)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Status != ReservationPending {
+		return nil, ErrReservationNotPending
+	}
+	return reservation, nil
+}
+
+// unreserveStock gives back the stock held by reservation, the stock
+// levels of its items must be locked by tx.
+func unreserveStock(tx *gorm.DB, reservation *Reservation, now time.Time) error {
+	for _, item := range reservation.Items {
+		err := tx.Model(&StockLevel{}).Where("sku = ?", item.Sku).Updates(map[string]interface{}{
+			"reserved":   gorm.Expr("reserved - ?", item.Quantity),
+			"updated_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setReservationStatus(tx *gorm.DB, reservation *Reservation, status ReservationStatus, now time.Time) error {
+	reservation.Status = status
+	reservation.UpdatedAt = now
+	return tx.Model(&Reservation{}).Where("order_id = ?", reservation.OrderID).Updates(map[string]interface{}{
+		"status":     status,
+		"updated_at": now,
+	}).Error
+}
+
+// reservationError logs err to span, errors caused by the state of the
+// reservation are not marked as span errors.
+func (r *StockRepo) reservationError(span trace.Span, err error) error {
+	switch {
+	case errors.Is(err, ErrReservationNotFound),
+		errors.Is(err, ErrReservationNotPending),
+		errors.Is(err, ErrReservationExpired):
+		span.RecordError(err)
+	default:
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+	}
+	return err
+}
+
+// ListExpiredReservations retrieves at most limit pending reservations
+// that expired before now with their items from the database, oldest
+// first.
+func (r *StockRepo) ListExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*Reservation, error) {
+	_, span := r.tracer.Start(ctx, "ListExpiredReservations", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "reservations")
+	tracing.SetAttribute(span, "param.limit", limit)
+
+	reservations := []*Reservation{}
+	err := r.db.Where("status = ? AND expires_at <= ?", ReservationPending, now).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("sku") }).
+		Order("expires_at").Limit(limit).Find(&reservations).Error
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Where.Preload.Order.Limit.Find")
+		return nil, err
+	}
+	tracing.SetAttribute(span, "response.count", len(reservations))
+	return reservations, nil
+}
+
+// PlaceOrder sells the stock of the items of order and adds their units to
+// the sales of their products in a single transaction. The checkout
+// service has already placed the order so its stock is sold even when a
+// sku is oversold. The stock of an order whose reservation was committed
+// has already been sold and is not sold again, a pending reservation of
+// the order is committed by the order so that the stock it holds is given
+// back and cannot be committed or released again.
+func (r *StockRepo) PlaceOrder(ctx context.Context, order *Order) error {
+	_, span := r.tracer.Start(ctx, "PlaceOrder", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "orders")
+	tracing.SetAttribute(span, "param.orderID", order.ID)
+	tracing.SetAttribute(span, "param.items.count", len(order.Items))
+
+	order.Items = MergeOrderItems(order.Items)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&Order{}).Where("id = ?", order.ID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrOrderExists
+		}
+		now := time.Now()
+		// the reservation is locked before the stock levels, like
+		// CommitReservation and ReleaseReservation do.
+		reservation, err := getReservation(tx.Clauses(clause.Locking{Strength: "UPDATE"}), order.ID)
+		if err != nil && !errors.Is(err, ErrReservationNotFound) {
+			return err
+		}
+		switch {
+		case reservation != nil && reservation.Status == ReservationCommitted:
+			span.AddEvent("stock sold by the committed reservation")
+		case reservation != nil && reservation.Status == ReservationPending:
+			span.AddEvent("committing the pending reservation")
+			err = sellOrderStock(tx, span, order, reservation, now)
+			if err != nil {
+				return err
+			}
+			err = setReservationStatus(tx, reservation, ReservationCommitted, now)
+			if err != nil {
+				return err
+			}
+		default:
+			err = sellOrderStock(tx, span, order, nil, now)
+			if err != nil {
+				return err
+			}
+		}
+		err = addProductSales(tx, order.Items, 1, now)
+		if err != nil {
+			return err
+		}
+		order.Status = OrderPlaced
+		order.CreatedAt = now
+		order.UpdatedAt = now
+		// an order can be placed again by another replica between the
+		// count above and this insert, the primary key makes one of them
+		// fail and roll back.
+		return tx.Create(order).Error
+	})
+	if errors.Is(err, ErrOrderExists) {
+		span.RecordError(err)
+		return err
+	}
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return err
+	}
+	return nil
+}
+
+// sellOrderStock sells the stock of the items of order and appends the
+// sales to the ledger. The stock held by reservation, when it is not nil,
+// is given back in the same locks.
+func sellOrderStock(tx *gorm.DB, span trace.Span, order *Order, reservation *Reservation, now time.Time) error {
+	skus := orderItemSKUs(order.Items)
+	// the rows are created first when they do not exist so that there are
+	// always rows to lock, even for skus that have never been stocked.
+	for _, sku := range skus {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&StockLevel{Sku: sku, UpdatedAt: now}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if reservation != nil {
+		skus = append(skus, reservationItemSKUs(reservation.Items)...)
+	}
+	levels, err := lockStockLevels(tx, skus)
+	if err != nil {
+		return err
+	}
+	if reservation != nil {
+		err = unreserveStock(tx, reservation, now)
+		if err != nil {
+			return err
+		}
+		for _, item := range reservation.Items {
+			if level, ok := levels[item.Sku]; ok {
+				level.Reserved -= item.Quantity
+			}
+		}
+	}
+	for _, item := range order.Items {
+		level := levels[item.Sku]
+		level.OnHand -= item.Quantity
+		level.UpdatedAt = now
+		if level.Available() < 0 {
+			tracing.AddEvent(span, "oversold", attribute.String("sku", item.Sku))
+		}
+		err = tx.Model(level).Updates(map[string]interface{}{
+			"on_hand":    level.OnHand,
+			"updated_at": level.UpdatedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&Adjustment{
+			Sku:       item.Sku,
+			Kind:      KindSell,
+			Quantity:  -item.Quantity,
+			Balance:   level.OnHand,
+			Reason:    "order " + order.ID,
+			Actor:     order.UserID,
+			CreatedAt: now,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addProductSales adds the units of items multiplied by sign to the sales
+// of their products.
+func addProductSales(tx *gorm.DB, items []OrderItem, sign int64, now time.Time) error {
+	for _, sales := range productSales(items) {
+		sales.UnitsSold *= sign
+		sales.UpdatedAt = now
+		err := tx.Clauses(clause.OnConflict{DoUpdates: clause.Assignments(map[string]interface{}{
+			"units_sold": gorm.Expr("units_sold + ?", sales.UnitsSold),
+			"updated_at": now,
+		})}).Create(sales).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CancelOrder gives back the stock sold by the placed order with the
+// provided id and removes its units from the sales of their products in a
+// single transaction. An order that has not been placed yet is recorded as
+// cancelled without items, so that it is not placed when its placed event
+// is delivered after the cancellation.
+func (r *StockRepo) CancelOrder(ctx context.Context, orderID string) (*Order, error) {
+	_, span := r.tracer.Start(ctx, "CancelOrder", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "orders")
+	tracing.SetAttribute(span, "param.orderID", orderID)
+
+	order := &Order{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", orderID).
+			Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("sku") }).
+			First(order).Error
+		now := time.Now()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			span.AddEvent("cancelling order that has not been placed")
+			order = &Order{ID: orderID, Status: OrderCancelled, CreatedAt: now, UpdatedAt: now}
+			return tx.Create(order).Error
+		}
+		if err != nil {
+			return err
+		}
+		if order.Status == OrderCancelled {
+			return ErrOrderCancelled
+		}
+		levels, err := lockStockLevels(tx, orderItemSKUs(order.Items))
+		if err != nil {
+			return err
+		}
+		for _, item := range order.Items {
+			level, ok := levels[item.Sku]
+			if !ok {
+				return fmt.Errorf("stock level of sold sku %s does not exist", item.Sku)
+			}
+			level.OnHand += item.Quantity
+			level.UpdatedAt = now
+			err = tx.Model(level).Updates(map[string]interface{}{
+				"on_hand":    level.OnHand,
+				"updated_at": level.UpdatedAt,
+			}).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Create(&Adjustment{
+				Sku:       item.Sku,
+				Kind:      KindReturn,
+				Quantity:  item.Quantity,
+				Balance:   level.OnHand,
+				Reason:    "order " + orderID + " cancelled",
+				Actor:     order.UserID,
+				CreatedAt: now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		err = addProductSales(tx, order.Items, -1, now)
+		if err != nil {
+			return err
+		}
+		order.Status = OrderCancelled
+		order.UpdatedAt = now
+		return tx.Model(&Order{}).Where("id = ?", orderID).Updates(map[string]interface{}{
+			"status":     order.Status,
+			"updated_at": now,
+		}).Error
+	})
+	if errors.Is(err, ErrOrderCancelled) {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return nil, err
+	}
+	return order, nil
+}
@@ -0,0 +1,334 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel/trace"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeStore is a database/sql driver connection that keeps rows in memory
+// and understands just enough of the statements of StockRepo and of the
+// migrations to run them: selects and updates of rows by comparisons of
+// their columns, inserts and column renames. A table has the columns of
+// its rows. Transactions are not rolled back.
+type fakeStore struct {
+	tables map[string][]map[string]driver.Value
+	// failOnce is the prefix of a statement that fails the next time it
+	// is executed.
+	failOnce string
+}
+
+// fakeStoreKeys are the primary keys of the tables whose rows must be
+// unique.
+var fakeStoreKeys = map[string]string{
+	"stock_levels": "sku",
+	"reservations": "order_id",
+	"orders":       "id",
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{tables: map[string][]map[string]driver.Value{}}
+}
+
+func (s *fakeStore) Connect(ctx context.Context) (driver.Conn, error) { return s, nil }
+func (s *fakeStore) Driver() driver.Driver                            { return nil }
+
+func (s *fakeStore) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (s *fakeStore) Close() error              { return nil }
+func (s *fakeStore) Begin() (driver.Tx, error) { return s, nil }
+func (s *fakeStore) Commit() error             { return nil }
+func (s *fakeStore) Rollback() error           { return nil }
+
+func (s *fakeStore) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	switch {
+	case query == "SELECT DATABASE()":
+		return &fakeRows{columns: []string{"DATABASE()"}, values: [][]driver.Value{{"products"}}}, nil
+	case strings.Contains(query, "FROM INFORMATION_SCHEMA.columns"):
+		var count int64
+		if rows := s.tables[args[1].Value.(string)]; len(rows) > 0 && rows[0][args[2].Value.(string)] != nil {
+			count = 1
+		}
+		return &fakeRows{columns: []string{"count(*)"}, values: [][]driver.Value{{count}}}, nil
+	}
+	table := between(query, "FROM `", "`")
+	rows := s.where(table, between(query, "WHERE ", " ORDER BY")+" ", args)
+	if strings.HasPrefix(query, "SELECT count(*)") {
+		return &fakeRows{columns: []string{"count(*)"}, values: [][]driver.Value{{int64(len(rows))}}}, nil
+	}
+	result := &fakeRows{}
+	for _, row := range rows {
+		if result.columns == nil {
+			for column := range row {
+				result.columns = append(result.columns, column)
+			}
+			sort.Strings(result.columns)
+		}
+		values := make([]driver.Value, 0, len(row))
+		for _, column := range result.columns {
+			values = append(values, row[column])
+		}
+		result.values = append(result.values, values)
+	}
+	return result, nil
+}
+
+func (s *fakeStore) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if s.failOnce != "" && strings.HasPrefix(query, s.failOnce) {
+		s.failOnce = ""
+		return nil, errors.New("connection lost")
+	}
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO "):
+		return s.insert(query, args)
+	case strings.HasPrefix(query, "UPDATE "):
+		return s.update(query, args)
+	case strings.HasPrefix(query, "ALTER TABLE ") && strings.Contains(query, " RENAME COLUMN "):
+		// ALTER TABLE table RENAME COLUMN from TO to
+		fields := strings.Fields(query)
+		for _, row := range s.tables[fields[2]] {
+			row[fields[7]] = row[fields[5]]
+			delete(row, fields[5])
+		}
+		return fakeResult(0), nil
+	}
+	return nil, fmt.Errorf("statement is not supported: %s", query)
+}
+
+func (s *fakeStore) insert(query string, args []driver.NamedValue) (driver.Result, error) {
+	table := between(query, "INSERT INTO `", "`")
+	columns := strings.Split(strings.ReplaceAll(between(query, "(", ")"), "`", ""), ",")
+	key := fakeStoreKeys[table]
+	var inserted int64
+	for i := 0; i+len(columns) <= len(args); i += len(columns) {
+		row := map[string]driver.Value{}
+		for j, column := range columns {
+			row[column] = args[i+j].Value
+		}
+		if key != "" && len(s.where(table, "`"+key+"` = ? ", []driver.NamedValue{{Value: row[key]}})) > 0 {
+			if strings.Contains(query, "ON DUPLICATE KEY") {
+				continue
+			}
+			return nil, &mysql.MySQLError{Number: mysqlErrDuplicateEntry, Message: fmt.Sprintf("Duplicate entry '%v' for key 'PRIMARY'", row[key])}
+		}
+		s.tables[table] = append(s.tables[table], row)
+		inserted++
+	}
+	return fakeResult(inserted), nil
+}
+
+// update applies assignments of the form `column`=? and
+// `column`=column + ? or column - ? to the matching rows.
+func (s *fakeStore) update(query string, args []driver.NamedValue) (driver.Result, error) {
+	table := between(query, "UPDATE `", "`")
+	assignments := strings.Split(between(query, " SET ", " WHERE "), ",")
+	condition := query[strings.Index(query, " WHERE ")+len(" WHERE "):]
+	rows := s.where(table, condition+" ", args[len(assignments):])
+	for _, row := range rows {
+		for i, assignment := range assignments {
+			parts := strings.SplitN(assignment, "=", 2)
+			column, expression := strings.Trim(parts[0], "`"), parts[1]
+			switch {
+			case strings.HasSuffix(expression, "+ ?"):
+				row[column] = row[column].(int64) + args[i].Value.(int64)
+			case strings.HasSuffix(expression, "- ?"):
+				row[column] = row[column].(int64) - args[i].Value.(int64)
+			default:
+				row[column] = args[i].Value
+			}
+		}
+	}
+	return fakeResult(len(rows)), nil
+}
+
+// where returns the rows of table matching condition, comparisons of a
+// column with arguments joined by AND, in order of their sku or of their
+// key.
+func (s *fakeStore) where(table, condition string, args []driver.NamedValue) []map[string]driver.Value {
+	rows := []map[string]driver.Value{}
+	for _, row := range s.tables[table] {
+		if matches(row, condition, args) {
+			rows = append(rows, row)
+		}
+	}
+	order := fakeStoreKeys[table]
+	if len(rows) > 0 && rows[0]["sku"] != nil {
+		order = "sku"
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return fmt.Sprint(rows[i][order]) < fmt.Sprint(rows[j][order])
+	})
+	return rows
+}
+
+// matches reports whether row matches condition, the comparisons are
+// either column > ?, column = ? or column IN (?, ...).
+func matches(row map[string]driver.Value, condition string, args []driver.NamedValue) bool {
+	for _, comparison := range strings.Split(strings.TrimSpace(condition), " AND ") {
+		fields := strings.Fields(comparison)
+		column := strings.Trim(fields[0][strings.LastIndex(fields[0], ".")+1:], "`")
+		values := args[:strings.Count(comparison, "?")]
+		args = args[len(values):]
+		if fields[1] == ">" {
+			if row[column].(int64) <= values[0].Value.(int64) {
+				return false
+			}
+			continue
+		}
+		found := false
+		for _, value := range values {
+			found = found || row[column] == value.Value
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// between returns the part of s between the first start and the next end,
+// or the rest of s when end is not found.
+func between(s, start, end string) string {
+	s = s[strings.Index(s, start)+len(start):]
+	if i := strings.Index(s, end); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// fakeResult is the number of rows affected by a statement, inserted
+// rows have no generated ids.
+type fakeResult int64
+
+func (r fakeResult) LastInsertId() (int64, error) { return 0, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func newFakeStockRepo(t *testing.T, store *fakeStore) *StockRepo {
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{Conn: sql.OpenDB(store), SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return NewRepository(db, trace.NewNoopTracerProvider().Tracer(""))
+}
+
+func TestStockRepo_PlaceOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		reserve      bool
+		commit       bool
+		wantOnHand   int64
+		wantReserved int64
+	}{
+		{name: "without reservation", wantOnHand: 3},
+		{name: "pending reservation", reserve: true, wantOnHand: 3},
+		{name: "committed reservation", reserve: true, commit: true, wantOnHand: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			store.tables["stock_levels"] = []map[string]driver.Value{
+				{"sku": "sku.1", "on_hand": int64(5), "reserved": int64(0), "updated_at": time.Now()},
+			}
+			repo := newFakeStockRepo(t, store)
+			ctx := context.Background()
+			if tt.reserve {
+				err := repo.ReserveStock(ctx, &Reservation{
+					OrderID:   "order.1",
+					Items:     []ReservationItem{{Sku: "sku.1", Quantity: 2}},
+					ExpiresAt: time.Now().Add(time.Hour),
+				})
+				if err != nil {
+					t.Fatalf("ReserveStock() error = %v", err)
+				}
+			}
+			if tt.commit {
+				if _, err := repo.CommitReservation(ctx, "order.1", nil); err != nil {
+					t.Fatalf("CommitReservation() error = %v", err)
+				}
+			}
+
+			err := repo.PlaceOrder(ctx, &Order{
+				ID:    "order.1",
+				Items: []OrderItem{{Sku: "sku.1", ProductSku: "sku.1", Quantity: 2}},
+			})
+			if err != nil {
+				t.Fatalf("PlaceOrder() error = %v", err)
+			}
+			if tt.reserve {
+				// the order consumed the reservation, it cannot sell or
+				// give back its stock again.
+				if _, err = repo.CommitReservation(ctx, "order.1", nil); !errors.Is(err, ErrReservationNotPending) {
+					t.Errorf("CommitReservation() error = %v, want %v", err, ErrReservationNotPending)
+				}
+				if _, err = repo.ReleaseReservation(ctx, "order.1", ReservationReleased, nil); !errors.Is(err, ErrReservationNotPending) {
+					t.Errorf("ReleaseReservation() error = %v, want %v", err, ErrReservationNotPending)
+				}
+			}
+			level, err := repo.GetStockLevel(ctx, "sku.1")
+			if err != nil {
+				t.Fatalf("GetStockLevel() error = %v", err)
+			}
+			if level.OnHand != tt.wantOnHand || level.Reserved != tt.wantReserved {
+				t.Errorf("GetStockLevel() on hand = %v, reserved = %v, want %v, %v", level.OnHand, level.Reserved, tt.wantOnHand, tt.wantReserved)
+			}
+		})
+	}
+}
+
+func Test_isDuplicateEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil error", err: nil, want: false},
+		{name: "duplicate entry", err: &mysql.MySQLError{Number: mysqlErrDuplicateEntry}, want: true},
+		{
+			name: "wrapped duplicate entry",
+			err:  fmt.Errorf("inserting reservation: %w", &mysql.MySQLError{Number: mysqlErrDuplicateEntry}), want: true,
+		},
+		{name: "other mysql error", err: &mysql.MySQLError{Number: 1213}, want: false},
+		{name: "other error", err: errors.New("connection refused"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDuplicateEntry(tt.err); got != tt.want {
+				t.Errorf("isDuplicateEntry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package inventory
+
+import (
+	"sort"
+	"time"
+)
+
+// ReservationStatus is the state of a stock reservation.
+type ReservationStatus string
+
+const (
+	// ReservationPending is a reservation that holds stock until it is
+	// committed, released or expires.
+	ReservationPending ReservationStatus = "pending"
+	// ReservationCommitted is a reservation whose stock has been sold.
+	ReservationCommitted ReservationStatus = "committed"
+	// ReservationReleased is a reservation whose stock was given back
+	// before it expired.
+	ReservationReleased ReservationStatus = "released"
+	// ReservationExpired is a reservation whose stock was given back
+	// because it was not committed in time.
+	ReservationExpired ReservationStatus = "expired"
+)
+
+// Reservation holds stock of one or more skus for an order during
+// checkout.
+type Reservation struct {
+	OrderID string `json:"orderId" gorm:"primaryKey;size:64"`
+	// UserID is the id of the shopper that made the reservation.
+	UserID    string            `json:"userId" gorm:"size:64"`
+	Status    ReservationStatus `json:"status" gorm:"size:20;index:idx_reservations_status_expires_at"`
+	Items     []ReservationItem `json:"items" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	ExpiresAt time.Time         `json:"expiresAt" gorm:"index:idx_reservations_status_expires_at"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// ReservationItem is the quantity of a sku held by a reservation.
+type ReservationItem struct {
+	ID       int64  `json:"-" gorm:"autoIncrement,primaryKey"`
+	OrderID  string `json:"-" gorm:"index;size:64"`
+	Sku      string `json:"sku" gorm:"size:64"`
+	Quantity int64  `json:"quantity"`
+}
+
+// MergeReservationItems merges the items of the same sku and sorts them by
+// sku, the order stock levels are locked in to avoid deadlocks between
+// concurrent reservations.
+func MergeReservationItems(items []ReservationItem) []ReservationItem {
+	quantities := map[string]int64{}
+	merged := []ReservationItem{}
+	for _, item := range items {
+		if _, ok := quantities[item.Sku]; !ok {
+			merged = append(merged, ReservationItem{Sku: item.Sku})
+		}
+		quantities[item.Sku] += item.Quantity
+	}
+	for i := range merged {
+		merged[i].Quantity = quantities[merged[i].Sku]
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Sku < merged[j].Sku })
+	return merged
+}
+
+// reservationItemSKUs returns the skus of items in order.
+func reservationItemSKUs(items []ReservationItem) []string {
+	skus := make([]string, 0, len(items))
+	for _, item := range items {
+		skus = append(skus, item.Sku)
+	}
+	return skus
+}
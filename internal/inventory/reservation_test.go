@@ -0,0 +1,37 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeReservationItems(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []ReservationItem
+		want  []ReservationItem
+	}{
+		{
+			name:  "single item",
+			items: []ReservationItem{{Sku: "b", Quantity: 1}},
+			want:  []ReservationItem{{Sku: "b", Quantity: 1}},
+		},
+		{
+			name:  "items are sorted by sku",
+			items: []ReservationItem{{Sku: "c", Quantity: 1}, {Sku: "a", Quantity: 2}, {Sku: "b", Quantity: 3}},
+			want:  []ReservationItem{{Sku: "a", Quantity: 2}, {Sku: "b", Quantity: 3}, {Sku: "c", Quantity: 1}},
+		},
+		{
+			name:  "items of the same sku are merged",
+			items: []ReservationItem{{Sku: "b", Quantity: 1}, {Sku: "a", Quantity: 2}, {Sku: "b", Quantity: 4}},
+			want:  []ReservationItem{{Sku: "a", Quantity: 2}, {Sku: "b", Quantity: 5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeReservationItems(tt.items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeReservationItems() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
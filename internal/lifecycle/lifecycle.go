@@ -0,0 +1,232 @@
+// Package lifecycle stops the service gracefully. A Manager runs the
+// background workers of the service, waits for a termination signal and
+// then runs the shutdown hooks of the components in the order they were
+// added, so that new work is refused first, in-flight work is drained and
+// the connections are closed last.
+package lifecycle
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hook stops a component of the service, ctx is done when the shutdown
+// deadline is exceeded.
+type Hook func(ctx context.Context) error
+
+// HookError is the error a shutdown hook returned.
+type HookError struct {
+	Name string
+	Err  error
+}
+
+func (e HookError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+func (e HookError) Unwrap() error {
+	return e.Err
+}
+
+// ShutdownError is the list of the shutdown hooks that failed.
+type ShutdownError []HookError
+
+func (e ShutdownError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, hookErr := range e {
+		messages = append(messages, hookErr.Error())
+	}
+	return "shutdown failed: " + strings.Join(messages, "; ")
+}
+
+type hook struct {
+	name string
+	run  Hook
+}
+
+// Manager runs the background workers and the shutdown hooks of the
+// service.
+type Manager struct {
+	timeout time.Duration
+
+	workersCtx  context.Context
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
+
+	failed chan error
+
+	mu    sync.Mutex
+	hooks []hook
+}
+
+// NewManager returns a new lifecycle manager, the shutdown hooks must all
+// return within timeout.
+func NewManager(timeout time.Duration) *Manager {
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	return &Manager{
+		timeout:     timeout,
+		workersCtx:  workersCtx,
+		stopWorkers: stopWorkers,
+		failed:      make(chan error, 1),
+	}
+}
+
+// Go runs worker in a goroutine, the context of worker is done when the
+// StopWorkers hook runs.
+func (m *Manager) Go(worker func(ctx context.Context)) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		worker(m.workersCtx)
+	}()
+}
+
+// StopWorkers is the hook that stops the workers started with Go, it
+// waits until they return.
+func (m *Manager) StopWorkers(ctx context.Context) error {
+	m.stopWorkers()
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OnShutdown adds a shutdown hook, the hooks run in the order they were
+// added.
+func (m *Manager) OnShutdown(name string, run Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, run: run})
+}
+
+// Fail reports that a component stopped working, e.g the grpc server
+// stopped serving, so that the service shuts down. Wait returns the first
+// reported error.
+func (m *Manager) Fail(err error) {
+	select {
+	case m.failed <- err:
+	default:
+	}
+}
+
+// Wait blocks until one of signals is received or a component fails. It
+// returns the received signal, or the error passed to Fail.
+func (m *Manager) Wait(signals ...os.Signal) (os.Signal, error) {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	defer signal.Stop(received)
+	select {
+	case sig := <-received:
+		return sig, nil
+	case err := <-m.failed:
+		return nil, err
+	}
+}
+
+// hookGrace is how long a hook that is still running when the shutdown
+// deadline is exceeded is waited for, e.g closing a connection does not
+// depend on the deadline and returns right away.
+var hookGrace = time.Second
+
+// Shutdown runs the shutdown hooks in order. A failed hook does not stop
+// the shutdown, and a hook that has not returned hookGrace after the
+// deadline is exceeded is left behind so that the next hooks still run,
+// e.g the database is closed even when draining the requests timed out.
+func (m *Manager) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	m.mu.Lock()
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	var errs ShutdownError
+	for _, h := range hooks {
+		err := runHook(ctx, h.run)
+		if err != nil {
+			errs = append(errs, HookError{Name: h.name, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// runHook returns the error of hook, or the error of ctx when hook has not
+// returned hookGrace after ctx is done.
+func runHook(ctx context.Context, hook Hook) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- hook(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+	grace := time.NewTimer(hookGrace)
+	defer grace.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-grace.C:
+		return ctx.Err()
+	}
+}
+
+// Delay returns the hook that waits for d, e.g so that load balancers
+// notice that the service is not serving anymore before it stops
+// accepting connections.
+func Delay(d time.Duration) Hook {
+	return func(ctx context.Context) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// GracefulStopper is the interface that describes a server that can be
+// stopped gracefully, *grpc.Server implements it.
+type GracefulStopper interface {
+	GracefulStop()
+	Stop()
+}
+
+// GracefulStop returns the hook that stops server from accepting new
+// connections and waits for the in-flight calls to finish. The calls that
+// are still running after timeout are canceled.
+func GracefulStop(server GracefulStopper, timeout time.Duration) Hook {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			server.Stop()
+			<-stopped
+			return ctx.Err()
+		}
+	}
+}
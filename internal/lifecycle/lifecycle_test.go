@@ -0,0 +1,168 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestManager_Shutdown(t *testing.T) {
+	hookGrace = 10 * time.Millisecond
+	failed := errors.New("closing: connection reset")
+	tests := []struct {
+		name      string
+		hooks     map[string]Hook
+		wantCalls []string
+		wantErr   ShutdownError
+	}{
+		{
+			name:      "hooks run in order",
+			hooks:     map[string]Hook{},
+			wantCalls: []string{"grpc", "workers", "outbox", "nats", "database"},
+		},
+		{
+			name: "failed hook does not stop the shutdown",
+			hooks: map[string]Hook{
+				"outbox": func(ctx context.Context) error { return failed },
+			},
+			wantCalls: []string{"grpc", "workers", "nats", "database"},
+			wantErr:   ShutdownError{{Name: "outbox", Err: failed}},
+		},
+		{
+			name: "hook exceeding the deadline is left behind",
+			hooks: map[string]Hook{
+				"grpc": func(ctx context.Context) error {
+					select {}
+				},
+			},
+			wantCalls: []string{"workers", "outbox", "nats", "database"},
+			wantErr:   ShutdownError{{Name: "grpc", Err: context.DeadlineExceeded}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(50 * time.Millisecond)
+			var (
+				mu    sync.Mutex
+				calls []string
+			)
+			for _, name := range []string{"grpc", "workers", "outbox", "nats", "database"} {
+				name := name
+				hook, ok := tt.hooks[name]
+				if !ok {
+					hook = func(ctx context.Context) error {
+						mu.Lock()
+						defer mu.Unlock()
+						calls = append(calls, name)
+						return nil
+					}
+				}
+				m.OnShutdown(name, hook)
+			}
+			err := m.Shutdown()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("Manager.Shutdown() error = %v, want %v", err, tt.wantErr)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("Manager.Shutdown() calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestManager_StopWorkers(t *testing.T) {
+	m := NewManager(time.Second)
+	stopped := make(chan struct{})
+	m.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		// the worker finishes its work before returning.
+		time.Sleep(10 * time.Millisecond)
+		close(stopped)
+	})
+	err := m.StopWorkers(context.Background())
+	if err != nil {
+		t.Fatalf("Manager.StopWorkers() error = %v", err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("Manager.StopWorkers() returned before the worker")
+	}
+
+	m = NewManager(time.Second)
+	m.Go(func(ctx context.Context) {
+		select {}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = m.StopWorkers(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Manager.StopWorkers() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestManager_Wait(t *testing.T) {
+	m := NewManager(time.Second)
+	failed := errors.New("grpc: serve failed")
+	m.Fail(failed)
+	m.Fail(errors.New("metrics: serve failed"))
+	sig, err := m.Wait()
+	if sig != nil || err != failed {
+		t.Errorf("Manager.Wait() = %v, %v, want the first failure %v", sig, err, failed)
+	}
+}
+
+// fakeServer is a server whose graceful stop returns when Stop is called
+// or release is closed.
+type fakeServer struct {
+	release chan struct{}
+	stopped chan struct{}
+}
+
+func (s *fakeServer) GracefulStop() {
+	select {
+	case <-s.release:
+	case <-s.stopped:
+	}
+}
+
+func (s *fakeServer) Stop() {
+	close(s.stopped)
+}
+
+func TestGracefulStop(t *testing.T) {
+	tests := []struct {
+		name        string
+		inFlight    time.Duration
+		wantErr     error
+		wantStopped bool
+	}{
+		{name: "in-flight calls finished", inFlight: 0},
+		{name: "in-flight calls canceled", inFlight: time.Hour, wantErr: context.DeadlineExceeded, wantStopped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &fakeServer{release: make(chan struct{}), stopped: make(chan struct{})}
+			time.AfterFunc(tt.inFlight, func() { close(server.release) })
+			err := GracefulStop(server, 20*time.Millisecond)(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GracefulStop() error = %v, want %v", err, tt.wantErr)
+			}
+			select {
+			case <-server.stopped:
+				if !tt.wantStopped {
+					t.Error("GracefulStop() stopped the server forcibly")
+				}
+			default:
+				if tt.wantStopped {
+					t.Error("GracefulStop() did not stop the server forcibly")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Package metrics contains the prometheus metrics of the service that are
+// not owned by a single package, e.g the metrics of the grpc server and
+// clients.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServerMetrics are the request metrics of a grpc server.
+type GRPCServerMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewGRPCServerMetrics returns the metrics of a grpc server registered
+// with registerer.
+func NewGRPCServerMetrics(registerer prometheus.Registerer) *GRPCServerMetrics {
+	m := &GRPCServerMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "product_service",
+			Subsystem: "grpc",
+			Name:      "requests_total",
+			Help:      "gRPC requests handled by method and status code.",
+		}, []string{"method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "product_service",
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the gRPC requests handled by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+	}
+	registerer.MustRegister(m.requests, m.duration)
+	return m
+}
+
+func (m *GRPCServerMetrics) observe(method string, start time.Time, err error) {
+	code := status.Code(err).String()
+	m.requests.WithLabelValues(method, code).Inc()
+	m.duration.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+}
+
+// UnaryServerInterceptor records the metrics of unary requests.
+func (m *GRPCServerMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observe(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records the metrics of streams, the latency of
+// a stream is its whole duration.
+func (m *GRPCServerMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observe(info.FullMethod, start, err)
+		return err
+	}
+}
+
+// GRPCClientMetrics are the metrics of the calls made by a grpc client.
+type GRPCClientMetrics struct {
+	duration *prometheus.HistogramVec
+}
+
+// NewGRPCClientMetrics returns the metrics of the grpc client of service,
+// e.g user_service, registered with registerer.
+func NewGRPCClientMetrics(registerer prometheus.Registerer, service string) *GRPCClientMetrics {
+	m := &GRPCClientMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "product_service",
+			Subsystem: service,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the gRPC calls to " + service + " by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+	}
+	registerer.MustRegister(m.duration)
+	return m
+}
+
+// UnaryClientInterceptor records the latency of unary calls.
+func (m *GRPCClientMetrics) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		m.duration.WithLabelValues(method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCServerMetrics_UnaryServerInterceptor(t *testing.T) {
+	m := NewGRPCServerMetrics(prometheus.NewRegistry())
+	interceptor := m.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/ProductService/GetProduct"}
+	handlers := []grpc.UnaryHandler{
+		func(ctx context.Context, req interface{}) (interface{}, error) { return "product", nil },
+		func(ctx context.Context, req interface{}) (interface{}, error) { return "product", nil },
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.NotFound, "product does not exist")
+		},
+	}
+	for _, handler := range handlers {
+		interceptor(context.Background(), nil, info, handler)
+	}
+
+	tests := []struct {
+		code string
+		want float64
+	}{
+		{code: "OK", want: 2},
+		{code: "NotFound", want: 1},
+		{code: "Internal", want: 0},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(m.requests.WithLabelValues(info.FullMethod, tt.code)); got != tt.want {
+			t.Errorf("requests with code %s = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+	if got := testutil.CollectAndCount(m.duration); got != 2 {
+		t.Errorf("request duration series = %v, want 2", got)
+	}
+}
+
+func TestGRPCClientMetrics_UnaryClientInterceptor(t *testing.T) {
+	m := NewGRPCClientMetrics(prometheus.NewRegistry(), "user_service")
+	interceptor := m.UnaryClientInterceptor()
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "user service is unavailable")
+	}
+	err := interceptor(context.Background(), "/UserService/GetUserFromJWT", nil, nil, nil, invoker)
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("UnaryClientInterceptor() error = %v, want the invoker error", err)
+	}
+	if got := testutil.CollectAndCount(m.duration, "product_service_user_service_request_duration_seconds"); got != 1 {
+		t.Errorf("request duration series = %v, want 1", got)
+	}
+}
+
+func TestRepositoryMetrics_Observe(t *testing.T) {
+	m := NewRepositoryMetrics(prometheus.NewRegistry())
+	m.Observe("products", "SaveProduct", time.Now(), false)
+	m.Observe("products", "SaveProduct", time.Now(), true)
+	if got := testutil.ToFloat64(m.errors.WithLabelValues("products", "SaveProduct")); got != 1 {
+		t.Errorf("errors = %v, want 1", got)
+	}
+}
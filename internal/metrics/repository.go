@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RepositoryMetrics are the latency and error metrics of the methods of
+// the repositories.
+type RepositoryMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewRepositoryMetrics returns the repository metrics registered with
+// registerer.
+func NewRepositoryMetrics(registerer prometheus.Registerer) *RepositoryMetrics {
+	m := &RepositoryMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "product_service",
+			Subsystem: "repository",
+			Name:      "duration_seconds",
+			Help:      "Latency of the repository methods.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"repository", "method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "product_service",
+			Subsystem: "repository",
+			Name:      "errors_total",
+			Help:      "Errors returned by the repository methods, expected errors e.g not found are not counted.",
+		}, []string{"repository", "method"}),
+	}
+	registerer.MustRegister(m.duration, m.errors)
+	return m
+}
+
+// Observe records a call to method of repository that started at start,
+// failed is whether the call returned an unexpected error.
+func (m *RepositoryMetrics) Observe(repository, method string, start time.Time, failed bool) {
+	m.duration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+	if failed {
+		m.errors.WithLabelValues(repository, method).Inc()
+	}
+}
@@ -0,0 +1,165 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/stream"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Encoder encodes an outbox message into the nats message that is
+// published, ctx carries the publish span of the message.
+type Encoder interface {
+	Encode(ctx context.Context, message *Message) (*nats.Msg, error)
+}
+
+// Names of the encoders returned by NewEncoder.
+const (
+	EncodingLegacy                = "legacy"
+	EncodingCloudEventsStructured = "cloudevents-structured"
+	EncodingCloudEventsBinary     = "cloudevents-binary"
+)
+
+// NewEncoder returns the encoder with the provided name, source is the
+// cloudevents source of the published events.
+func NewEncoder(name, source string) (Encoder, error) {
+	switch name {
+	case EncodingLegacy:
+		return LegacyEncoder{}, nil
+	case EncodingCloudEventsStructured:
+		return CloudEventsEncoder{Source: source}, nil
+	case EncodingCloudEventsBinary:
+		return CloudEventsEncoder{Source: source, Binary: true}, nil
+	}
+	return nil, fmt.Errorf("unknown event encoding %q, expected %s, %s or %s",
+		name, EncodingLegacy, EncodingCloudEventsStructured, EncodingCloudEventsBinary)
+}
+
+// LegacyEncoder encodes messages as an opentracing binary trace message
+// followed by the payload, the format expected by the notification
+// service. The span context is also sent in the traceparent header.
+type LegacyEncoder struct{}
+
+func (LegacyEncoder) Encode(ctx context.Context, message *Message) (*nats.Msg, error) {
+	var data bytes.Buffer
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		err := tracing.WriteLegacyTrace(&data, spanContext)
+		if err != nil {
+			return nil, err
+		}
+	}
+	data.Write(message.Payload)
+	return &nats.Msg{
+		Subject: message.Subject,
+		Header:  messageHeader(ctx, message),
+		Data:    data.Bytes(),
+	}, nil
+}
+
+// Headers of the schema of the published payload.
+const (
+	HeaderSchema        = "Event-Schema"
+	HeaderSchemaVersion = "Event-Schema-Version"
+)
+
+// messageHeader returns the headers every encoder publishes message with,
+// including the traceparent of the span of ctx.
+func messageHeader(ctx context.Context, message *Message) nats.Header {
+	header := nats.Header{nats.MsgIdHdr: []string{MsgID(message)}}
+	if message.Schema != "" {
+		header.Set(HeaderSchema, message.Schema)
+		header.Set(HeaderSchemaVersion, message.SchemaVersion)
+	}
+	tracing.InjectHeader(ctx, header)
+	return header
+}
+
+// CloudEventsEncoder encodes messages as cloudevents 1.0, in structured
+// mode the event is a json document and in binary mode the attributes are
+// nats headers and the payload is the message data.
+type CloudEventsEncoder struct {
+	Source string
+	Binary bool
+}
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsContentType = "application/cloudevents+json"
+	payloadContentType     = "application/json"
+	headerContentType      = "Content-Type"
+	// cloudEventsHeaderPrefix prefixes the attributes sent as headers in
+	// binary mode.
+	cloudEventsHeaderPrefix = "ce-"
+)
+
+// cloudEvent is a cloudevent in the structured json format.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	TraceParent     string          `json:"traceparent,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+func (e CloudEventsEncoder) Encode(ctx context.Context, message *Message) (*nats.Msg, error) {
+	event := cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              MsgID(message),
+		Source:          e.Source,
+		Type:            message.Subject,
+		Time:            message.CreatedAt.UTC().Format(time.RFC3339Nano),
+		DataContentType: payloadContentType,
+		TraceParent:     tracing.TraceParent(trace.SpanContextFromContext(ctx)),
+	}
+	msg := &nats.Msg{
+		Subject: message.Subject,
+		Header:  messageHeader(ctx, message),
+	}
+	if !e.Binary {
+		event.Data = message.Payload
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		msg.Header.Set(headerContentType, cloudEventsContentType)
+		msg.Data = data
+		return msg, nil
+	}
+	msg.Header.Set(headerContentType, event.DataContentType)
+	msg.Header.Set(cloudEventsHeaderPrefix+"specversion", event.SpecVersion)
+	msg.Header.Set(cloudEventsHeaderPrefix+"id", event.ID)
+	msg.Header.Set(cloudEventsHeaderPrefix+"source", event.Source)
+	msg.Header.Set(cloudEventsHeaderPrefix+"type", event.Type)
+	msg.Header.Set(cloudEventsHeaderPrefix+"time", event.Time)
+	if event.TraceParent != "" {
+		msg.Header.Set(cloudEventsHeaderPrefix+"traceparent", event.TraceParent)
+	}
+	msg.Data = message.Payload
+	return msg, nil
+}
+
+// SubjectEncoder encodes the messages whose subject matches one of
+// Subjects with Matched and the other messages with Default.
+type SubjectEncoder struct {
+	Subjects []string
+	Matched  Encoder
+	Default  Encoder
+}
+
+func (e SubjectEncoder) Encode(ctx context.Context, message *Message) (*nats.Msg, error) {
+	for _, pattern := range e.Subjects {
+		if stream.SubjectMatches(pattern, message.Subject) {
+			return e.Matched.Encode(ctx, message)
+		}
+	}
+	return e.Default.Encode(ctx, message)
+}
@@ -0,0 +1,229 @@
+package outbox
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var encoderTestMessage = &Message{
+	ID:        7,
+	Subject:   "products.v1.created",
+	Payload:   []byte(`{"sku":"sku.1"}`),
+	CreatedAt: time.Date(2021, time.November, 20, 10, 30, 0, 0, time.UTC),
+}
+
+func TestNewEncoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Encoder
+		wantErr bool
+	}{
+		{name: "legacy", want: LegacyEncoder{}},
+		{name: "cloudevents-structured", want: CloudEventsEncoder{Source: "/product-service"}},
+		{name: "cloudevents-binary", want: CloudEventsEncoder{Source: "/product-service", Binary: true}},
+		{name: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewEncoder(tt.name, "/product-service")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewEncoder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewEncoder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoders_Encode(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoder Encoder
+		want    *nats.Msg
+	}{
+		{
+			name:    "legacy",
+			encoder: LegacyEncoder{},
+			want: &nats.Msg{
+				Subject: "products.v1.created",
+				Header:  nats.Header{"Nats-Msg-Id": {"outbox-7"}},
+				Data:    []byte(`{"sku":"sku.1"}`),
+			},
+		},
+		{
+			name:    "cloudevents structured",
+			encoder: CloudEventsEncoder{Source: "/product-service"},
+			want: &nats.Msg{
+				Subject: "products.v1.created",
+				Header: nats.Header{
+					"Nats-Msg-Id":  {"outbox-7"},
+					"Content-Type": {"application/cloudevents+json"},
+				},
+				Data: []byte(`{"specversion":"1.0","id":"outbox-7","source":"/product-service","type":"products.v1.created",` +
+					`"time":"2021-11-20T10:30:00Z","datacontenttype":"application/json","data":{"sku":"sku.1"}}`),
+			},
+		},
+		{
+			name:    "cloudevents binary",
+			encoder: CloudEventsEncoder{Source: "/product-service", Binary: true},
+			want: &nats.Msg{
+				Subject: "products.v1.created",
+				Header: nats.Header{
+					"Nats-Msg-Id":    {"outbox-7"},
+					"Content-Type":   {"application/json"},
+					"ce-specversion": {"1.0"},
+					"ce-id":          {"outbox-7"},
+					"ce-source":      {"/product-service"},
+					"ce-type":        {"products.v1.created"},
+					"ce-time":        {"2021-11-20T10:30:00Z"},
+				},
+				Data: []byte(`{"sku":"sku.1"}`),
+			},
+		},
+		{
+			name: "legacy subject",
+			encoder: SubjectEncoder{
+				Subjects: []string{"notification.>", "products.v1.>"},
+				Matched:  LegacyEncoder{},
+				Default:  CloudEventsEncoder{Source: "/product-service", Binary: true},
+			},
+			want: &nats.Msg{
+				Subject: "products.v1.created",
+				Header:  nats.Header{"Nats-Msg-Id": {"outbox-7"}},
+				Data:    []byte(`{"sku":"sku.1"}`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.encoder.Encode(context.Background(), encoderTestMessage)
+			if err != nil {
+				t.Fatalf("Encoder.Encode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Encoder.Encode() = %v %v %s, want %v %v %s",
+					got.Subject, got.Header, got.Data, tt.want.Subject, tt.want.Header, tt.want.Data)
+			}
+		})
+	}
+}
+
+func TestEncoders_EncodeTraced(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x0a, 15: 0x0b},
+		SpanID:     trace.SpanID{0x0c, 7: 0x0d},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	traceParent := "00-0a00000000000000000000000000000b-0c0000000000000d-01"
+	// the span context in the jaeger binary format: trace id, span id,
+	// parent id, flags and baggage count.
+	legacyTrace := []byte{
+		0x0a, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x0b,
+		0x0c, 0, 0, 0, 0, 0, 0, 0x0d,
+		0, 0, 0, 0, 0, 0, 0, 0,
+		1,
+		0, 0, 0, 0,
+	}
+	tests := []struct {
+		name    string
+		encoder Encoder
+		want    *nats.Msg
+	}{
+		{
+			name:    "legacy",
+			encoder: LegacyEncoder{},
+			want: &nats.Msg{
+				Subject: "products.v1.created",
+				Header:  nats.Header{"Nats-Msg-Id": {"outbox-7"}, "traceparent": {traceParent}},
+				Data:    append(legacyTrace, `{"sku":"sku.1"}`...),
+			},
+		},
+		{
+			name:    "cloudevents structured",
+			encoder: CloudEventsEncoder{Source: "/product-service"},
+			want: &nats.Msg{
+				Subject: "products.v1.created",
+				Header: nats.Header{
+					"Nats-Msg-Id":  {"outbox-7"},
+					"traceparent":  {traceParent},
+					"Content-Type": {"application/cloudevents+json"},
+				},
+				Data: []byte(`{"specversion":"1.0","id":"outbox-7","source":"/product-service","type":"products.v1.created",` +
+					`"time":"2021-11-20T10:30:00Z","datacontenttype":"application/json","traceparent":"` + traceParent + `",` +
+					`"data":{"sku":"sku.1"}}`),
+			},
+		},
+		{
+			name:    "cloudevents binary",
+			encoder: CloudEventsEncoder{Source: "/product-service", Binary: true},
+			want: &nats.Msg{
+				Subject: "products.v1.created",
+				Header: nats.Header{
+					"Nats-Msg-Id":    {"outbox-7"},
+					"traceparent":    {traceParent},
+					"Content-Type":   {"application/json"},
+					"ce-specversion": {"1.0"},
+					"ce-id":          {"outbox-7"},
+					"ce-source":      {"/product-service"},
+					"ce-type":        {"products.v1.created"},
+					"ce-time":        {"2021-11-20T10:30:00Z"},
+					"ce-traceparent": {traceParent},
+				},
+				Data: []byte(`{"sku":"sku.1"}`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.encoder.Encode(ctx, encoderTestMessage)
+			if err != nil {
+				t.Fatalf("Encoder.Encode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Encoder.Encode() = %v %v %q, want %v %v %q",
+					got.Subject, got.Header, got.Data, tt.want.Subject, tt.want.Header, tt.want.Data)
+			}
+		})
+	}
+}
+
+func Test_messageHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		message *Message
+		want    nats.Header
+	}{
+		{
+			name:    "without schema",
+			message: &Message{ID: 3},
+			want:    nats.Header{"Nats-Msg-Id": {"outbox-3"}},
+		},
+		{
+			name:    "with schema",
+			message: &Message{ID: 3, Schema: "ProductEvent", SchemaVersion: "1"},
+			want: nats.Header{
+				"Nats-Msg-Id":          {"outbox-3"},
+				"Event-Schema":         {"ProductEvent"},
+				"Event-Schema-Version": {"1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageHeader(context.Background(), tt.message); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageHeader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Message is an event waiting in the outbox to be published to nats, it is
+// saved in the same transaction as the change that caused the event so
+// that the event is never lost when publishing fails.
+type Message struct {
+	ID      int64  `gorm:"autoIncrement,primaryKey"`
+	Subject string `gorm:"size:255"`
+	Payload []byte `gorm:"type:mediumblob"`
+	// Schema and SchemaVersion identify the schema of the payload, they
+	// are published in the Event-Schema and Event-Schema-Version headers.
+	Schema        string `gorm:"size:255"`
+	SchemaVersion string `gorm:"size:16"`
+	// TraceContext is the span context of the request that caused the
+	// event as a W3C traceparent, messages saved before the move to
+	// opentelemetry use the opentracing binary format.
+	TraceContext []byte `gorm:"type:blob"`
+	Attempts     int
+	LastError    string `gorm:"type:text"`
+	// NextAttemptAt is the time the message is due to be published.
+	NextAttemptAt time.Time  `gorm:"index:idx_outbox_messages_pending,priority:2"`
+	SentAt        *time.Time `gorm:"index:idx_outbox_messages_pending,priority:1"`
+	// ParkedAt is the time the message was given up on after it failed
+	// too many times, parked messages are not published anymore.
+	ParkedAt  *time.Time
+	CreatedAt time.Time
+}
+
+// Save adds messages to the outbox using tx, it must be called with the
+// transaction that saves the change the messages are about.
+func Save(tx *gorm.DB, messages []*Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for _, message := range messages {
+		message.NextAttemptAt = now
+	}
+	return tx.Create(messages).Error
+}
+
+const (
+	minRetryDelay = time.Second
+	maxRetryDelay = 5 * time.Minute
+)
+
+// retryDelay returns how long to wait before publishing a message again
+// after attempts failed attempts, the delay doubles after every attempt
+// up to maxRetryDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
+
+// dueMessages returns the messages, in order, up to the first one that is
+// not due at now. A message waiting for its retry or claimed by another
+// relay holds back the messages behind it so that their order is kept.
+func dueMessages(messages []*Message, now time.Time) []*Message {
+	for i, message := range messages {
+		if message.NextAttemptAt.After(now) {
+			return messages[:i]
+		}
+	}
+	return messages
+}
@@ -0,0 +1,27 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_retryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first attempt", attempts: 1, want: time.Second},
+		{name: "second attempt", attempts: 2, want: 2 * time.Second},
+		{name: "fifth attempt", attempts: 5, want: 16 * time.Second},
+		{name: "delay is capped", attempts: 20, want: maxRetryDelay},
+		{name: "large attempts do not overflow", attempts: 1000, want: maxRetryDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(tt.attempts); got != tt.want {
+				t.Errorf("retryDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Publisher is the interface that describes the nats connection used by
+// the relay, *nats.Conn implements it.
+type Publisher interface {
+	PublishMsg(msg *nats.Msg) error
+	FlushTimeout(timeout time.Duration) error
+}
+
+// ErrUnavailable is wrapped by the errors of a Publisher that could not
+// reach nats. A message that failed because of it is retried but never
+// parked, since it would be published if nats was reachable.
+var ErrUnavailable = errors.New("nats is unavailable")
+
+const (
+	relayBatchSize    = 100
+	relayFlushTimeout = 5 * time.Second
+	// relayClaimLease is how long claimed messages are not published by
+	// another relay, it must be longer than publishing a batch takes.
+	relayClaimLease = time.Minute
+)
+
+// Relay publishes the messages in the outbox to nats.
+type Relay struct {
+	messageRepo MessageRepository
+	publisher   Publisher
+	encoder     Encoder
+	maxAttempts int
+	tracer      trace.Tracer
+}
+
+// NewRelay returns a new outbox relay object, a message that failed
+// maxAttempts times is parked.
+func NewRelay(messageRepo MessageRepository, publisher Publisher, encoder Encoder, maxAttempts int, tracer trace.Tracer) *Relay {
+	return &Relay{
+		messageRepo: messageRepo,
+		publisher:   publisher,
+		encoder:     encoder,
+		maxAttempts: maxAttempts,
+		tracer:      tracer,
+	}
+}
+
+// Run publishes pending messages every interval until ctx is done, a full
+// batch is followed by the next batch right away.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			// errors are recorded on the span and the messages are
+			// retried on the next run.
+			sent, _ := r.relayPending(ctx)
+			if sent == relayBatchSize {
+				timer.Reset(0)
+			} else {
+				timer.Reset(interval)
+			}
+		}
+	}
+}
+
+// Flush publishes the pending messages until the outbox is empty, it is
+// called on shutdown after Run returned so that the messages saved by the
+// last requests are not left behind until the next start.
+func (r *Relay) Flush(ctx context.Context) error {
+	for {
+		sent, err := r.relayPending(ctx)
+		if err != nil || sent < relayBatchSize {
+			return err
+		}
+	}
+}
+
+// MsgID returns the id of message that is sent in the Nats-Msg-Id header,
+// the id does not change when publishing is retried so that jetstream can
+// drop the duplicates.
+func MsgID(message *Message) string {
+	return "outbox-" + strconv.FormatInt(message.ID, 10)
+}
+
+// relayPending publishes a batch of pending messages in order and saves the
+// result, it returns the number of messages that were sent. Publishing
+// stops at the first message that fails, the message is retried with a
+// backoff and the messages behind it wait for it so that their order is
+// kept, until it failed maxAttempts times and is parked.
+func (r *Relay) relayPending(ctx context.Context) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "RelayPending")
+	defer span.End()
+	messages, err := r.messageRepo.ClaimPending(ctx, relayBatchSize, relayClaimLease)
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+	sent, publishErr := r.publish(messages)
+	tracing.SetAttribute(span, "response.sent", sent)
+	if sent > 0 {
+		err = r.messageRepo.MarkSent(ctx, messages[:sent])
+		if err != nil {
+			return 0, err
+		}
+	}
+	if publishErr == nil {
+		return sent, nil
+	}
+	tracing.RecordError(span, publishErr, "publishing messages")
+	err = r.messageRepo.Reschedule(ctx, r.reschedule(messages[sent:], publishErr, time.Now()))
+	if err != nil {
+		return sent, err
+	}
+	return sent, publishErr
+}
+
+// reschedule records the failed attempt of the first of messages and
+// schedules the others behind it.
+func (r *Relay) reschedule(messages []*Message, publishErr error, now time.Time) []*Message {
+	failed := messages[0]
+	failed.Attempts++
+	failed.LastError = publishErr.Error()
+	failed.NextAttemptAt = now.Add(retryDelay(failed.Attempts))
+	if failed.Attempts >= r.maxAttempts && !errors.Is(publishErr, ErrUnavailable) {
+		failed.ParkedAt = &now
+		failed.NextAttemptAt = now
+	}
+	for _, message := range messages[1:] {
+		message.NextAttemptAt = failed.NextAttemptAt
+	}
+	return messages
+}
+
+// publish publishes messages in order and waits for nats to acknowledge
+// them, it returns the number of messages nats acknowledged. The span
+// context of every message is continued by a publish span that is
+// injected into the published message.
+func (r *Relay) publish(messages []*Message) (int, error) {
+	for i, message := range messages {
+		err := r.publishMessage(context.Background(), message)
+		if err != nil {
+			flushErr := r.publisher.FlushTimeout(relayFlushTimeout)
+			if flushErr != nil {
+				return 0, fmt.Errorf("%w: %v", ErrUnavailable, flushErr)
+			}
+			return i, err
+		}
+	}
+	err := r.publisher.FlushTimeout(relayFlushTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return len(messages), nil
+}
+
+func (r *Relay) publishMessage(ctx context.Context, message *Message) error {
+	if len(message.TraceContext) > 0 {
+		parent, err := tracing.UnmarshalSpanContext(message.TraceContext)
+		if err == nil {
+			ctx = trace.ContextWithRemoteSpanContext(ctx, parent)
+		}
+	}
+	ctx, span := r.tracer.Start(ctx, "publish "+message.Subject, trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+	tracing.SetAttribute(span, "message.id", message.ID)
+	tracing.SetAttribute(span, "message.attempts", message.Attempts)
+
+	msg, err := r.encoder.Encode(ctx, message)
+	if err != nil {
+		tracing.RecordError(span, err, "encoding outbox message")
+		return err
+	}
+	err = r.publisher.PublishMsg(msg)
+	if err != nil {
+		tracing.RecordError(span, err, "nats."+message.Subject)
+		return err
+	}
+	return nil
+}
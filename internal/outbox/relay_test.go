@@ -0,0 +1,258 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fakePublisher records published messages, the mocks package cannot be
+// used here since it imports this package.
+type fakePublisher struct {
+	published  []string
+	publishErr error
+	flushErr   error
+}
+
+func (p *fakePublisher) PublishMsg(msg *nats.Msg) error {
+	p.published = append(p.published, msg.Header.Get(nats.MsgIdHdr)+" "+msg.Subject+" "+string(msg.Data))
+	return p.publishErr
+}
+
+func (p *fakePublisher) FlushTimeout(timeout time.Duration) error {
+	return p.flushErr
+}
+
+func TestRelay_publish(t *testing.T) {
+	messages := []*Message{
+		{ID: 1, Subject: "products.ProductDeleted", Payload: []byte(`{"sku":"sku.1"}`)},
+		{ID: 2, Subject: "products.ProductRestored", Payload: []byte(`{"sku":"sku.1"}`)},
+	}
+	tests := []struct {
+		name            string
+		publisher       *fakePublisher
+		wantPublished   []string
+		wantSent        int
+		wantErr         bool
+		wantUnavailable bool
+	}{
+		{
+			name:      "published and flushed",
+			publisher: &fakePublisher{},
+			wantPublished: []string{
+				`outbox-1 products.ProductDeleted {"sku":"sku.1"}`,
+				`outbox-2 products.ProductRestored {"sku":"sku.1"}`,
+			},
+			wantSent: 2,
+		},
+		{
+			name:          "publish error",
+			publisher:     &fakePublisher{publishErr: errors.New("nats: invalid message")},
+			wantPublished: []string{`outbox-1 products.ProductDeleted {"sku":"sku.1"}`},
+			wantErr:       true,
+		},
+		{
+			name:      "flush error",
+			publisher: &fakePublisher{flushErr: errors.New("nats: timeout")},
+			wantPublished: []string{
+				`outbox-1 products.ProductDeleted {"sku":"sku.1"}`,
+				`outbox-2 products.ProductRestored {"sku":"sku.1"}`,
+			},
+			wantErr:         true,
+			wantUnavailable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRelay(nil, tt.publisher, LegacyEncoder{}, 3, trace.NewNoopTracerProvider().Tracer(""))
+			sent, err := r.publish(messages)
+			if (err != nil) != tt.wantErr || errors.Is(err, ErrUnavailable) != tt.wantUnavailable || sent != tt.wantSent {
+				t.Errorf("Relay.publish() = %v, %v, want %v, wantErr %v", sent, err, tt.wantSent, tt.wantErr)
+				return
+			}
+			if len(tt.publisher.published) != len(tt.wantPublished) {
+				t.Errorf("Relay.publish() published = %v, want %v", tt.publisher.published, tt.wantPublished)
+				return
+			}
+			for i := range tt.wantPublished {
+				if tt.publisher.published[i] != tt.wantPublished[i] {
+					t.Errorf("Relay.publish() published = %v, want %v", tt.publisher.published, tt.wantPublished)
+				}
+			}
+		})
+	}
+}
+
+// fakeMessageRepository claims the messages in pending in order up to the
+// first one that is not due, the messages are removed once they are sent
+// or parked.
+type fakeMessageRepository struct {
+	pending []*Message
+}
+
+func (r *fakeMessageRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*Message, error) {
+	batch := r.pending
+	if len(batch) > limit {
+		batch = batch[:limit]
+	}
+	return dueMessages(batch, time.Now()), nil
+}
+
+func (r *fakeMessageRepository) MarkSent(ctx context.Context, messages []*Message) error {
+	r.pending = r.pending[len(messages):]
+	return nil
+}
+
+func (r *fakeMessageRepository) Reschedule(ctx context.Context, messages []*Message) error {
+	if messages[0].ParkedAt != nil {
+		r.pending = r.pending[1:]
+	}
+	return nil
+}
+
+func TestRelay_Flush(t *testing.T) {
+	newPending := func() []*Message {
+		pending := make([]*Message, relayBatchSize+1)
+		for i := range pending {
+			pending[i] = &Message{ID: int64(i + 1), Subject: "products.v1.created", Payload: []byte("{}")}
+		}
+		return pending
+	}
+	tests := []struct {
+		name          string
+		publisher     *fakePublisher
+		wantPublished int
+		wantPending   int
+		wantErr       bool
+	}{
+		{
+			name:          "every batch published",
+			publisher:     &fakePublisher{},
+			wantPublished: relayBatchSize + 1,
+		},
+		{
+			name:          "publish error",
+			publisher:     &fakePublisher{publishErr: errors.New("nats: connection closed")},
+			wantPublished: 1,
+			wantPending:   relayBatchSize + 1,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeMessageRepository{pending: newPending()}
+			r := NewRelay(repo, tt.publisher, LegacyEncoder{}, 3, trace.NewNoopTracerProvider().Tracer(""))
+			err := r.Flush(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Relay.Flush() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(tt.publisher.published) != tt.wantPublished || len(repo.pending) != tt.wantPending {
+				t.Errorf("Relay.Flush() published %d, left %d pending, want %d and %d",
+					len(tt.publisher.published), len(repo.pending), tt.wantPublished, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestRelay_relayPending(t *testing.T) {
+	newPending := func() []*Message {
+		pending := make([]*Message, 2*relayBatchSize+1)
+		for i := range pending {
+			pending[i] = &Message{ID: int64(i + 1), Subject: "products.v1.created", Payload: []byte("{}")}
+		}
+		return pending
+	}
+	tests := []struct {
+		name          string
+		publisher     *fakePublisher
+		claimed       bool
+		runs          int
+		wantPublished int
+	}{
+		{
+			name:          "every batch published",
+			publisher:     &fakePublisher{},
+			runs:          3,
+			wantPublished: 2*relayBatchSize + 1,
+		},
+		{
+			name:          "failed message holds back the next batches",
+			publisher:     &fakePublisher{publishErr: errors.New("nats: invalid message")},
+			runs:          3,
+			wantPublished: 1,
+		},
+		{
+			name:          "message claimed by another relay holds back the messages behind it",
+			publisher:     &fakePublisher{},
+			claimed:       true,
+			runs:          3,
+			wantPublished: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeMessageRepository{pending: newPending()}
+			if tt.claimed {
+				repo.pending[0].NextAttemptAt = time.Now().Add(relayClaimLease)
+			}
+			r := NewRelay(repo, tt.publisher, LegacyEncoder{}, 3, trace.NewNoopTracerProvider().Tracer(""))
+			for i := 0; i < tt.runs; i++ {
+				// errors are retried by the next run like Run does.
+				_, _ = r.relayPending(context.Background())
+			}
+			if len(tt.publisher.published) != tt.wantPublished {
+				t.Errorf("Relay.relayPending() published %d, want %d", len(tt.publisher.published), tt.wantPublished)
+				return
+			}
+			for i, published := range tt.publisher.published {
+				if want := MsgID(&Message{ID: int64(i + 1)}) + " "; !strings.HasPrefix(published, want) {
+					t.Errorf("Relay.relayPending() published %q at %d, want %s", published, i, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRelay_reschedule(t *testing.T) {
+	now := time.Now()
+	rejected := errors.New("nats: invalid message")
+	tests := []struct {
+		name          string
+		attempts      int
+		err           error
+		wantParked    bool
+		wantNextDelay time.Duration
+	}{
+		{name: "retried with backoff", attempts: 1, err: rejected, wantNextDelay: 2 * time.Second},
+		{name: "parked after max attempts", attempts: 2, err: rejected, wantParked: true},
+		{
+			name: "not parked when nats is unavailable", attempts: 2, err: fmt.Errorf("%w: timeout", ErrUnavailable),
+			wantNextDelay: 4 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRelay(nil, nil, LegacyEncoder{}, 3, trace.NewNoopTracerProvider().Tracer(""))
+			messages := []*Message{{ID: 1, Attempts: tt.attempts}, {ID: 2, Attempts: 1}}
+			r.reschedule(messages, tt.err, now)
+			failed := messages[0]
+			if failed.Attempts != tt.attempts+1 || failed.LastError != tt.err.Error() || (failed.ParkedAt != nil) != tt.wantParked {
+				t.Errorf("Relay.reschedule() failed message = %+v, want attempts %d, parked %v", failed, tt.attempts+1, tt.wantParked)
+			}
+			if got := failed.NextAttemptAt.Sub(now); got != tt.wantNextDelay {
+				t.Errorf("Relay.reschedule() next attempt in %v, want %v", got, tt.wantNextDelay)
+			}
+			// the messages behind the failed message keep their order.
+			if messages[1].Attempts != 1 || !messages[1].NextAttemptAt.Equal(failed.NextAttemptAt) {
+				t.Errorf("Relay.reschedule() next message = %+v, want it scheduled with the failed message", messages[1])
+			}
+		})
+	}
+}
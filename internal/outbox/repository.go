@@ -0,0 +1,128 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MessageRepository is the interface that describes an outbox repository
+// object.
+type MessageRepository interface {
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*Message, error)
+	MarkSent(ctx context.Context, messages []*Message) error
+	Reschedule(ctx context.Context, messages []*Message) error
+}
+
+// MessageRepo is the default implementation for MessageRepository
+// interface.
+type MessageRepo struct {
+	db     *gorm.DB
+	tracer trace.Tracer
+}
+
+// NewRepository returns a new outbox repository object.
+func NewRepository(db *gorm.DB, tracer trace.Tracer) *MessageRepo {
+	return &MessageRepo{
+		db:     db,
+		tracer: tracer,
+	}
+}
+
+func (r *MessageRepo) setMySqlComponentTags(span trace.Span, tableName string) {
+	span.SetAttributes(semconv.DBSystemMySQL, semconv.DBSQLTableKey.String(tableName))
+}
+
+// ClaimPending claims at most limit messages that are due to be published,
+// oldest first, up to the oldest pending message that is not due. The
+// messages are locked only while they are claimed, a claimed message is not
+// due again before lease expired so that replicas never publish the same
+// message at the same time, nor the messages behind it, and a relay that
+// stopped before saving the result does not hold the message forever.
+func (r *MessageRepo) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*Message, error) {
+	_, span := r.tracer.Start(ctx, "ClaimPending", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "outbox_messages")
+	tracing.SetAttribute(span, "param.limit", limit)
+
+	messages := []*Message{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// the rows are not skipped when they are locked, a replica claiming
+		// the messages behind them would publish them first.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("sent_at IS NULL AND parked_at IS NULL").
+			Order("id").Limit(limit).Find(&messages).Error
+		if err != nil {
+			return err
+		}
+		messages = dueMessages(messages, now)
+		if len(messages) == 0 {
+			return nil
+		}
+		return tx.Model(&Message{}).Where("id IN ?", messageIDs(messages)).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return nil, err
+	}
+	tracing.SetAttribute(span, "response.count", len(messages))
+	return messages, nil
+}
+
+// MarkSent marks messages as sent, they are not published anymore.
+func (r *MessageRepo) MarkSent(ctx context.Context, messages []*Message) error {
+	_, span := r.tracer.Start(ctx, "MarkSent", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "outbox_messages")
+	tracing.SetAttribute(span, "param.count", len(messages))
+
+	err := r.db.Model(&Message{}).Where("id IN ?", messageIDs(messages)).Update("sent_at", time.Now()).Error
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Update")
+		return err
+	}
+	return nil
+}
+
+// Reschedule saves the attempts, last error, next attempt and parking time
+// of messages that were claimed but not sent.
+func (r *MessageRepo) Reschedule(ctx context.Context, messages []*Message) error {
+	_, span := r.tracer.Start(ctx, "Reschedule", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "outbox_messages")
+	tracing.SetAttribute(span, "param.count", len(messages))
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, message := range messages {
+			err := tx.Model(message).Updates(map[string]interface{}{
+				"attempts":        message.Attempts,
+				"last_error":      message.LastError,
+				"next_attempt_at": message.NextAttemptAt,
+				"parked_at":       message.ParkedAt,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return err
+	}
+	return nil
+}
+
+func messageIDs(messages []*Message) []int64 {
+	ids := make([]int64, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
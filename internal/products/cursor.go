@@ -0,0 +1,103 @@
+package products
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded or its
+// signature does not match.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of a product in a sorted product list, it is
+// made up of the sort field value and the product id so that the position
+// stays the same when new products are added.
+type Cursor struct {
+	SortBy     SortField `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	Price      int64     `json:"p,omitempty"`
+	Name       string    `json:"n,omitempty"`
+	TimeAdded  time.Time `json:"t,omitempty"`
+	ID         int       `json:"i"`
+}
+
+// NewCursor returns the cursor of product in a list sorted by sortBy.
+func NewCursor(product *Product, sortBy SortField, descending bool) *Cursor {
+	cursor := &Cursor{SortBy: sortBy, Descending: descending, ID: product.ID}
+	switch sortBy {
+	case SortByPrice:
+		cursor.Price = product.Price.Amount
+	case SortByName:
+		cursor.Name = product.Name
+	default:
+		cursor.TimeAdded = product.TimeAdded
+	}
+	return cursor
+}
+
+// value returns the sort field value of the cursor.
+func (c *Cursor) value() interface{} {
+	switch c.SortBy {
+	case SortByPrice:
+		return c.Price
+	case SortByName:
+		return c.Name
+	default:
+		return c.TimeAdded
+	}
+}
+
+// CursorCodec encodes cursors into opaque tokens signed with a secret key
+// and decodes them back, tokens that were modified are rejected.
+type CursorCodec struct {
+	secret []byte
+}
+
+// NewCursorCodec returns a new cursor codec that signs tokens with secret.
+func NewCursorCodec(secret []byte) *CursorCodec {
+	return &CursorCodec{secret: secret}
+}
+
+// Encode returns the opaque token of cursor.
+func (c *CursorCodec) Encode(cursor *Cursor) (string, error) {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode returns the cursor of an opaque token returned by Encode.
+func (c *CursorCodec) Decode(token string) (*Cursor, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	encoding := base64.RawURLEncoding
+	payload, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	signature, err := encoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &Cursor{}
+	err = json.Unmarshal(payload, cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
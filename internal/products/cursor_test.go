@@ -0,0 +1,73 @@
+package products
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCursorCodec_EncodeDecode(t *testing.T) {
+	codec := NewCursorCodec([]byte("secret"))
+	timeAdded := time.Date(2021, time.November, 10, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		cursor *Cursor
+	}{
+		{
+			name:   "time added cursor",
+			cursor: NewCursor(&Product{ID: 10, TimeAdded: timeAdded}, SortByTimeAdded, false),
+		},
+		{
+			name:   "price cursor",
+			cursor: NewCursor(&Product{ID: 11, Price: Money{Amount: 1999, Currency: "USD"}}, SortByPrice, true),
+		},
+		{
+			name:   "name cursor",
+			cursor: NewCursor(&Product{ID: 12, Name: "Leather Shoe"}, SortByName, false),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := codec.Encode(tt.cursor)
+			if err != nil {
+				t.Fatalf("CursorCodec.Encode() error = %v", err)
+			}
+			got, err := codec.Decode(token)
+			if err != nil {
+				t.Fatalf("CursorCodec.Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.cursor) {
+				t.Errorf("CursorCodec.Decode() = %v, want %v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestCursorCodec_Decode(t *testing.T) {
+	codec := NewCursorCodec([]byte("secret"))
+	validToken, _ := codec.Encode(&Cursor{SortBy: SortByPrice, Price: 100, ID: 3})
+	otherSecretToken, _ := NewCursorCodec([]byte("other")).Encode(&Cursor{SortBy: SortByPrice, Price: 100, ID: 3})
+	tamperedCursor, _ := NewCursorCodec([]byte("other")).Encode(&Cursor{SortBy: SortByPrice, Price: 100, ID: 4})
+	tamperedToken := strings.Split(tamperedCursor, ".")[0] + "." + strings.Split(validToken, ".")[1]
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "empty token", token: "", wantErr: true},
+		{name: "malformed token", token: "abc", wantErr: true},
+		{name: "token signed with another secret", token: otherSecretToken, wantErr: true},
+		{name: "token with modified payload", token: tamperedToken, wantErr: true},
+		{name: "valid token", token: validToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := codec.Decode(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CursorCodec.Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
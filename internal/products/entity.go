@@ -1,16 +1,46 @@
 package products
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
 
 type Product struct {
-	ID          int       `json:"_" gorm:"autoIncrement,primaryKey"`
-	Sku         string    `json:"sku"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Category    string    `json:"category"`
-	MerchantID  string    `json:"merchantId"`
-	Brand       string    `json:"brand"`
-	Price       float64   `json:"price"`
-	ImageURL    string    `json:"imageUrl"`
-	TimeAdded   time.Time `json:"timeAdded"`
+	ID          int            `json:"_" gorm:"autoIncrement,primaryKey"`
+	Sku         string         `json:"sku"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Category    string         `json:"category"`
+	MerchantID  string         `json:"merchantId"`
+	Brand       string         `json:"brand"`
+	Price       Money          `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	ImageURL    string         `json:"imageUrl"`
+	TimeAdded   time.Time      `json:"timeAdded"`
+	DeletedAt   gorm.DeletedAt `json:"deletedAt" gorm:"index"`
+	Options     []Option       `json:"options" gorm:"constraint:OnDelete:CASCADE"`
+	Variants    []Variant      `json:"variants" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// PrepareNew gives a new product and its variants their skus and sets the
+// time the product was added. It is called before the product is saved so
+// that events about the product can reference its sku.
+func (p *Product) PrepareNew() {
+	p.Sku = uuid.NewString()
+	p.TimeAdded = time.Now()
+	PrepareVariants(p.Options, p.Variants)
+}
+
+// PrepareVariants sets the position of options and gives variants without
+// a sku a new sku.
+func PrepareVariants(options []Option, variants []Variant) {
+	for i := range options {
+		options[i].Position = i
+	}
+	for i := range variants {
+		if variants[i].Sku == "" {
+			variants[i].Sku = uuid.NewString()
+		}
+	}
 }
@@ -0,0 +1,49 @@
+package products
+
+import "time"
+
+// SortField is a product field products can be sorted by.
+type SortField string
+
+const (
+	SortByTimeAdded SortField = "time_added"
+	SortByPrice     SortField = "price"
+	SortByName      SortField = "name"
+)
+
+// column returns the database column of f.
+func (f SortField) column() string {
+	if f == SortByPrice {
+		return "price_amount"
+	}
+	return string(f)
+}
+
+// Valid reports whether products can be sorted by f.
+func (f SortField) Valid() bool {
+	switch f {
+	case SortByTimeAdded, SortByPrice, SortByName:
+		return true
+	}
+	return false
+}
+
+// ListFilter describes the products to retrieve with ListProducts, zero
+// values are ignored.
+type ListFilter struct {
+	MerchantID string
+	Category   string
+	Brand      string
+	// Currency, MinPrice and MaxPrice filter products by price, prices
+	// are in the minor unit of the currency.
+	Currency    string
+	MinPrice    *int64
+	MaxPrice    *int64
+	AddedAfter  *time.Time
+	AddedBefore *time.Time
+	SortBy      SortField
+	Descending  bool
+	// After is the position of the last product of the previous page.
+	After *Cursor
+	Limit int
+}
@@ -0,0 +1,117 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/metrics"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+)
+
+// InstrumentedRepository records the latency and errors of the methods of
+// a product repository.
+type InstrumentedRepository struct {
+	repo    Repository
+	metrics *metrics.RepositoryMetrics
+}
+
+// NewInstrumentedRepository returns a repository that records the metrics
+// of repo.
+func NewInstrumentedRepository(repo Repository, metrics *metrics.RepositoryMetrics) *InstrumentedRepository {
+	return &InstrumentedRepository{
+		repo:    repo,
+		metrics: metrics,
+	}
+}
+
+// observe records a call to method, a product or variant that does not
+// exist is not an error of the repository.
+func (r *InstrumentedRepository) observe(method string, start time.Time, err error) {
+	failed := err != nil && !errors.Is(err, ErrProductNotFound) && !errors.Is(err, ErrVariantNotFound)
+	r.metrics.Observe("products", method, start, failed)
+}
+
+func (r *InstrumentedRepository) SaveProduct(ctx context.Context, product *Product, messages []*outbox.Message) error {
+	start := time.Now()
+	err := r.repo.SaveProduct(ctx, product, messages)
+	r.observe("SaveProduct", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) GetProductBySKU(ctx context.Context, sku string, includeDeleted bool) (*Product, error) {
+	start := time.Now()
+	product, err := r.repo.GetProductBySKU(ctx, sku, includeDeleted)
+	r.observe("GetProductBySKU", start, err)
+	return product, err
+}
+
+func (r *InstrumentedRepository) GetProductsBySKUs(ctx context.Context, skus []string) ([]*Product, error) {
+	start := time.Now()
+	products, err := r.repo.GetProductsBySKUs(ctx, skus)
+	r.observe("GetProductsBySKUs", start, err)
+	return products, err
+}
+
+func (r *InstrumentedRepository) UpdateProduct(ctx context.Context, product *Product, fields []string, messages []*outbox.Message) error {
+	start := time.Now()
+	err := r.repo.UpdateProduct(ctx, product, fields, messages)
+	r.observe("UpdateProduct", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) DeleteProduct(ctx context.Context, product *Product, messages []*outbox.Message) error {
+	start := time.Now()
+	err := r.repo.DeleteProduct(ctx, product, messages)
+	r.observe("DeleteProduct", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) DeleteProducts(ctx context.Context, products []*Product, messages []*outbox.Message) error {
+	start := time.Now()
+	err := r.repo.DeleteProducts(ctx, products, messages)
+	r.observe("DeleteProducts", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) RestoreProduct(ctx context.Context, product *Product, messages []*outbox.Message) error {
+	start := time.Now()
+	err := r.repo.RestoreProduct(ctx, product, messages)
+	r.observe("RestoreProduct", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) PurgeProduct(ctx context.Context, product *Product, messages []*outbox.Message) error {
+	start := time.Now()
+	err := r.repo.PurgeProduct(ctx, product, messages)
+	r.observe("PurgeProduct", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) ListProducts(ctx context.Context, filter ListFilter) ([]*Product, error) {
+	start := time.Now()
+	products, err := r.repo.ListProducts(ctx, filter)
+	r.observe("ListProducts", start, err)
+	return products, err
+}
+
+func (r *InstrumentedRepository) ReplaceVariants(ctx context.Context, product *Product, options []Option, variants []Variant, messages []*outbox.Message) error {
+	start := time.Now()
+	err := r.repo.ReplaceVariants(ctx, product, options, variants, messages)
+	r.observe("ReplaceVariants", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) GetVariantBySKU(ctx context.Context, sku string) (*Variant, error) {
+	start := time.Now()
+	variant, err := r.repo.GetVariantBySKU(ctx, sku)
+	r.observe("GetVariantBySKU", start, err)
+	return variant, err
+}
+
+func (r *InstrumentedRepository) GetProductByVariantSKU(ctx context.Context, sku string, includeDeleted bool) (*Product, error) {
+	start := time.Now()
+	product, err := r.repo.GetProductByVariantSKU(ctx, sku, includeDeleted)
+	r.observe("GetProductByVariantSKU", start, err)
+	return product, err
+}
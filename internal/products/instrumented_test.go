@@ -0,0 +1,52 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/metrics"
+)
+
+// fakeRepository returns err from GetProductBySKU, the mocks package
+// cannot be used here since it imports this package.
+type fakeRepository struct {
+	Repository
+	err error
+}
+
+func (r *fakeRepository) GetProductBySKU(ctx context.Context, sku string, includeDeleted bool) (*Product, error) {
+	return nil, r.err
+}
+
+func TestInstrumentedRepository_GetProductBySKU(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantErrors float64
+	}{
+		{name: "product found"},
+		{name: "product not found", err: ErrProductNotFound},
+		{name: "unexpected error", err: errors.New("an error occured"), wantErrors: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			repo := NewInstrumentedRepository(&fakeRepository{err: tt.err}, metrics.NewRepositoryMetrics(registry))
+			_, err := repo.GetProductBySKU(context.Background(), "sku", false)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("InstrumentedRepository.GetProductBySKU() error = %v, want %v", err, tt.err)
+			}
+			count, err := testutil.GatherAndCount(registry, "product_service_repository_duration_seconds")
+			if err != nil || count != 1 {
+				t.Errorf("duration series = %v, err = %v, want 1", count, err)
+			}
+			count, err = testutil.GatherAndCount(registry, "product_service_repository_errors_total")
+			if err != nil || float64(count) != tt.wantErrors {
+				t.Errorf("error series = %v, err = %v, want %v", count, err, tt.wantErrors)
+			}
+		})
+	}
+}
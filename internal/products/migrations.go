@@ -0,0 +1,30 @@
+package products
+
+import (
+	"fmt"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// MigrateLegacyPrices converts the float prices of products created before
+// prices were stored as Money to the minor unit of currency. The legacy
+// price column is renamed to legacy_price instead of being dropped so that
+// no data is lost, the migration does nothing once it has been applied.
+func MigrateLegacyPrices(db *gorm.DB, currency string) error {
+	exponent, ok := CurrencyExponent(currency)
+	if !ok {
+		return fmt.Errorf("%s is not a supported currency", currency)
+	}
+	if !db.Migrator().HasColumn(&Product{}, "price") {
+		return nil
+	}
+	err := db.Exec(
+		"UPDATE products SET price_amount = ROUND(price * ?), price_currency = ? WHERE price IS NOT NULL AND (price_currency IS NULL OR price_currency = '')",
+		math.Pow10(exponent), currency,
+	).Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("ALTER TABLE products RENAME COLUMN price TO legacy_price").Error
+}
@@ -0,0 +1,62 @@
+package products
+
+import (
+	"fmt"
+	"strings"
+)
+
+// currencyExponents are the number of decimal places of the minor unit of
+// the supported ISO 4217 currencies.
+var currencyExponents = map[string]int{
+	"AUD": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"GHS": 2,
+	"INR": 2,
+	"JPY": 0,
+	"KES": 2,
+	"KRW": 0,
+	"KWD": 3,
+	"NGN": 2,
+	"USD": 2,
+	"ZAR": 2,
+}
+
+// CurrencyExponent returns the number of decimal places of the minor unit
+// of currency, ok is false if the currency is not supported.
+func CurrencyExponent(currency string) (exponent int, ok bool) {
+	exponent, ok = currencyExponents[currency]
+	return exponent, ok
+}
+
+// Money is an amount of money in the minor unit of its currency e.g cents
+// for USD, so that amounts are never rounded.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency" gorm:"size:3"`
+}
+
+// Decimal returns the amount formatted with the decimal places of the
+// currency e.g 19.99 for 1999 USD.
+func (m Money) Decimal() string {
+	exponent := currencyExponents[m.Currency]
+	if exponent == 0 {
+		return fmt.Sprintf("%d", m.Amount)
+	}
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	unit := int64(1)
+	for i := 0; i < exponent; i++ {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, exponent, amount%unit)
+}
+
+func (m Money) String() string {
+	return strings.TrimSpace(m.Decimal() + " " + m.Currency)
+}
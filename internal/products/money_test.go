@@ -0,0 +1,26 @@
+package products
+
+import "testing"
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		want  string
+	}{
+		{name: "two decimal places", money: Money{Amount: 1999, Currency: "USD"}, want: "19.99 USD"},
+		{name: "leading zero cents", money: Money{Amount: 1005, Currency: "EUR"}, want: "10.05 EUR"},
+		{name: "less than one unit", money: Money{Amount: 7, Currency: "GBP"}, want: "0.07 GBP"},
+		{name: "no decimal places", money: Money{Amount: 1999, Currency: "JPY"}, want: "1999 JPY"},
+		{name: "three decimal places", money: Money{Amount: 1999, Currency: "KWD"}, want: "1.999 KWD"},
+		{name: "negative amount", money: Money{Amount: -150, Currency: "USD"}, want: "-1.50 USD"},
+		{name: "no currency", money: Money{Amount: 150}, want: "150"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("Money.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
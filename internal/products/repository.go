@@ -1,77 +1,445 @@
 package products
 
 import (
-	"time"
+	"errors"
+	"fmt"
 
-	"github.com/google/uuid"
-	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/ext"
-	"github.com/opentracing/opentracing-go/log"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 	"gorm.io/gorm"
 )
 
+// ErrProductNotFound is returned when a product does not exist in the
+// database.
+var ErrProductNotFound = errors.New("product does not exist")
+
+// ErrVariantNotFound is returned when a product variant does not exist in
+// the database.
+var ErrVariantNotFound = errors.New("variant does not exist")
+
 // Repository is the interface that describes a product repository
 // object.
 type Repository interface {
-	SaveProduct(ctx context.Context, product *Product) error
-	GetProductBySKU(ctx context.Context, sku string) (*Product, error)
+	SaveProduct(ctx context.Context, product *Product, messages []*outbox.Message) error
+	GetProductBySKU(ctx context.Context, sku string, includeDeleted bool) (*Product, error)
+	GetProductsBySKUs(ctx context.Context, skus []string) ([]*Product, error)
+	UpdateProduct(ctx context.Context, product *Product, fields []string, messages []*outbox.Message) error
+	DeleteProduct(ctx context.Context, product *Product, messages []*outbox.Message) error
+	DeleteProducts(ctx context.Context, products []*Product, messages []*outbox.Message) error
+	RestoreProduct(ctx context.Context, product *Product, messages []*outbox.Message) error
+	PurgeProduct(ctx context.Context, product *Product, messages []*outbox.Message) error
+	ListProducts(ctx context.Context, filter ListFilter) ([]*Product, error)
+	ReplaceVariants(ctx context.Context, product *Product, options []Option, variants []Variant, messages []*outbox.Message) error
+	GetVariantBySKU(ctx context.Context, sku string) (*Variant, error)
+	GetProductByVariantSKU(ctx context.Context, sku string, includeDeleted bool) (*Product, error)
 }
 
 // ProductRepo is the default implementation for Repository inteface.
 type ProductRepo struct {
 	db     *gorm.DB
-	tracer opentracing.Tracer
+	tracer trace.Tracer
 }
 
 // NewRepository returns a new product repository object.
-func NewRepository(db *gorm.DB, tracer opentracing.Tracer) *ProductRepo {
+func NewRepository(db *gorm.DB, tracer trace.Tracer) *ProductRepo {
 	return &ProductRepo{
 		db:     db,
 		tracer: tracer,
 	}
 }
 
-func (r *ProductRepo) setMySqlComponentTags(span opentracing.Span, tableName string) {
-	ext.DBInstance.Set(span, tableName)
-	ext.DBType.Set(span, "mysql")
-	ext.SpanKindRPCClient.Set(span)
+func (r *ProductRepo) setMySqlComponentTags(span trace.Span, tableName string) {
+	span.SetAttributes(semconv.DBSystemMySQL, semconv.DBSQLTableKey.String(tableName))
+}
+
+// SaveProduct saves a new product prepared with PrepareNew to the
+// database, messages are added to the outbox in the same transaction.
+func (r *ProductRepo) SaveProduct(ctx context.Context, product *Product, messages []*outbox.Message) error {
+	_, span := r.tracer.Start(ctx, "SaveProduct", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "products")
+	tracing.SetAttribute(span, "param.product", product)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(product).Error
+		if err != nil {
+			return err
+		}
+		return outbox.Save(tx, messages)
+	})
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return err
+	}
+	return nil
+}
+
+// GetProductBySKU retrieves the product with the provided sku from the
+// database, soft deleted products are only returned when includeDeleted
+// is true.
+func (r *ProductRepo) GetProductBySKU(ctx context.Context, sku string, includeDeleted bool) (*Product, error) {
+	_, span := r.tracer.Start(ctx, "GetProductBySKU", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "products")
+	tracing.SetAttribute(span, "param.sku", sku)
+	tracing.SetAttribute(span, "param.includeDeleted", includeDeleted)
+
+	db := r.db
+	if includeDeleted {
+		db = db.Unscoped()
+	}
+	product := &Product{}
+	err := db.Where("sku = ?", sku).
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		span.AddEvent("product not found")
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Where.First")
+		return nil, err
+	}
+	tracing.SetAttribute(span, "response.product", product)
+	return product, nil
+}
+
+// GetProductsBySKUs retrieves the products with the provided skus from the
+// database in a single query, skus that do not exist are skipped and the
+// products are not returned in any particular order.
+func (r *ProductRepo) GetProductsBySKUs(ctx context.Context, skus []string) ([]*Product, error) {
+	_, span := r.tracer.Start(ctx, "GetProductsBySKUs", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "products")
+	tracing.SetAttribute(span, "param.skus", skus)
+
+	products := []*Product{}
+	err := r.db.Where("sku IN ?", skus).
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Find(&products).Error
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Where.Find")
+		return nil, err
+	}
+	tracing.SetAttribute(span, "response.count", len(products))
+	return products, nil
+}
+
+// UpdateProduct saves the provided fields of an existing product to the
+// database, other fields are left untouched. messages are added to the
+// outbox in the same transaction.
+func (r *ProductRepo) UpdateProduct(ctx context.Context, product *Product, fields []string, messages []*outbox.Message) error {
+	_, span := r.tracer.Start(ctx, "UpdateProduct", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "products")
+	tracing.SetAttribute(span, "param.fields", fields)
+	tracing.SetAttribute(span, "param.product", product)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(product).Select(updateColumns(fields)).Updates(product).Error
+		if err != nil {
+			return err
+		}
+		return outbox.Save(tx, messages)
+	})
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return err
+	}
+	return nil
+}
+
+// embeddedColumns are the columns of the product fields that are embedded
+// structs, gorm does not match the name of such a field to its columns.
+var embeddedColumns = map[string][]string{
+	"Price": {"price_amount", "price_currency"},
+}
+
+// updateColumns returns the columns to update for the provided product
+// fields.
+func updateColumns(fields []string) []string {
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if embedded, ok := embeddedColumns[field]; ok {
+			columns = append(columns, embedded...)
+			continue
+		}
+		columns = append(columns, field)
+	}
+	return columns
 }
 
-// SaveProduct saves a new product to the database.
-func (r *ProductRepo) SaveProduct(ctx context.Context, product *Product) error {
-	product.Sku = uuid.NewString()
-	product.TimeAdded = time.Now()
+// DeleteProduct soft deletes a product, the product can be restored later
+// with RestoreProduct. messages are added to the outbox in the same
+// transaction.
+func (r *ProductRepo) DeleteProduct(ctx context.Context, product *Product, messages []*outbox.Message) error {
+	_, span := r.tracer.Start(ctx, "DeleteProduct", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "products")
+	tracing.SetAttribute(span, "param.sku", product.Sku)
 
-	span, _ := opentracing.StartSpanFromContextWithTracer(ctx, r.tracer, "SaveProduct")
-	defer span.Finish()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(product).Error
+		if err != nil {
+			return err
+		}
+		return outbox.Save(tx, messages)
+	})
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return err
+	}
+	return nil
+}
+
+// DeleteProducts soft deletes products in a single transaction, messages
+// are added to the outbox in the same transaction.
+func (r *ProductRepo) DeleteProducts(ctx context.Context, products []*Product, messages []*outbox.Message) error {
+	_, span := r.tracer.Start(ctx, "DeleteProducts", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
 	r.setMySqlComponentTags(span, "products")
-	span.LogFields(
-		log.Object("param.product", product),
-	)
+	tracing.SetAttribute(span, "param.count", len(products))
 
-	err := r.db.Create(product).Error
+	ids := make([]int, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id IN ?", ids).Delete(&Product{}).Error
+		if err != nil {
+			return err
+		}
+		return outbox.Save(tx, messages)
+	})
 	if err != nil {
-		ext.Error.Set(span, true)
-		span.LogFields(log.Error(err), log.Event("gorm.db.Create"))
+		tracing.RecordError(span, err, "gorm.db.Transaction")
 		return err
 	}
 	return nil
 }
 
-func (r *ProductRepo) GetProductBySKU(ctx context.Context, sku string) (*Product, error) {
-	span, _ := opentracing.StartSpanFromContextWithTracer(ctx, r.tracer, "GetProductBySKU")
-	defer span.Finish()
+// RestoreProduct restores a soft deleted product, messages are added to the
+// outbox in the same transaction.
+func (r *ProductRepo) RestoreProduct(ctx context.Context, product *Product, messages []*outbox.Message) error {
+	_, span := r.tracer.Start(ctx, "RestoreProduct", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
 	r.setMySqlComponentTags(span, "products")
-	span.SetTag("param.sku", sku)
+	tracing.SetAttribute(span, "param.sku", product.Sku)
 
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(product).Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		return outbox.Save(tx, messages)
+	})
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return err
+	}
+	product.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// PurgeProduct permanently removes a product from the database, messages
+// are added to the outbox in the same transaction.
+func (r *ProductRepo) PurgeProduct(ctx context.Context, product *Product, messages []*outbox.Message) error {
+	_, span := r.tracer.Start(ctx, "PurgeProduct", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "products")
+	tracing.SetAttribute(span, "param.sku", product.Sku)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Delete(product).Error
+		if err != nil {
+			return err
+		}
+		return outbox.Save(tx, messages)
+	})
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return err
+	}
+	return nil
+}
+
+// ListProducts retrieves the products matching filter from the database,
+// products are sorted by filter.SortBy and then by id so that pages
+// stay stable when new products are added.
+func (r *ProductRepo) ListProducts(ctx context.Context, filter ListFilter) ([]*Product, error) {
+	_, span := r.tracer.Start(ctx, "ListProducts", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "products")
+	tracing.SetAttribute(span, "param.filter", filter)
+
+	db := r.db
+	if filter.MerchantID != "" {
+		db = db.Where("merchant_id = ?", filter.MerchantID)
+	}
+	if filter.Category != "" {
+		db = db.Where("category = ?", filter.Category)
+	}
+	if filter.Brand != "" {
+		db = db.Where("brand = ?", filter.Brand)
+	}
+	if filter.Currency != "" {
+		db = db.Where("price_currency = ?", filter.Currency)
+	}
+	if filter.MinPrice != nil {
+		db = db.Where("price_amount >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		db = db.Where("price_amount <= ?", *filter.MaxPrice)
+	}
+	if filter.AddedAfter != nil {
+		db = db.Where("time_added >= ?", *filter.AddedAfter)
+	}
+	if filter.AddedBefore != nil {
+		db = db.Where("time_added < ?", *filter.AddedBefore)
+	}
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = SortByTimeAdded
+	}
+	if !sortBy.Valid() {
+		err := fmt.Errorf("products cannot be sorted by %s", sortBy)
+		tracing.RecordError(span, err, "")
+		return nil, err
+	}
+	operator, direction := ">", "ASC"
+	if filter.Descending {
+		operator, direction = "<", "DESC"
+	}
+	column := sortBy.column()
+	if filter.After != nil {
+		value := filter.After.value()
+		db = db.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, operator, column, operator),
+			value, value, filter.After.ID,
+		)
+	}
+
+	products := []*Product{}
+	err := db.Order(fmt.Sprintf("%s %s", column, direction)).Order("id " + direction).
+		Limit(filter.Limit).Find(&products).Error
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Where.Order.Limit.Find")
+		return nil, err
+	}
+	tracing.SetAttribute(span, "response.count", len(products))
+	return products, nil
+}
+
+// ReplaceVariants replaces the options and variants of product, prepared
+// with PrepareVariants, in a single transaction. Variants with an id are
+// updated, variants without an id are created and variants that are not
+// provided are deleted, messages are added to the outbox in the same
+// transaction.
+func (r *ProductRepo) ReplaceVariants(ctx context.Context, product *Product, options []Option, variants []Variant, messages []*outbox.Message) error {
+	_, span := r.tracer.Start(ctx, "ReplaceVariants", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "variants")
+	tracing.SetAttribute(span, "param.sku", product.Sku)
+	tracing.SetAttribute(span, "param.variants.count", len(variants))
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("product_id = ?", product.ID).Delete(&Option{}).Error
+		if err != nil {
+			return err
+		}
+		for i := range options {
+			options[i].ID = 0
+			options[i].ProductID = product.ID
+		}
+		if len(options) > 0 {
+			err = tx.Create(&options).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		keptIDs := []int{}
+		for i := range variants {
+			variants[i].ProductID = product.ID
+			if variants[i].ID != 0 {
+				keptIDs = append(keptIDs, variants[i].ID)
+			}
+		}
+		deleteQuery := tx.Where("product_id = ?", product.ID)
+		if len(keptIDs) > 0 {
+			deleteQuery = deleteQuery.Where("id NOT IN ?", keptIDs)
+		}
+		err = deleteQuery.Delete(&Variant{}).Error
+		if err != nil {
+			return err
+		}
+		for i := range variants {
+			err = tx.Save(&variants[i]).Error
+			if err != nil {
+				return err
+			}
+		}
+		return outbox.Save(tx, messages)
+	})
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Transaction")
+		return err
+	}
+	product.Options = options
+	product.Variants = variants
+	return nil
+}
+
+// GetVariantBySKU retrieves the variant with the provided sku from the
+// database.
+func (r *ProductRepo) GetVariantBySKU(ctx context.Context, sku string) (*Variant, error) {
+	_, span := r.tracer.Start(ctx, "GetVariantBySKU", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "variants")
+	tracing.SetAttribute(span, "param.sku", sku)
+
+	variant := &Variant{}
+	err := r.db.Where("sku = ?", sku).First(variant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		span.AddEvent("variant not found")
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Where.First")
+		return nil, err
+	}
+	return variant, nil
+}
+
+// GetProductByVariantSKU retrieves the product that has a variant with the
+// provided sku from the database, soft deleted products are only retrieved
+// when includeDeleted is true.
+func (r *ProductRepo) GetProductByVariantSKU(ctx context.Context, sku string, includeDeleted bool) (*Product, error) {
+	_, span := r.tracer.Start(ctx, "GetProductByVariantSKU", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "products")
+	tracing.SetAttribute(span, "param.sku", sku)
+	tracing.SetAttribute(span, "param.includeDeleted", includeDeleted)
+
+	db := r.db
+	if includeDeleted {
+		db = db.Unscoped()
+	}
 	product := &Product{}
-	err := r.db.Where("sku = ?", sku).First(product).Error
+	err := db.Where("id = (?)", r.db.Model(&Variant{}).Select("product_id").Where("sku = ?", sku)).
+		First(product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		span.AddEvent("product not found")
+		return nil, ErrProductNotFound
+	}
 	if err != nil {
-		ext.Error.Set(span, true)
-		span.LogFields(log.Error(err), log.Event("gorm.db.Where.First"))
+		tracing.RecordError(span, err, "gorm.db.Where.First")
 		return nil, err
 	}
-	span.SetTag("response.product", product)
 	return product, nil
 }
@@ -0,0 +1,94 @@
+package products
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// recordingConn is a gorm connection pool that records the executed
+// statements instead of running them, queries are not supported. gorm
+// takes it for an open transaction and uses savepoints.
+type recordingConn struct {
+	statements []string
+}
+
+func (c *recordingConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	c.statements = append(c.statements, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("query is not supported")
+}
+
+func (c *recordingConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (c *recordingConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return c, nil
+}
+
+func (c *recordingConn) Commit() error   { return nil }
+func (c *recordingConn) Rollback() error { return nil }
+
+func TestProductRepo_UpdateProduct(t *testing.T) {
+	tests := []struct {
+		name        string
+		fields      []string
+		wantColumns []string
+	}{
+		{
+			name:        "price",
+			fields:      []string{"Price"},
+			wantColumns: []string{"`price_amount`=?", "`price_currency`=?"},
+		},
+		{
+			name:        "price and name",
+			fields:      []string{"Name", "Price"},
+			wantColumns: []string{"`name`=?", "`price_amount`=?", "`price_currency`=?"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &recordingConn{}
+			db, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}), &gorm.Config{})
+			if err != nil {
+				t.Fatalf("gorm.Open() error = %v", err)
+			}
+			repo := NewRepository(db, trace.NewNoopTracerProvider().Tracer(""))
+			product := &Product{ID: 1, Name: "Shoe", Price: Money{Amount: 1999, Currency: "USD"}}
+
+			err = repo.UpdateProduct(context.Background(), product, tt.fields, nil)
+			if err != nil {
+				t.Fatalf("UpdateProduct() error = %v", err)
+			}
+			var update string
+			for _, statement := range conn.statements {
+				if strings.HasPrefix(statement, "UPDATE `products` SET ") {
+					update = statement
+				}
+			}
+			if update == "" {
+				t.Fatalf("UpdateProduct() statements = %q, want a products update", conn.statements)
+			}
+			for _, column := range tt.wantColumns {
+				if !strings.Contains(update, column) {
+					t.Errorf("UpdateProduct() statement = %q, want column %s", update, column)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package products
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits of the product fields.
+const (
+	MaxNameLength        = 150
+	MaxDescriptionLength = 5000
+	MaxBrandLength       = 100
+	MaxImageURLLength    = 2048
+	// MaxPriceAmount is the maximum price in the minor unit of the
+	// product currency.
+	MaxPriceAmount = 10000000000
+)
+
+// Categories are the categories a product can belong to.
+var Categories = []string{
+	"automotive",
+	"beauty",
+	"books",
+	"electronics",
+	"fashion",
+	"groceries",
+	"health",
+	"home",
+	"sports",
+	"toys",
+	"other",
+}
+
+// FieldError describes why a product field is invalid, Field is the json
+// name of the field.
+type FieldError struct {
+	Field       string
+	Description string
+}
+
+// productValidators are the validators of each product field that can be
+// set by a merchant.
+var productValidators = []struct {
+	name     string
+	validate func(p *Product) []FieldError
+}{
+	{name: "Name", validate: validateName},
+	{name: "Description", validate: validateDescription},
+	{name: "Category", validate: validateCategory},
+	{name: "Brand", validate: validateBrand},
+	{name: "Price", validate: validatePrice},
+	{name: "ImageURL", validate: validateImageURL},
+	{name: "Variants", validate: validateVariants},
+}
+
+// Validate checks the provided product fields and returns every invalid
+// field, all the fields are checked when no field is provided.
+func (p *Product) Validate(fields ...string) []FieldError {
+	selected := map[string]bool{}
+	for _, field := range fields {
+		selected[field] = true
+	}
+	// the price of the variants must have the currency of the product.
+	if selected["Price"] {
+		selected["Variants"] = true
+	}
+	errs := []FieldError{}
+	for _, validator := range productValidators {
+		if len(selected) > 0 && !selected[validator.name] {
+			continue
+		}
+		errs = append(errs, validator.validate(p)...)
+	}
+	return errs
+}
+
+func validateName(p *Product) []FieldError {
+	if strings.TrimSpace(p.Name) == "" {
+		return []FieldError{{Field: "name", Description: "name is required"}}
+	}
+	if utf8.RuneCountInString(p.Name) > MaxNameLength {
+		return []FieldError{{Field: "name", Description: fmt.Sprintf("name must not be longer than %d characters", MaxNameLength)}}
+	}
+	return nil
+}
+
+func validateDescription(p *Product) []FieldError {
+	if utf8.RuneCountInString(p.Description) > MaxDescriptionLength {
+		return []FieldError{{Field: "description", Description: fmt.Sprintf("description must not be longer than %d characters", MaxDescriptionLength)}}
+	}
+	return nil
+}
+
+func validateCategory(p *Product) []FieldError {
+	if strings.TrimSpace(p.Category) == "" {
+		return []FieldError{{Field: "category", Description: "category is required"}}
+	}
+	for _, category := range Categories {
+		if strings.EqualFold(p.Category, category) {
+			return nil
+		}
+	}
+	return []FieldError{{Field: "category", Description: fmt.Sprintf("category must be one of %s", strings.Join(Categories, ", "))}}
+}
+
+func validateBrand(p *Product) []FieldError {
+	if utf8.RuneCountInString(p.Brand) > MaxBrandLength {
+		return []FieldError{{Field: "brand", Description: fmt.Sprintf("brand must not be longer than %d characters", MaxBrandLength)}}
+	}
+	return nil
+}
+
+func validatePrice(p *Product) []FieldError {
+	errs := []FieldError{}
+	if p.Price.Amount <= 0 {
+		errs = append(errs, FieldError{Field: "price.amount", Description: "price must be greater than 0"})
+	}
+	if p.Price.Amount > MaxPriceAmount {
+		errs = append(errs, FieldError{Field: "price.amount", Description: fmt.Sprintf("price must not be greater than %d", int64(MaxPriceAmount))})
+	}
+	if _, ok := CurrencyExponent(p.Price.Currency); !ok {
+		errs = append(errs, FieldError{Field: "price.currencyCode", Description: "currencyCode must be a supported ISO 4217 currency code"})
+	}
+	return errs
+}
+
+func validateImageURL(p *Product) []FieldError {
+	return validateURL("imageUrl", p.ImageURL)
+}
+
+// validateURL checks that value is empty or a http or https url, field is
+// the path of the field e.g variants[0].imageUrl.
+func validateURL(field, value string) []FieldError {
+	if value == "" {
+		return nil
+	}
+	if len(value) > MaxImageURLLength {
+		return []FieldError{{Field: field, Description: fmt.Sprintf("%s must not be longer than %d characters", field, MaxImageURLLength)}}
+	}
+	parsedURL, err := url.ParseRequestURI(value)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return []FieldError{{Field: field, Description: field + " must be a valid http or https url"}}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package products
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProduct_Validate(t *testing.T) {
+	validProduct := Product{
+		Name:     "Leather Shoe",
+		Category: "Fashion",
+		Price:    Money{Amount: 1999, Currency: "USD"},
+		ImageURL: "https://cdn.example.com/shoe.png",
+	}
+	type args struct {
+		fields []string
+	}
+	tests := []struct {
+		name    string
+		product Product
+		args    args
+		want    []FieldError
+	}{
+		{
+			name:    "valid product",
+			product: validProduct,
+			want:    []FieldError{},
+		},
+		{
+			name:    "empty product",
+			product: Product{},
+			want: []FieldError{
+				{Field: "name", Description: "name is required"},
+				{Field: "category", Description: "category is required"},
+				{Field: "price.amount", Description: "price must be greater than 0"},
+				{Field: "price.currencyCode", Description: "currencyCode must be a supported ISO 4217 currency code"},
+			},
+		},
+		{
+			name: "every field invalid",
+			product: Product{
+				Name:        strings.Repeat("a", MaxNameLength+1),
+				Description: strings.Repeat("a", MaxDescriptionLength+1),
+				Category:    "weapons",
+				Brand:       strings.Repeat("a", MaxBrandLength+1),
+				Price:       Money{Amount: -10, Currency: "XYZ"},
+				ImageURL:    "javascript:alert(1)",
+			},
+			want: []FieldError{
+				{Field: "name", Description: "name must not be longer than 150 characters"},
+				{Field: "description", Description: "description must not be longer than 5000 characters"},
+				{Field: "category", Description: "category must be one of " + strings.Join(Categories, ", ")},
+				{Field: "brand", Description: "brand must not be longer than 100 characters"},
+				{Field: "price.amount", Description: "price must be greater than 0"},
+				{Field: "price.currencyCode", Description: "currencyCode must be a supported ISO 4217 currency code"},
+				{Field: "imageUrl", Description: "imageUrl must be a valid http or https url"},
+			},
+		},
+		{
+			name:    "price above limit",
+			product: Product{Name: "Car", Category: "automotive", Price: Money{Amount: MaxPriceAmount + 1, Currency: "USD"}},
+			want:    []FieldError{{Field: "price.amount", Description: "price must not be greater than 10000000000"}},
+		},
+		{
+			name:    "only selected fields are validated",
+			product: Product{Price: Money{Amount: -1}, ImageURL: "not a url"},
+			args:    args{fields: []string{"ImageURL"}},
+			want:    []FieldError{{Field: "imageUrl", Description: "imageUrl must be a valid http or https url"}},
+		},
+		{
+			name: "price change checks the variant currencies",
+			product: Product{
+				Price:    Money{Amount: 1000, Currency: "EUR"},
+				Options:  []Option{{Name: "size", Values: StringList{"S"}}},
+				Variants: []Variant{{Options: OptionValues{"size": "S"}, Price: Money{Amount: 1200, Currency: "USD"}}},
+			},
+			args: args{fields: []string{"Price"}},
+			want: []FieldError{{Field: "variants[0].price.currencyCode", Description: "currencyCode must be the same as the product currency"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.product.Validate(tt.args.fields...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Product.Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package products
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Limits of product options and variants.
+const (
+	MaxOptions          = 3
+	MaxOptionValues     = 50
+	MaxOptionNameLength = 50
+	MaxVariants         = 250
+)
+
+// Option is an axis a product varies on e.g size or colour, with the
+// values it can take.
+type Option struct {
+	ID        int        `json:"-" gorm:"autoIncrement,primaryKey"`
+	ProductID int        `json:"-" gorm:"index"`
+	Name      string     `json:"name"`
+	Position  int        `json:"position"`
+	Values    StringList `json:"values" gorm:"type:text"`
+}
+
+// Variant is a purchasable version of a product with one value of each
+// product option e.g a medium red shirt.
+type Variant struct {
+	ID        int          `json:"-" gorm:"autoIncrement,primaryKey"`
+	ProductID int          `json:"-" gorm:"index"`
+	Sku       string       `json:"sku" gorm:"uniqueIndex;size:64"`
+	Options   OptionValues `json:"options" gorm:"type:text"`
+	// Price overrides the product price when it is set.
+	Price    Money  `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	ImageURL string `json:"imageUrl"`
+}
+
+// EffectivePrice returns the price the variant is sold at.
+func (v *Variant) EffectivePrice(product *Product) Money {
+	if v.Price == (Money{}) {
+		return product.Price
+	}
+	return v.Price
+}
+
+// StringList is a list of strings stored as json.
+type StringList []string
+
+// Scan implements the sql.Scanner interface.
+func (l *StringList) Scan(value interface{}) error {
+	return scanJSON(value, l)
+}
+
+// Value implements the driver.Valuer interface.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return valueJSON(l)
+}
+
+// OptionValues maps option names to the value of a variant e.g
+// size: M, colour: red.
+type OptionValues map[string]string
+
+// Scan implements the sql.Scanner interface.
+func (o *OptionValues) Scan(value interface{}) error {
+	return scanJSON(value, o)
+}
+
+// Value implements the driver.Valuer interface.
+func (o OptionValues) Value() (driver.Value, error) {
+	if o == nil {
+		return "{}", nil
+	}
+	return valueJSON(o)
+}
+
+// Key returns a string that is the same for option values that only
+// differ in case.
+func (o OptionValues) Key() string {
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	lowered := map[string]string{}
+	for name, value := range o {
+		lowered[strings.ToLower(name)] = strings.ToLower(value)
+	}
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+lowered[name])
+	}
+	return strings.Join(parts, ";")
+}
+
+func scanJSON(value interface{}, dest interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(value, dest)
+	case string:
+		return json.Unmarshal([]byte(value), dest)
+	}
+	return errors.New("unsupported json column value")
+}
+
+func valueJSON(value interface{}) (driver.Value, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// GenerateVariantOptions returns every combination of the option values
+// in options, in the order of the options and their values.
+func GenerateVariantOptions(options []Option) []OptionValues {
+	if len(options) == 0 {
+		return nil
+	}
+	combinations := []OptionValues{{}}
+	for _, option := range options {
+		next := make([]OptionValues, 0, len(combinations)*len(option.Values))
+		for _, combination := range combinations {
+			for _, value := range option.Values {
+				values := OptionValues{option.Name: value}
+				for name, existing := range combination {
+					values[name] = existing
+				}
+				next = append(next, values)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// VariantCount returns the number of variants the full matrix of options
+// has.
+func VariantCount(options []Option) int {
+	if len(options) == 0 {
+		return 0
+	}
+	count := 1
+	for _, option := range options {
+		count *= len(option.Values)
+	}
+	return count
+}
+
+// ValidateOptions checks that options can be used to generate variants.
+func ValidateOptions(options []Option) []FieldError {
+	errs := []FieldError{}
+	if len(options) > MaxOptions {
+		errs = append(errs, FieldError{Field: "options", Description: fmt.Sprintf("a product can have at most %d options", MaxOptions)})
+	}
+	names := map[string]bool{}
+	for i, option := range options {
+		field := fmt.Sprintf("options[%d]", i)
+		name := strings.ToLower(strings.TrimSpace(option.Name))
+		switch {
+		case name == "":
+			errs = append(errs, FieldError{Field: field + ".name", Description: "name is required"})
+		case len(option.Name) > MaxOptionNameLength:
+			errs = append(errs, FieldError{Field: field + ".name", Description: fmt.Sprintf("name must not be longer than %d characters", MaxOptionNameLength)})
+		case names[name]:
+			errs = append(errs, FieldError{Field: field + ".name", Description: "name must be unique"})
+		}
+		names[name] = true
+		if len(option.Values) == 0 {
+			errs = append(errs, FieldError{Field: field + ".values", Description: "at least one value is required"})
+		}
+		if len(option.Values) > MaxOptionValues {
+			errs = append(errs, FieldError{Field: field + ".values", Description: fmt.Sprintf("an option can have at most %d values", MaxOptionValues)})
+		}
+		values := map[string]bool{}
+		for _, value := range option.Values {
+			value = strings.ToLower(strings.TrimSpace(value))
+			if value == "" || values[value] {
+				errs = append(errs, FieldError{Field: field + ".values", Description: "values must be unique and not empty"})
+				break
+			}
+			values[value] = true
+		}
+	}
+	if len(errs) == 0 && VariantCount(options) > MaxVariants {
+		errs = append(errs, FieldError{Field: "options", Description: fmt.Sprintf("options must not generate more than %d variants", MaxVariants)})
+	}
+	return errs
+}
+
+// validateVariants checks that every variant has one valid value of each
+// product option and that no two variants have the same values.
+func validateVariants(p *Product) []FieldError {
+	errs := ValidateOptions(p.Options)
+	if len(p.Variants) > 0 && len(p.Options) == 0 {
+		return append(errs, FieldError{Field: "variants", Description: "options are required for a product with variants"})
+	}
+	optionValues := map[string]map[string]bool{}
+	for _, option := range p.Options {
+		values := map[string]bool{}
+		for _, value := range option.Values {
+			values[strings.ToLower(value)] = true
+		}
+		optionValues[strings.ToLower(option.Name)] = values
+	}
+	seen := map[string]bool{}
+	for i, variant := range p.Variants {
+		field := fmt.Sprintf("variants[%d]", i)
+		valid := len(variant.Options) == len(p.Options)
+		for name, value := range variant.Options {
+			if !optionValues[strings.ToLower(name)][strings.ToLower(value)] {
+				valid = false
+			}
+		}
+		if !valid {
+			errs = append(errs, FieldError{Field: field + ".options", Description: "options must have one valid value of each product option"})
+		} else if seen[variant.Options.Key()] {
+			errs = append(errs, FieldError{Field: field + ".options", Description: "another variant has the same options"})
+		}
+		seen[variant.Options.Key()] = true
+		if variant.Price != (Money{}) {
+			if variant.Price.Amount <= 0 {
+				errs = append(errs, FieldError{Field: field + ".price.amount", Description: "price must be greater than 0"})
+			}
+			if variant.Price.Currency != p.Price.Currency {
+				errs = append(errs, FieldError{Field: field + ".price.currencyCode", Description: "currencyCode must be the same as the product currency"})
+			}
+		}
+		errs = append(errs, validateURL(field+".imageUrl", variant.ImageURL)...)
+	}
+	return errs
+}
@@ -0,0 +1,150 @@
+package products
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateVariantOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		want    []OptionValues
+	}{
+		{
+			name:    "no options",
+			options: nil,
+			want:    nil,
+		},
+		{
+			name:    "single option",
+			options: []Option{{Name: "size", Values: StringList{"S", "M"}}},
+			want:    []OptionValues{{"size": "S"}, {"size": "M"}},
+		},
+		{
+			name: "multiple options",
+			options: []Option{
+				{Name: "size", Values: StringList{"S", "M"}},
+				{Name: "colour", Values: StringList{"red", "blue"}},
+				{Name: "material", Values: StringList{"cotton"}},
+			},
+			want: []OptionValues{
+				{"size": "S", "colour": "red", "material": "cotton"},
+				{"size": "S", "colour": "blue", "material": "cotton"},
+				{"size": "M", "colour": "red", "material": "cotton"},
+				{"size": "M", "colour": "blue", "material": "cotton"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateVariantOptions(tt.options)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GenerateVariantOptions() = %v, want %v", got, tt.want)
+			}
+			if len(got) != VariantCount(tt.options) {
+				t.Errorf("VariantCount() = %v, want %v", VariantCount(tt.options), len(got))
+			}
+		})
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	manyValues := StringList{}
+	for i := 0; i < 16; i++ {
+		manyValues = append(manyValues, string(rune('a'+i)))
+	}
+	tests := []struct {
+		name    string
+		options []Option
+		want    []FieldError
+	}{
+		{
+			name:    "valid options",
+			options: []Option{{Name: "size", Values: StringList{"S", "M"}}, {Name: "colour", Values: StringList{"red"}}},
+			want:    []FieldError{},
+		},
+		{
+			name: "too many options",
+			options: []Option{
+				{Name: "a", Values: StringList{"1"}}, {Name: "b", Values: StringList{"1"}},
+				{Name: "c", Values: StringList{"1"}}, {Name: "d", Values: StringList{"1"}},
+			},
+			want: []FieldError{{Field: "options", Description: "a product can have at most 3 options"}},
+		},
+		{
+			name: "invalid names and values",
+			options: []Option{
+				{Name: "", Values: StringList{"S"}},
+				{Name: "size", Values: nil},
+				{Name: "Size", Values: StringList{"red", "Red"}},
+			},
+			want: []FieldError{
+				{Field: "options[0].name", Description: "name is required"},
+				{Field: "options[1].values", Description: "at least one value is required"},
+				{Field: "options[2].name", Description: "name must be unique"},
+				{Field: "options[2].values", Description: "values must be unique and not empty"},
+			},
+		},
+		{
+			name: "too many variants",
+			options: []Option{
+				{Name: "a", Values: manyValues}, {Name: "b", Values: manyValues},
+			},
+			want: []FieldError{{Field: "options", Description: "options must not generate more than 250 variants"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateOptions(tt.options); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateVariants(t *testing.T) {
+	options := []Option{{Name: "size", Values: StringList{"S", "M"}}, {Name: "colour", Values: StringList{"red"}}}
+	tests := []struct {
+		name    string
+		product *Product
+		want    []FieldError
+	}{
+		{
+			name: "valid variants",
+			product: &Product{Price: Money{Amount: 1000, Currency: "USD"}, Options: options, Variants: []Variant{
+				{Options: OptionValues{"size": "S", "colour": "red"}},
+				{Options: OptionValues{"Size": "m", "colour": "Red"}, Price: Money{Amount: 1200, Currency: "USD"}},
+			}},
+			want: []FieldError{},
+		},
+		{
+			name:    "variants without options",
+			product: &Product{Variants: []Variant{{Options: OptionValues{"size": "S"}}}},
+			want:    []FieldError{{Field: "variants", Description: "options are required for a product with variants"}},
+		},
+		{
+			name: "invalid variants",
+			product: &Product{Price: Money{Amount: 1000, Currency: "USD"}, Options: options, Variants: []Variant{
+				{Options: OptionValues{"size": "XL", "colour": "red"}},
+				{Options: OptionValues{"size": "S"}},
+				{Options: OptionValues{"size": "M", "colour": "red"}, Price: Money{Amount: 1200, Currency: "EUR"}},
+				{Options: OptionValues{"size": "M", "colour": "red"}, ImageURL: "ftp://example.com/a.png"},
+			}},
+			want: []FieldError{
+				{Field: "variants[0].options", Description: "options must have one valid value of each product option"},
+				{Field: "variants[1].options", Description: "options must have one valid value of each product option"},
+				{Field: "variants[2].price.currencyCode", Description: "currencyCode must be the same as the product currency"},
+				{Field: "variants[3].options", Description: "another variant has the same options"},
+				{Field: "variants[3].imageUrl", Description: "variants[3].imageUrl must be a valid http or https url"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateVariants(tt.product); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateVariants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
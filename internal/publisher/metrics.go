@@ -0,0 +1,46 @@
+package publisher
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Outcomes of publishing a message, they are the values of the outcome
+// label of the publish metric.
+const (
+	OutcomePublished    = "published"
+	OutcomeSpooled      = "spooled"
+	OutcomeDeadLettered = "dead_lettered"
+	OutcomeFailed       = "failed"
+)
+
+// Metrics are the prometheus metrics of a publisher.
+type Metrics struct {
+	publishes *prometheus.CounterVec
+	retries   *prometheus.CounterVec
+	spooled   prometheus.Gauge
+}
+
+// NewMetrics returns the metrics of a publisher registered with
+// registerer.
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		publishes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "product_service",
+			Subsystem: "nats",
+			Name:      "publishes_total",
+			Help:      "Messages published to nats by subject and outcome.",
+		}, []string{"subject", "outcome"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "product_service",
+			Subsystem: "nats",
+			Name:      "publish_retries_total",
+			Help:      "Failed attempts to publish a message to nats that were retried, by subject.",
+		}, []string{"subject"}),
+		spooled: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "product_service",
+			Subsystem: "nats",
+			Name:      "spooled_messages",
+			Help:      "Messages waiting in the local spool for nats to be reachable.",
+		}),
+	}
+	registerer.MustRegister(m.publishes, m.retries, m.spooled)
+	return m
+}
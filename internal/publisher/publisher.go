@@ -0,0 +1,306 @@
+// Package publisher publishes messages to nats without losing them when
+// nats fails. Failed publishes are retried with a bounded exponential
+// backoff, messages that still cannot be sent are stored in a local spool
+// until nats is reachable again, and messages nats rejects are moved to a
+// dead letter subject.
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/stream"
+)
+
+// Conn is the interface that describes the nats connection messages are
+// published with, *nats.Conn and *stream.Publisher implement it.
+type Conn interface {
+	PublishMsg(msg *nats.Msg) error
+	FlushTimeout(timeout time.Duration) error
+	IsConnected() bool
+}
+
+// ErrSpooled is returned by PublishMsg when a message was spooled instead
+// of published. The spool is on the local disk and is lost with it, so the
+// outbox keeps the message pending and publishes it again later, nats
+// drops the duplicates by their Nats-Msg-Id.
+var ErrSpooled = fmt.Errorf("message spooled: %w", outbox.ErrUnavailable)
+
+// DeadLetter is the payload published to the dead letter subject of a
+// message nats rejected. It is published without headers, and the header
+// and data of the message are left out when they caused the rejection, so
+// that nats does not reject the dead letter for the same reason.
+type DeadLetter struct {
+	Subject string      `json:"subject"`
+	MsgID   string      `json:"msgId,omitempty"`
+	Error   string      `json:"error"`
+	Header  nats.Header `json:"header,omitempty"`
+	Data    []byte      `json:"data,omitempty"`
+}
+
+const (
+	spoolBatchSize    = 100
+	spoolFlushTimeout = 5 * time.Second
+)
+
+// Config is the configuration of a publisher.
+type Config struct {
+	// MaxAttempts is how many times publishing a message is tried before
+	// the message is spooled.
+	MaxAttempts int
+	// RetryDelay is the delay before the first retry, it doubles after
+	// every attempt up to MaxRetryDelay.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	// DeadLetterPrefix prefixes the subject of the messages nats rejects,
+	// e.g dlq.product-service.publish.products.v1.created.
+	DeadLetterPrefix string
+}
+
+// Publisher publishes messages to nats, it implements outbox.Publisher.
+// Messages are published in order, while the spool has messages new
+// messages are spooled behind them.
+type Publisher struct {
+	conn    Conn
+	spool   *Spool
+	metrics *Metrics
+	config  Config
+
+	mu      sync.Mutex
+	spooled int
+}
+
+// NewPublisher returns a new publisher object, the messages already in
+// spool are published when Run is called.
+func NewPublisher(conn Conn, spool *Spool, metrics *Metrics, config Config) (*Publisher, error) {
+	spooled, err := spool.Len()
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	metrics.spooled.Set(float64(spooled))
+	return &Publisher{
+		conn:    conn,
+		spool:   spool,
+		metrics: metrics,
+		config:  config,
+		spooled: spooled,
+	}, nil
+}
+
+// PublishMsg publishes msg, or spools it when nats is not connected,
+// publishing still fails after MaxAttempts or nats rejected it and it
+// could not be dead lettered. It returns ErrSpooled when the message was
+// spooled, a message is spooled once even when it is published again
+// while it waits in the spool.
+func (p *Publisher) PublishMsg(msg *nats.Msg) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.spooled > 0 || !p.conn.IsConnected() {
+		return p.spoolMsg(msg)
+	}
+	err := p.publishWithRetry(msg)
+	switch {
+	case err == nil:
+		p.metrics.publishes.WithLabelValues(msg.Subject, OutcomePublished).Inc()
+		return nil
+	case isRejected(err) && p.deadLetter(msg, err) == nil:
+		return nil
+	}
+	return p.spoolMsg(msg)
+}
+
+// FlushTimeout waits for nats to receive the published messages, there is
+// nothing to wait for when nats is not connected since the messages were
+// spooled. The messages jetstream rejected while they were flushed are
+// dead lettered.
+func (p *Publisher) FlushTimeout(timeout time.Duration) error {
+	if !p.conn.IsConnected() {
+		return nil
+	}
+	return p.flushConn(timeout)
+}
+
+// flushConn flushes the conn and dead letters the messages of the
+// stream.RejectedError it returns, the error is returned when a message
+// could not be dead lettered.
+func (p *Publisher) flushConn(timeout time.Duration) error {
+	err := p.conn.FlushTimeout(timeout)
+	var rejected stream.RejectedError
+	if !errors.As(err, &rejected) {
+		return err
+	}
+	for _, msg := range rejected {
+		err = p.deadLetter(msg.Msg, msg.Err)
+		if err != nil {
+			return err
+		}
+	}
+	return p.conn.FlushTimeout(timeout)
+}
+
+// Flush publishes the spooled messages when nats is connected and waits
+// for nats to receive the published messages. Messages that are still
+// spooled are published after the next start.
+func (p *Publisher) Flush(timeout time.Duration) error {
+	err := p.DrainSpool()
+	if err != nil {
+		return err
+	}
+	return p.FlushTimeout(timeout)
+}
+
+// Run publishes the spooled messages every interval until ctx is done.
+func (p *Publisher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// the messages that could not be published stay in the spool
+			// and are retried on the next tick.
+			p.DrainSpool()
+		}
+	}
+}
+
+// DrainSpool publishes the spooled messages in order while nats is
+// connected, a message is removed from the spool once nats received it.
+func (p *Publisher) DrainSpool() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.spooled > 0 && p.conn.IsConnected() {
+		entries, err := p.spool.Pending(spoolBatchSize)
+		if err != nil {
+			return err
+		}
+		sent := []*SpoolEntry{}
+		var publishErr error
+		for _, entry := range entries {
+			publishErr = p.conn.PublishMsg(entry.Msg)
+			if publishErr == nil {
+				p.metrics.publishes.WithLabelValues(entry.Msg.Subject, OutcomePublished).Inc()
+			} else if isRejected(publishErr) {
+				// the message can never be published, it is removed once
+				// it is dead lettered.
+				publishErr = p.deadLetter(entry.Msg, publishErr)
+			}
+			if publishErr != nil {
+				break
+			}
+			sent = append(sent, entry)
+		}
+		if len(sent) > 0 {
+			// the messages are published again when flushing fails,
+			// jetstream drops the duplicates by their Nats-Msg-Id.
+			err = p.flushConn(spoolFlushTimeout)
+			if err != nil {
+				return err
+			}
+			err = p.spool.Remove(sent)
+			if err != nil {
+				return err
+			}
+			p.spooled -= len(sent)
+			p.metrics.spooled.Set(float64(p.spooled))
+		}
+		if publishErr != nil {
+			return publishErr
+		}
+		if len(entries) == 0 {
+			// the spool was emptied outside of the publisher.
+			p.spooled = 0
+			p.metrics.spooled.Set(0)
+		}
+	}
+	return nil
+}
+
+// publishWithRetry publishes msg until it succeeds, nats rejects it or it
+// was tried MaxAttempts times. It is called with p.mu held, the lock is
+// released while backing off so that draining the spool is not blocked,
+// messages are published by a single relay so their order is kept.
+func (p *Publisher) publishWithRetry(msg *nats.Msg) error {
+	delay := p.config.RetryDelay
+	for attempt := 1; ; attempt++ {
+		err := p.conn.PublishMsg(msg)
+		if err == nil || isRejected(err) || attempt >= p.config.MaxAttempts || !p.conn.IsConnected() {
+			return err
+		}
+		p.metrics.retries.WithLabelValues(msg.Subject).Inc()
+		p.mu.Unlock()
+		time.Sleep(delay)
+		p.mu.Lock()
+		if p.spooled > 0 {
+			// another message was spooled meanwhile, msg is spooled
+			// behind it.
+			return err
+		}
+		delay *= 2
+		if p.config.MaxRetryDelay > 0 && delay > p.config.MaxRetryDelay {
+			delay = p.config.MaxRetryDelay
+		}
+	}
+}
+
+func (p *Publisher) spoolMsg(msg *nats.Msg) error {
+	if id := msg.Header.Get(nats.MsgIdHdr); id != "" && p.spool.Has(id) {
+		return ErrSpooled
+	}
+	err := p.spool.Add(msg)
+	if err != nil {
+		p.metrics.publishes.WithLabelValues(msg.Subject, OutcomeFailed).Inc()
+		return err
+	}
+	p.spooled++
+	p.metrics.spooled.Set(float64(p.spooled))
+	p.metrics.publishes.WithLabelValues(msg.Subject, OutcomeSpooled).Inc()
+	return ErrSpooled
+}
+
+// deadLetter publishes a DeadLetter of msg to the dead letter subject with
+// the error nats rejected msg with.
+func (p *Publisher) deadLetter(msg *nats.Msg, rejectErr error) error {
+	deadLetter := DeadLetter{
+		Subject: msg.Subject,
+		MsgID:   msg.Header.Get(nats.MsgIdHdr),
+		Error:   rejectErr.Error(),
+		Header:  msg.Header,
+		Data:    msg.Data,
+	}
+	switch {
+	case errors.Is(rejectErr, nats.ErrMaxPayload):
+		deadLetter.Header, deadLetter.Data = nil, nil
+	case errors.Is(rejectErr, nats.ErrHeadersNotSupported):
+		deadLetter.Header = nil
+	}
+	data, err := json.Marshal(deadLetter)
+	if err == nil {
+		err = p.conn.PublishMsg(&nats.Msg{Subject: p.config.DeadLetterPrefix + "." + msg.Subject, Data: data})
+	}
+	if err != nil {
+		p.metrics.publishes.WithLabelValues(msg.Subject, OutcomeFailed).Inc()
+		return err
+	}
+	p.metrics.publishes.WithLabelValues(msg.Subject, OutcomeDeadLettered).Inc()
+	return nil
+}
+
+// isRejected reports whether nats rejected a message because of the
+// message itself, publishing it again cannot succeed.
+func isRejected(err error) bool {
+	return errors.Is(err, nats.ErrMaxPayload) ||
+		errors.Is(err, nats.ErrBadSubject) ||
+		errors.Is(err, nats.ErrInvalidMsg) ||
+		errors.Is(err, nats.ErrHeadersNotSupported)
+}
@@ -0,0 +1,385 @@
+package publisher
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/stream"
+)
+
+// fakeConn records the published subjects and messages, errs are returned
+// by the next publishes of a subject and flushErrs by the next flushes.
+type fakeConn struct {
+	mu        sync.Mutex
+	connected bool
+	errs      map[string][]error
+	flushErrs []error
+	published []string
+	msgs      []*nats.Msg
+}
+
+func (c *fakeConn) PublishMsg(msg *nats.Msg) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if errs := c.errs[msg.Subject]; len(errs) > 0 {
+		c.errs[msg.Subject] = errs[1:]
+		return errs[0]
+	}
+	c.published = append(c.published, msg.Subject)
+	c.msgs = append(c.msgs, msg)
+	return nil
+}
+
+func (c *fakeConn) FlushTimeout(timeout time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.flushErrs) > 0 {
+		err := c.flushErrs[0]
+		c.flushErrs = c.flushErrs[1:]
+		return err
+	}
+	return nil
+}
+
+func (c *fakeConn) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
+}
+
+func newTestPublisher(t *testing.T, conn *fakeConn) (*Publisher, *Spool, *Metrics) {
+	t.Helper()
+	spool, err := NewSpool(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSpool() error = %v", err)
+	}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	p, err := NewPublisher(conn, spool, metrics, Config{
+		MaxAttempts: 3, RetryDelay: time.Millisecond, MaxRetryDelay: 2 * time.Millisecond, DeadLetterPrefix: "dlq",
+	})
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+	return p, spool, metrics
+}
+
+func TestPublisher_PublishMsg(t *testing.T) {
+	unavailable := errors.New("nats: connection unavailable")
+	tests := []struct {
+		name          string
+		connected     bool
+		errs          []error
+		deadLetterErr error
+		wantPublished []string
+		wantOutcome   string
+		wantRetries   float64
+		wantSpooled   int
+	}{
+		{name: "published", connected: true, wantPublished: []string{"products.created"}, wantOutcome: OutcomePublished},
+		{
+			name: "published after retries", connected: true, errs: []error{unavailable, unavailable},
+			wantPublished: []string{"products.created"}, wantOutcome: OutcomePublished, wantRetries: 2,
+		},
+		{
+			name: "spooled after max attempts", connected: true, errs: []error{unavailable, unavailable, unavailable},
+			wantOutcome: OutcomeSpooled, wantRetries: 2, wantSpooled: 1,
+		},
+		{name: "spooled when not connected", wantOutcome: OutcomeSpooled, wantSpooled: 1},
+		{
+			name: "dead lettered when rejected", connected: true, errs: []error{nats.ErrMaxPayload},
+			wantPublished: []string{"dlq.products.created"}, wantOutcome: OutcomeDeadLettered,
+		},
+		{
+			name: "spooled when dead lettering fails", connected: true, errs: []error{nats.ErrMaxPayload},
+			deadLetterErr: nats.ErrMaxPayload, wantOutcome: OutcomeSpooled, wantSpooled: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeConn{connected: tt.connected, errs: map[string][]error{"products.created": tt.errs}}
+			if tt.deadLetterErr != nil {
+				conn.errs["dlq.products.created"] = []error{tt.deadLetterErr}
+			}
+			p, spool, metrics := newTestPublisher(t, conn)
+			err := p.PublishMsg(&nats.Msg{Subject: "products.created", Data: []byte("{}")})
+			if wantSpooled := tt.wantSpooled > 0; errors.Is(err, ErrSpooled) != wantSpooled || (err != nil && !wantSpooled) {
+				t.Errorf("Publisher.PublishMsg() error = %v, want ErrSpooled %v", err, wantSpooled)
+				return
+			}
+			if !reflect.DeepEqual(conn.published, tt.wantPublished) {
+				t.Errorf("Publisher.PublishMsg() published = %v, want %v", conn.published, tt.wantPublished)
+			}
+			if got := testutil.ToFloat64(metrics.publishes.WithLabelValues("products.created", tt.wantOutcome)); got != 1 {
+				t.Errorf("Publisher.PublishMsg() %s publishes = %v, want 1", tt.wantOutcome, got)
+			}
+			if got := testutil.ToFloat64(metrics.retries.WithLabelValues("products.created")); got != tt.wantRetries {
+				t.Errorf("Publisher.PublishMsg() retries = %v, want %v", got, tt.wantRetries)
+			}
+			if spooled, _ := spool.Len(); spooled != tt.wantSpooled {
+				t.Errorf("Publisher.PublishMsg() spooled = %v, want %v", spooled, tt.wantSpooled)
+			}
+		})
+	}
+}
+
+func TestPublisher_PublishMsg_BackoffReleasesLock(t *testing.T) {
+	unavailable := errors.New("nats: connection unavailable")
+	conn := &fakeConn{connected: true, errs: map[string][]error{"products.created": {unavailable}}}
+	spool, err := NewSpool(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSpool() error = %v", err)
+	}
+	p, err := NewPublisher(conn, spool, NewMetrics(prometheus.NewRegistry()), Config{MaxAttempts: 2, RetryDelay: time.Second})
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+	published := make(chan error, 1)
+	go func() {
+		published <- p.PublishMsg(&nats.Msg{Subject: "products.created"})
+	}()
+	for i := 0; i < 100 && testutil.ToFloat64(p.metrics.retries.WithLabelValues("products.created")) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	err = p.DrainSpool()
+	if elapsed := time.Since(start); err != nil || elapsed > 500*time.Millisecond {
+		t.Errorf("Publisher.DrainSpool() during a backoff took %v, error = %v, want it not blocked", elapsed, err)
+	}
+	if err := <-published; err != nil || !reflect.DeepEqual(conn.published, []string{"products.created"}) {
+		t.Errorf("Publisher.PublishMsg() error = %v, published %v, want products.created", err, conn.published)
+	}
+}
+
+func TestPublisher_DrainSpool(t *testing.T) {
+	conn := &fakeConn{errs: map[string][]error{}}
+	p, spool, metrics := newTestPublisher(t, conn)
+	for _, subject := range []string{"products.1", "products.2"} {
+		err := p.PublishMsg(&nats.Msg{Subject: subject})
+		if !errors.Is(err, ErrSpooled) {
+			t.Fatalf("Publisher.PublishMsg() error = %v", err)
+		}
+	}
+	conn.connected = true
+	// messages are spooled behind the spooled messages to keep their order.
+	err := p.PublishMsg(&nats.Msg{Subject: "products.3"})
+	if !errors.Is(err, ErrSpooled) || len(conn.published) > 0 {
+		t.Fatalf("Publisher.PublishMsg() with a spool = %v %v, want the message spooled", conn.published, err)
+	}
+	if got := testutil.ToFloat64(metrics.spooled); got != 3 {
+		t.Errorf("spooled messages = %v, want 3", got)
+	}
+
+	err = p.DrainSpool()
+	if err != nil {
+		t.Fatalf("Publisher.DrainSpool() error = %v", err)
+	}
+	if want := []string{"products.1", "products.2", "products.3"}; !reflect.DeepEqual(conn.published, want) {
+		t.Errorf("Publisher.DrainSpool() published = %v, want %v", conn.published, want)
+	}
+	if spooled, _ := spool.Len(); spooled != 0 || testutil.ToFloat64(metrics.spooled) != 0 {
+		t.Errorf("Publisher.DrainSpool() left %v spooled messages", spooled)
+	}
+
+	// a restarted publisher picks up the messages spooled before.
+	conn.connected = false
+	err = p.PublishMsg(&nats.Msg{Subject: "products.4"})
+	if !errors.Is(err, ErrSpooled) {
+		t.Fatalf("Publisher.PublishMsg() error = %v", err)
+	}
+	restarted, err := NewPublisher(conn, spool, NewMetrics(prometheus.NewRegistry()), Config{})
+	if err != nil || restarted.spooled != 1 {
+		t.Errorf("NewPublisher() spooled = %v, err = %v, want 1", restarted.spooled, err)
+	}
+}
+
+func TestPublisher_PublishMsg_SpooledOnce(t *testing.T) {
+	conn := &fakeConn{errs: map[string][]error{}}
+	p, spool, _ := newTestPublisher(t, conn)
+	msg := &nats.Msg{Subject: "products.created", Header: nats.Header{nats.MsgIdHdr: {"outbox-1"}}}
+	for i := 0; i < 2; i++ {
+		err := p.PublishMsg(msg)
+		if !errors.Is(err, outbox.ErrUnavailable) {
+			t.Fatalf("Publisher.PublishMsg() error = %v, want outbox.ErrUnavailable", err)
+		}
+	}
+	if spooled, _ := spool.Len(); spooled != 1 || p.spooled != 1 {
+		t.Errorf("Publisher.PublishMsg() spooled %v messages, want 1", spooled)
+	}
+
+	// the ids of the spooled messages are read again after a restart.
+	restarted, err := NewSpool(spool.dir)
+	if err != nil || !restarted.Has("outbox-1") {
+		t.Errorf("NewSpool().Has() = false, err = %v, want the spooled message", err)
+	}
+	conn.connected = true
+	err = p.DrainSpool()
+	if err != nil || spool.Has("outbox-1") {
+		t.Errorf("Publisher.DrainSpool() error = %v, want the message removed from the spool", err)
+	}
+}
+
+func TestPublisher_DrainSpool_DeadLetter(t *testing.T) {
+	conn := &fakeConn{errs: map[string][]error{}}
+	p, spool, metrics := newTestPublisher(t, conn)
+	for _, subject := range []string{"products.1", "products.2"} {
+		err := p.PublishMsg(&nats.Msg{Subject: subject, Data: []byte("{}")})
+		if !errors.Is(err, ErrSpooled) {
+			t.Fatalf("Publisher.PublishMsg() error = %v", err)
+		}
+	}
+	conn.connected = true
+	conn.errs["products.1"] = []error{nats.ErrMaxPayload, nats.ErrMaxPayload}
+	conn.errs["dlq.products.1"] = []error{errors.New("nats: connection closed")}
+
+	// the rejected message stays in the spool until it is dead lettered.
+	err := p.DrainSpool()
+	if err == nil || len(conn.published) > 0 {
+		t.Fatalf("Publisher.DrainSpool() = %v %v, want the dead letter error", conn.published, err)
+	}
+	if spooled, _ := spool.Len(); spooled != 2 {
+		t.Errorf("Publisher.DrainSpool() left %v spooled messages, want 2", spooled)
+	}
+
+	err = p.DrainSpool()
+	if err != nil {
+		t.Fatalf("Publisher.DrainSpool() error = %v", err)
+	}
+	if want := []string{"dlq.products.1", "products.2"}; !reflect.DeepEqual(conn.published, want) {
+		t.Errorf("Publisher.DrainSpool() published = %v, want %v", conn.published, want)
+	}
+	if got := testutil.ToFloat64(metrics.publishes.WithLabelValues("products.1", OutcomeDeadLettered)); got != 1 {
+		t.Errorf("dead lettered publishes = %v, want 1", got)
+	}
+}
+
+func TestPublisher_FlushTimeout(t *testing.T) {
+	rejectedMsg := &nats.Msg{Subject: "products.created", Data: []byte("{}")}
+	rejected := stream.RejectedError{{Msg: rejectedMsg, Err: errors.New("nats: maximum messages exceeded")}}
+	tests := []struct {
+		name          string
+		flushErrs     []error
+		deadLetterErr error
+		wantPublished []string
+		wantErr       bool
+	}{
+		{name: "flushed"},
+		{name: "flush error", flushErrs: []error{nats.ErrTimeout}, wantErr: true},
+		{name: "dead lettered when jetstream rejects", flushErrs: []error{rejected}, wantPublished: []string{"dlq.products.created"}},
+		{
+			name: "dead lettering fails", flushErrs: []error{rejected}, deadLetterErr: errors.New("nats: connection closed"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeConn{connected: true, errs: map[string][]error{}, flushErrs: tt.flushErrs}
+			if tt.deadLetterErr != nil {
+				conn.errs["dlq.products.created"] = []error{tt.deadLetterErr}
+			}
+			p, _, metrics := newTestPublisher(t, conn)
+			err := p.FlushTimeout(time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Publisher.FlushTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(conn.published, tt.wantPublished) {
+				t.Errorf("Publisher.FlushTimeout() published = %v, want %v", conn.published, tt.wantPublished)
+			}
+			want := float64(len(tt.wantPublished))
+			if got := testutil.ToFloat64(metrics.publishes.WithLabelValues("products.created", OutcomeDeadLettered)); got != want {
+				t.Errorf("dead lettered publishes = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPublisher_deadLetter(t *testing.T) {
+	header := nats.Header{nats.MsgIdHdr: {"outbox-1"}}
+	tests := []struct {
+		name      string
+		rejectErr error
+		want      DeadLetter
+	}{
+		{
+			name:      "max payload",
+			rejectErr: nats.ErrMaxPayload,
+			want:      DeadLetter{Subject: "products.created", MsgID: "outbox-1", Error: nats.ErrMaxPayload.Error()},
+		},
+		{
+			name:      "headers not supported",
+			rejectErr: nats.ErrHeadersNotSupported,
+			want: DeadLetter{
+				Subject: "products.created", MsgID: "outbox-1", Error: nats.ErrHeadersNotSupported.Error(), Data: []byte("{}"),
+			},
+		},
+		{
+			name:      "invalid message",
+			rejectErr: nats.ErrInvalidMsg,
+			want: DeadLetter{
+				Subject: "products.created", MsgID: "outbox-1", Error: nats.ErrInvalidMsg.Error(), Header: header, Data: []byte("{}"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeConn{connected: true}
+			p, _, _ := newTestPublisher(t, conn)
+			err := p.deadLetter(&nats.Msg{Subject: "products.created", Header: header, Data: []byte("{}")}, tt.rejectErr)
+			if err != nil || len(conn.msgs) != 1 {
+				t.Fatalf("Publisher.deadLetter() error = %v, published %v", err, conn.published)
+			}
+			if conn.msgs[0].Subject != "dlq.products.created" || conn.msgs[0].Header != nil {
+				t.Errorf("Publisher.deadLetter() published %s with header %v, want dlq.products.created without header",
+					conn.msgs[0].Subject, conn.msgs[0].Header)
+			}
+			var got DeadLetter
+			err = json.Unmarshal(conn.msgs[0].Data, &got)
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Publisher.deadLetter() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpool(t *testing.T) {
+	spool, err := NewSpool(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSpool() error = %v", err)
+	}
+	msgs := []*nats.Msg{
+		{Subject: "products.1", Header: nats.Header{nats.MsgIdHdr: {"outbox-1"}}, Data: []byte("one")},
+		{Subject: "products.2", Data: []byte("two")},
+		{Subject: "products.3", Data: []byte("three")},
+	}
+	for _, msg := range msgs {
+		err = spool.Add(msg)
+		if err != nil {
+			t.Fatalf("Spool.Add() error = %v", err)
+		}
+	}
+	entries, err := spool.Pending(2)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Spool.Pending() = %v, err = %v, want 2 entries", entries, err)
+	}
+	for i, entry := range entries {
+		if !reflect.DeepEqual(entry.Msg, msgs[i]) {
+			t.Errorf("Spool.Pending()[%d] = %v, want %v", i, entry.Msg, msgs[i])
+		}
+	}
+	err = spool.Remove(entries)
+	if err != nil {
+		t.Fatalf("Spool.Remove() error = %v", err)
+	}
+	entries, err = spool.Pending(2)
+	if err != nil || len(entries) != 1 || entries[0].Msg.Subject != "products.3" {
+		t.Errorf("Spool.Pending() after Remove() = %v, err = %v, want products.3", entries, err)
+	}
+}
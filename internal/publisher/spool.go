@@ -0,0 +1,166 @@
+package publisher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const spoolFileExt = ".msg"
+
+// Spool stores the messages that could not be published in a directory,
+// one file per message, so that they are not lost when the service
+// restarts before nats is reachable again.
+type Spool struct {
+	dir string
+
+	mu  sync.Mutex
+	seq uint64
+	// ids are the Nats-Msg-Id of the spooled messages.
+	ids map[string]bool
+}
+
+// spooledMsg is the file content of a spooled message.
+type spooledMsg struct {
+	Subject string      `json:"subject"`
+	Header  nats.Header `json:"header,omitempty"`
+	Data    []byte      `json:"data"`
+}
+
+// SpoolEntry is a message read from the spool.
+type SpoolEntry struct {
+	name string
+	Msg  *nats.Msg
+}
+
+// NewSpool returns a new spool object that stores messages in dir, the
+// directory is created when it does not exist.
+func NewSpool(dir string) (*Spool, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spool{dir: dir, ids: map[string]bool{}}
+	names, err := s.names()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.Pending(len(names))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if id := entry.Msg.Header.Get(nats.MsgIdHdr); id != "" {
+			s.ids[id] = true
+		}
+	}
+	return s, nil
+}
+
+// Has reports whether a message with the provided Nats-Msg-Id is spooled.
+func (s *Spool) Has(msgID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ids[msgID]
+}
+
+// Add stores msg in the spool. The file is written under a temporary name
+// and renamed so that a crash never leaves a partial message behind, file
+// names sort in the order messages were added.
+func (s *Spool) Add(msg *nats.Msg) error {
+	data, err := json.Marshal(spooledMsg{Subject: msg.Subject, Header: msg.Header, Data: msg.Data})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.seq++
+	name := fmt.Sprintf("%020d-%010d%s", time.Now().UnixNano(), s.seq, spoolFileExt)
+	s.mu.Unlock()
+	tmp := filepath.Join(s.dir, name+".tmp")
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp, filepath.Join(s.dir, name))
+	if err != nil {
+		return err
+	}
+	if id := msg.Header.Get(nats.MsgIdHdr); id != "" {
+		s.mu.Lock()
+		s.ids[id] = true
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Pending returns at most limit spooled messages, oldest first.
+func (s *Spool) Pending(limit int) ([]*SpoolEntry, error) {
+	names, err := s.names()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) > limit {
+		names = names[:limit]
+	}
+	entries := make([]*SpoolEntry, 0, len(names))
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var msg spooledMsg
+		err = json.Unmarshal(data, &msg)
+		if err != nil {
+			return nil, fmt.Errorf("decoding spooled message %s: %w", name, err)
+		}
+		entries = append(entries, &SpoolEntry{
+			name: name,
+			Msg:  &nats.Msg{Subject: msg.Subject, Header: msg.Header, Data: msg.Data},
+		})
+	}
+	return entries, nil
+}
+
+// Remove deletes entries from the spool once they have been published.
+func (s *Spool) Remove(entries []*SpoolEntry) error {
+	for _, entry := range entries {
+		err := os.Remove(filepath.Join(s.dir, entry.name))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		s.mu.Lock()
+		delete(s.ids, entry.Msg.Header.Get(nats.MsgIdHdr))
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Len returns the number of spooled messages.
+func (s *Spool) Len() (int, error) {
+	names, err := s.names()
+	return len(names), err
+}
+
+// names returns the file names of the spooled messages in order.
+func (s *Spool) names() ([]string, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), spoolFileExt) {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,80 @@
+// Package stream persists the events published by the product service in a
+// jetstream stream, so that subscribers that were offline do not lose
+// events and projections can be rebuilt by replaying the stream.
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Config is the configuration of the events stream.
+type Config struct {
+	Name      string
+	Subjects  []string
+	Retention nats.RetentionPolicy
+	// MaxAge, MaxMsgs and MaxBytes limit how many events the stream keeps,
+	// zero means no limit.
+	MaxAge   time.Duration
+	MaxMsgs  int64
+	MaxBytes int64
+	// DuplicateWindow is how long jetstream remembers the Nats-Msg-Id of
+	// published messages to drop duplicates.
+	DuplicateWindow time.Duration
+	Replicas        int
+}
+
+var retentionPolicies = map[string]nats.RetentionPolicy{
+	"limits":    nats.LimitsPolicy,
+	"interest":  nats.InterestPolicy,
+	"workqueue": nats.WorkQueuePolicy,
+}
+
+// ParseRetention returns the retention policy with the provided name, one
+// of limits, interest or workqueue.
+func ParseRetention(name string) (nats.RetentionPolicy, error) {
+	retention, ok := retentionPolicies[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown stream retention policy %q, expected limits, interest or workqueue", name)
+	}
+	return retention, nil
+}
+
+// EnsureStream creates the stream described by cfg or updates it to cfg
+// when it already exists.
+func EnsureStream(js nats.JetStreamManager, cfg Config) (*nats.StreamInfo, error) {
+	if cfg.Name == "" || len(cfg.Subjects) == 0 {
+		return nil, errors.New("stream name and subjects are required")
+	}
+	streamConfig := &nats.StreamConfig{
+		Name:       cfg.Name,
+		Subjects:   cfg.Subjects,
+		Retention:  cfg.Retention,
+		MaxAge:     cfg.MaxAge,
+		MaxMsgs:    limit(cfg.MaxMsgs),
+		MaxBytes:   limit(cfg.MaxBytes),
+		Duplicates: cfg.DuplicateWindow,
+		Replicas:   cfg.Replicas,
+		Storage:    nats.FileStorage,
+	}
+	_, err := js.StreamInfo(cfg.Name)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		return js.AddStream(streamConfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return js.UpdateStream(streamConfig)
+}
+
+// limit returns the jetstream value of a message or byte limit, jetstream
+// uses -1 for no limit.
+func limit(value int64) int64 {
+	if value <= 0 {
+		return -1
+	}
+	return value
+}
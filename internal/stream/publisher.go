@@ -0,0 +1,148 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Publisher publishes messages on the subjects of the stream to jetstream
+// and other messages to core nats, it implements outbox.Publisher.
+type Publisher struct {
+	conn     *nats.Conn
+	js       nats.JetStreamContext
+	subjects []string
+
+	mu      sync.Mutex
+	pending []nats.PubAckFuture
+}
+
+// NewPublisher returns a new stream publisher object, subjects are the
+// subjects of the stream.
+func NewPublisher(conn *nats.Conn, js nats.JetStreamContext, subjects []string) *Publisher {
+	return &Publisher{
+		conn:     conn,
+		js:       js,
+		subjects: subjects,
+	}
+}
+
+// PublishMsg publishes msg without waiting for jetstream to acknowledge
+// it, FlushTimeout waits for the acknowledgements.
+func (p *Publisher) PublishMsg(msg *nats.Msg) error {
+	if !p.captured(msg.Subject) {
+		return p.conn.PublishMsg(msg)
+	}
+	future, err := p.js.PublishMsgAsync(msg)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.pending = append(p.pending, future)
+	p.mu.Unlock()
+	return nil
+}
+
+// RejectedMsg is a message jetstream rejected with Err.
+type RejectedMsg struct {
+	Msg *nats.Msg
+	Err error
+}
+
+// RejectedError is returned by FlushTimeout when jetstream rejected
+// messages because of the messages themselves, e.g they exceed the max
+// message size of the stream, so publishing them again cannot succeed. The
+// other messages were acknowledged.
+type RejectedError []RejectedMsg
+
+func (e RejectedError) Error() string {
+	return fmt.Sprintf("jetstream rejected %d messages, %s: %v", len(e), e[0].Msg.Subject, e[0].Err)
+}
+
+// FlushTimeout flushes the core nats messages and waits until jetstream
+// has acknowledged every message published since the last flush. The
+// messages jetstream rejected are returned as a RejectedError once every
+// other message was acknowledged.
+func (p *Publisher) FlushTimeout(timeout time.Duration) error {
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var rejected RejectedError
+	for _, future := range pending {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			if !isRejected(err) {
+				return fmt.Errorf("publishing %s to jetstream: %w", future.Msg().Subject, err)
+			}
+			if strings.Contains(err.Error(), "size exceeds maximum allowed") {
+				err = fmt.Errorf("%w: %v", nats.ErrMaxPayload, err)
+			}
+			rejected = append(rejected, RejectedMsg{Msg: future.Msg(), Err: err})
+		case <-timer.C:
+			return nats.ErrTimeout
+		}
+	}
+	err := p.conn.FlushTimeout(timeout)
+	if err != nil {
+		return err
+	}
+	if len(rejected) > 0 {
+		return rejected
+	}
+	return nil
+}
+
+// isRejected reports whether err is the error of a jetstream api
+// rejection of a message, rather than of jetstream being unreachable or
+// temporarily unavailable, in which case publishing again can succeed.
+func isRejected(err error) bool {
+	switch {
+	case errors.Is(err, nats.ErrNoResponders),
+		errors.Is(err, nats.ErrInvalidJSAck),
+		errors.Is(err, nats.ErrTimeout),
+		errors.Is(err, nats.ErrConnectionClosed),
+		errors.Is(err, nats.ErrDisconnected):
+		return false
+	}
+	description := strings.ToLower(err.Error())
+	return !strings.Contains(description, "unavailable") && !strings.Contains(description, "insufficient resources")
+}
+
+// IsConnected reports whether the nats connection is connected.
+func (p *Publisher) IsConnected() bool {
+	return p.conn.IsConnected()
+}
+
+func (p *Publisher) captured(subject string) bool {
+	for _, pattern := range p.subjects {
+		if SubjectMatches(pattern, subject) {
+			return true
+		}
+	}
+	return false
+}
+
+// SubjectMatches reports whether subject matches the subject pattern, the
+// pattern can use the * and > nats wildcards.
+func SubjectMatches(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) || (token != "*" && token != subjectTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
@@ -0,0 +1,76 @@
+package stream
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ReplayOptions are the options of a stream replay, the replay starts at
+// StartSequence, else at StartTime, else at the first message.
+type ReplayOptions struct {
+	// Subject filters the replayed messages, it can use wildcards.
+	Subject       string
+	StartSequence uint64
+	StartTime     time.Time
+}
+
+// Replay calls handle in order with the messages of stream selected by
+// opts. It returns the number of handled messages once the messages that
+// were in the stream when it was called have been handled, or when handle
+// returns an error.
+func Replay(ctx context.Context, js nats.JetStreamContext, stream string, opts ReplayOptions, handle func(msg *nats.Msg) error) (int, error) {
+	info, err := js.StreamInfo(stream)
+	if err != nil {
+		return 0, err
+	}
+	lastSeq := info.State.LastSeq
+	if lastSeq == 0 || opts.StartSequence > lastSeq {
+		return 0, nil
+	}
+
+	subOpts := []nats.SubOpt{nats.BindStream(stream), nats.OrderedConsumer()}
+	switch {
+	case opts.StartSequence > 0:
+		subOpts = append(subOpts, nats.StartSequence(opts.StartSequence))
+	case !opts.StartTime.IsZero():
+		subOpts = append(subOpts, nats.StartTime(opts.StartTime))
+	default:
+		subOpts = append(subOpts, nats.DeliverAll())
+	}
+	sub, err := js.SubscribeSync(opts.Subject, subOpts...)
+	if err != nil {
+		return 0, err
+	}
+	defer sub.Unsubscribe()
+
+	consumerInfo, err := sub.ConsumerInfo()
+	if err != nil {
+		return 0, err
+	}
+	// the consumer starts delivering when it is created, no pending and no
+	// delivered messages means no message matched.
+	if consumerInfo.NumPending == 0 && consumerInfo.Delivered.Consumer == 0 {
+		return 0, nil
+	}
+	replayed := 0
+	for {
+		msg, err := sub.NextMsgWithContext(ctx)
+		if err != nil {
+			return replayed, err
+		}
+		meta, err := msg.Metadata()
+		if err != nil {
+			return replayed, err
+		}
+		err = handle(msg)
+		if err != nil {
+			return replayed, err
+		}
+		replayed++
+		if meta.NumPending == 0 || meta.Sequence.Stream >= lastSeq {
+			return replayed, nil
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// runJetStream starts an embedded nats server with jetstream enabled and
+// returns a connection to it.
+func runJetStream(t *testing.T) (*nats.Conn, nats.JetStreamContext) {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("starting nats server: %v", err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server is not ready for connections")
+	}
+	t.Cleanup(s.Shutdown)
+
+	conn, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatalf("connecting to nats server: %v", err)
+	}
+	t.Cleanup(conn.Close)
+	js, err := conn.JetStream()
+	if err != nil {
+		t.Fatalf("getting jetstream context: %v", err)
+	}
+	return conn, js
+}
+
+var testConfig = Config{
+	Name:            "PRODUCT_EVENTS",
+	Subjects:        []string{"products.v1.>"},
+	Retention:       nats.LimitsPolicy,
+	MaxAge:          time.Hour,
+	DuplicateWindow: time.Minute,
+}
+
+func TestSubjectMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		subject string
+		want    bool
+	}{
+		{pattern: "products.v1.created", subject: "products.v1.created", want: true},
+		{pattern: "products.v1.created", subject: "products.v1.updated", want: false},
+		{pattern: "products.*.created", subject: "products.v1.created", want: true},
+		{pattern: "products.*", subject: "products.v1.created", want: false},
+		{pattern: "products.>", subject: "products.v1.created", want: true},
+		{pattern: "products.>", subject: "products", want: false},
+		{pattern: "products.v1.>", subject: "products.ProductDeleted", want: false},
+		{pattern: "products.v1", subject: "products.v1.created", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.subject, func(t *testing.T) {
+			if got := SubjectMatches(tt.pattern, tt.subject); got != tt.want {
+				t.Errorf("SubjectMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    nats.RetentionPolicy
+		wantErr bool
+	}{
+		{name: "limits", want: nats.LimitsPolicy},
+		{name: "interest", want: nats.InterestPolicy},
+		{name: "workqueue", want: nats.WorkQueuePolicy},
+		{name: "forever", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRetention(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRetention() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseRetention() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureStream(t *testing.T) {
+	_, js := runJetStream(t)
+
+	info, err := EnsureStream(js, testConfig)
+	if err != nil {
+		t.Fatalf("EnsureStream() error = %v", err)
+	}
+	if info.Config.MaxAge != time.Hour || info.Config.MaxMsgs != -1 {
+		t.Errorf("EnsureStream() config = %+v", info.Config)
+	}
+
+	updated := testConfig
+	updated.MaxAge = 24 * time.Hour
+	updated.MaxMsgs = 1000
+	info, err = EnsureStream(js, updated)
+	if err != nil {
+		t.Fatalf("EnsureStream() update error = %v", err)
+	}
+	if info.Config.MaxAge != 24*time.Hour || info.Config.MaxMsgs != 1000 {
+		t.Errorf("EnsureStream() updated config = %+v", info.Config)
+	}
+
+	_, err = EnsureStream(js, Config{Name: "EMPTY"})
+	if err == nil {
+		t.Error("EnsureStream() without subjects error = nil, want error")
+	}
+}
+
+func TestPublisher_PublishMsg(t *testing.T) {
+	conn, js := runJetStream(t)
+	_, err := EnsureStream(js, testConfig)
+	if err != nil {
+		t.Fatalf("EnsureStream() error = %v", err)
+	}
+	coreSub, err := conn.SubscribeSync("products.ProductDeleted")
+	if err != nil {
+		t.Fatalf("subscribing: %v", err)
+	}
+
+	p := NewPublisher(conn, js, testConfig.Subjects)
+	msgs := []*nats.Msg{
+		{Subject: "products.v1.created", Header: nats.Header{nats.MsgIdHdr: []string{"outbox-1"}}, Data: []byte("1")},
+		// retried publish of the same outbox message.
+		{Subject: "products.v1.created", Header: nats.Header{nats.MsgIdHdr: []string{"outbox-1"}}, Data: []byte("1")},
+		{Subject: "products.v1.updated", Header: nats.Header{nats.MsgIdHdr: []string{"outbox-2"}}, Data: []byte("2")},
+		{Subject: "products.ProductDeleted", Header: nats.Header{nats.MsgIdHdr: []string{"outbox-3"}}, Data: []byte("3")},
+	}
+	for _, msg := range msgs {
+		err := p.PublishMsg(msg)
+		if err != nil {
+			t.Fatalf("Publisher.PublishMsg() error = %v", err)
+		}
+	}
+	err = p.FlushTimeout(5 * time.Second)
+	if err != nil {
+		t.Fatalf("Publisher.FlushTimeout() error = %v", err)
+	}
+
+	info, err := js.StreamInfo(testConfig.Name)
+	if err != nil {
+		t.Fatalf("getting stream info: %v", err)
+	}
+	if info.State.Msgs != 2 {
+		t.Errorf("stream messages = %v, want %v", info.State.Msgs, 2)
+	}
+	msg, err := coreSub.NextMsg(time.Second)
+	if err != nil || string(msg.Data) != "3" {
+		t.Errorf("core nats message = %v, %v, want %q", msg, err, "3")
+	}
+}
+
+func TestPublisher_FlushTimeout_Rejected(t *testing.T) {
+	conn, js := runJetStream(t)
+	_, err := js.AddStream(&nats.StreamConfig{Name: testConfig.Name, Subjects: testConfig.Subjects, MaxMsgSize: 16})
+	if err != nil {
+		t.Fatalf("adding stream: %v", err)
+	}
+
+	p := NewPublisher(conn, js, testConfig.Subjects)
+	msgs := []*nats.Msg{
+		{Subject: "products.v1.created", Data: []byte("a payload larger than the stream allows")},
+		{Subject: "products.v1.updated", Data: []byte("2")},
+	}
+	for _, msg := range msgs {
+		err := p.PublishMsg(msg)
+		if err != nil {
+			t.Fatalf("Publisher.PublishMsg() error = %v", err)
+		}
+	}
+	err = p.FlushTimeout(5 * time.Second)
+	var rejected RejectedError
+	if !errors.As(err, &rejected) || len(rejected) != 1 || rejected[0].Msg.Subject != "products.v1.created" {
+		t.Fatalf("Publisher.FlushTimeout() error = %v, want products.v1.created rejected", err)
+	}
+	if !errors.Is(rejected[0].Err, nats.ErrMaxPayload) {
+		t.Errorf("Publisher.FlushTimeout() rejected with %v, want %v", rejected[0].Err, nats.ErrMaxPayload)
+	}
+	info, err := js.StreamInfo(testConfig.Name)
+	if err != nil {
+		t.Fatalf("getting stream info: %v", err)
+	}
+	if info.State.Msgs != 1 {
+		t.Errorf("stream messages = %v, want %v", info.State.Msgs, 1)
+	}
+}
+
+func Test_isRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "api error", err: errors.New("nats: maximum messages exceeded"), want: true},
+		{name: "no responders", err: nats.ErrNoResponders, want: false},
+		{name: "invalid ack", err: nats.ErrInvalidJSAck, want: false},
+		{name: "temporarily unavailable", err: errors.New("nats: JetStream system temporarily unavailable"), want: false},
+		{name: "insufficient resources", err: errors.New("nats: insufficient resources"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRejected(tt.err); got != tt.want {
+				t.Errorf("isRejected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	_, js := runJetStream(t)
+	_, err := EnsureStream(js, testConfig)
+	if err != nil {
+		t.Fatalf("EnsureStream() error = %v", err)
+	}
+	subjects := []string{"products.v1.created", "products.v1.updated", "products.v1.created", "products.v1.deleted"}
+	for i, subject := range subjects {
+		_, err := js.Publish(subject, []byte{byte('1' + i)})
+		if err != nil {
+			t.Fatalf("publishing: %v", err)
+		}
+	}
+	afterPublish := time.Now()
+
+	tests := []struct {
+		name    string
+		opts    ReplayOptions
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "whole stream",
+			want: []string{"products.v1.created 1", "products.v1.updated 2", "products.v1.created 3", "products.v1.deleted 4"},
+		},
+		{
+			name: "from sequence",
+			opts: ReplayOptions{StartSequence: 3},
+			want: []string{"products.v1.created 3", "products.v1.deleted 4"},
+		},
+		{
+			name: "filtered by subject",
+			opts: ReplayOptions{Subject: "products.v1.created"},
+			want: []string{"products.v1.created 1", "products.v1.created 3"},
+		},
+		{
+			name: "from time after the last message",
+			opts: ReplayOptions{StartTime: afterPublish},
+		},
+		{
+			name: "from sequence after the last message",
+			opts: ReplayOptions{StartSequence: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			n, err := Replay(ctx, js, testConfig.Name, tt.opts, func(msg *nats.Msg) error {
+				got = append(got, msg.Subject+" "+string(msg.Data))
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Replay() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if n != len(tt.want) || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Replay() = %v %v, want %v", n, got, tt.want)
+			}
+		})
+	}
+}
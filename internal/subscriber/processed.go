@@ -0,0 +1,104 @@
+package subscriber
+
+import (
+	"context"
+	"time"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProcessedMessage records that a consumer handled a message, so that the
+// message is not handled again when it is delivered more than once.
+type ProcessedMessage struct {
+	Consumer    string    `gorm:"primaryKey;size:100"`
+	MessageID   string    `gorm:"primaryKey;size:255"`
+	ProcessedAt time.Time `gorm:"index"`
+}
+
+// ProcessedMessageRepository is the interface that describes a processed
+// message repository object.
+type ProcessedMessageRepository interface {
+	IsProcessed(ctx context.Context, consumer, messageID string, since time.Time) (bool, error)
+	MarkProcessed(ctx context.Context, consumer, messageID string) error
+	DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ProcessedMessageRepo is the default implementation for
+// ProcessedMessageRepository interface.
+type ProcessedMessageRepo struct {
+	db     *gorm.DB
+	tracer trace.Tracer
+}
+
+// NewRepository returns a new processed message repository object.
+func NewRepository(db *gorm.DB, tracer trace.Tracer) *ProcessedMessageRepo {
+	return &ProcessedMessageRepo{
+		db:     db,
+		tracer: tracer,
+	}
+}
+
+func (r *ProcessedMessageRepo) setMySqlComponentTags(span trace.Span, tableName string) {
+	span.SetAttributes(semconv.DBSystemMySQL, semconv.DBSQLTableKey.String(tableName))
+}
+
+// IsProcessed reports whether consumer handled the message with the
+// provided id after since.
+func (r *ProcessedMessageRepo) IsProcessed(ctx context.Context, consumer, messageID string, since time.Time) (bool, error) {
+	_, span := r.tracer.Start(ctx, "IsProcessed", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "processed_messages")
+	tracing.SetAttribute(span, "param.consumer", consumer)
+	tracing.SetAttribute(span, "param.messageID", messageID)
+
+	var count int64
+	err := r.db.Model(&ProcessedMessage{}).
+		Where("consumer = ? AND message_id = ? AND processed_at > ?", consumer, messageID, since).Count(&count).Error
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Where.Count")
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// MarkProcessed records that consumer handled the message with the
+// provided id, the time of a message handled again is updated.
+func (r *ProcessedMessageRepo) MarkProcessed(ctx context.Context, consumer, messageID string) error {
+	_, span := r.tracer.Start(ctx, "MarkProcessed", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "processed_messages")
+	tracing.SetAttribute(span, "param.consumer", consumer)
+	tracing.SetAttribute(span, "param.messageID", messageID)
+
+	err := r.db.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"processed_at"})}).Create(&ProcessedMessage{
+		Consumer:    consumer,
+		MessageID:   messageID,
+		ProcessedAt: time.Now(),
+	}).Error
+	if err != nil {
+		tracing.RecordError(span, err, "gorm.db.Create")
+		return err
+	}
+	return nil
+}
+
+// DeleteProcessedBefore deletes the messages processed before before, it
+// returns the number of deleted messages.
+func (r *ProcessedMessageRepo) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	_, span := r.tracer.Start(ctx, "DeleteProcessedBefore", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	r.setMySqlComponentTags(span, "processed_messages")
+	tracing.SetAttribute(span, "param.before", before)
+
+	result := r.db.Where("processed_at < ?", before).Delete(&ProcessedMessage{})
+	if result.Error != nil {
+		tracing.RecordError(span, result.Error, "gorm.db.Where.Delete")
+		return 0, result.Error
+	}
+	tracing.SetAttribute(span, "response.deleted", result.RowsAffected)
+	return result.RowsAffected, nil
+}
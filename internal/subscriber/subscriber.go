@@ -0,0 +1,269 @@
+// Package subscriber consumes the nats messages other services publish, a
+// consumer does not handle the same message twice, handling is retried
+// when it fails and the message is moved to a dead letter subject when
+// retrying does not help.
+package subscriber
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Handler handles a message received on a subscribed subject, the span
+// of the message is in ctx and the trace context is removed from the
+// message data.
+type Handler func(ctx context.Context, msg *nats.Msg) error
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as an error that retrying the message cannot fix,
+// e.g a malformed message, the message is moved to the dead letter subject
+// without being retried.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Headers added to the messages moved to the dead letter subject.
+const (
+	HeaderDeadLetterConsumer = "Dead-Letter-Consumer"
+	HeaderDeadLetterError    = "Dead-Letter-Error"
+	HeaderDeadLetterAttempts = "Dead-Letter-Attempts"
+)
+
+// drainPollInterval is how often Close checks whether the subscriptions
+// are drained.
+const drainPollInterval = 10 * time.Millisecond
+
+// Config is the configuration of a subscriber.
+type Config struct {
+	// Queue is the queue group of the subscriptions, every message is
+	// delivered to a single replica of the service.
+	Queue string
+	// DeadLetterPrefix prefixes the subject of the messages that could
+	// not be handled, e.g dlq.product-service.user.deleted.
+	DeadLetterPrefix string
+	// MaxAttempts is how many times a message is handled before it is
+	// moved to the dead letter subject.
+	MaxAttempts int
+	// RetryDelay is the delay before the first retry, it doubles after
+	// every attempt.
+	RetryDelay time.Duration
+	// HashDedupWindow is how long a message without a Nats-Msg-Id is not
+	// handled again when a message with the same data is received, a
+	// message repeated after it is handled again.
+	HashDedupWindow time.Duration
+	// ProcessedRetention is how long processed messages are kept, it must
+	// be longer than redeliveries of a message can happen.
+	ProcessedRetention time.Duration
+	// LegacyTrace enables reading the span context of the not.TraceMsg
+	// binary format from the message data, for publishers that do not
+	// send the traceparent header yet.
+	LegacyTrace bool
+}
+
+// Subscriber subscribes handlers to nats subjects.
+type Subscriber struct {
+	conn          *nats.Conn
+	processedRepo ProcessedMessageRepository
+	tracer        trace.Tracer
+	config        Config
+
+	mu   sync.Mutex
+	subs []*nats.Subscription
+	// retries are the scheduled retries of failed messages.
+	retries sync.WaitGroup
+}
+
+// NewSubscriber returns a new subscriber object.
+func NewSubscriber(conn *nats.Conn, processedRepo ProcessedMessageRepository, tracer trace.Tracer, config Config) *Subscriber {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	return &Subscriber{
+		conn:          conn,
+		processedRepo: processedRepo,
+		tracer:        tracer,
+		config:        config,
+	}
+}
+
+// Handle subscribes handler to subject, consumer identifies the handler
+// in the processed messages and dead letters.
+func (s *Subscriber) Handle(consumer, subject string, handler Handler) error {
+	sub, err := s.conn.QueueSubscribe(subject, s.config.Queue, func(msg *nats.Msg) {
+		s.handle(consumer, msg, handler, 1)
+	})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.subs = append(s.subs, sub)
+	s.mu.Unlock()
+	return nil
+}
+
+// Close drains the subscriptions, no more messages are received and the
+// messages that were already received are still handled, including their
+// scheduled retries. It waits until they are handled or ctx is done.
+func (s *Subscriber) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subs {
+		err := sub.Drain()
+		if err != nil {
+			return err
+		}
+	}
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for _, sub := range s.subs {
+		// a drained subscription is closed once its pending messages
+		// were handled.
+		for sub.IsValid() {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+	retried := make(chan struct{})
+	go func() {
+		s.retries.Wait()
+		close(retried)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-retried:
+	}
+	s.subs = nil
+	return nil
+}
+
+// handle handles msg once, a failed attempt is retried after a backoff
+// from a timer so that the nats callback returns right away and does not
+// hold back the next messages of the subscription.
+func (s *Subscriber) handle(consumer string, msg *nats.Msg, handler Handler, attempt int) {
+	ctx, data := tracing.ExtractMsg(context.Background(), msg, s.config.LegacyTrace)
+	ctx, span := s.tracer.Start(ctx, "consume "+msg.Subject, trace.WithSpanKind(trace.SpanKindConsumer))
+	defer span.End()
+	span.SetAttributes(semconv.MessagingDestinationKey.String(msg.Subject))
+	tracing.SetAttribute(span, "consumer", consumer)
+	tracing.SetAttribute(span, "message.attempt", attempt)
+
+	handlerMsg := &nats.Msg{Subject: msg.Subject, Reply: msg.Reply, Header: msg.Header, Data: data}
+	messageID := MessageID(handlerMsg)
+	tracing.SetAttribute(span, "message.id", messageID)
+	// a message without a producer id is a duplicate only within the
+	// window, the same data can be a new event later.
+	var since time.Time
+	if handlerMsg.Header.Get(nats.MsgIdHdr) == "" {
+		since = time.Now().Add(-s.config.HashDedupWindow)
+	}
+	processed, err := s.processedRepo.IsProcessed(ctx, consumer, messageID, since)
+	if err != nil {
+		// handling a message twice is better than not handling it.
+		span.RecordError(err, trace.WithAttributes(attribute.String("event", "checking processed message")))
+	}
+	if processed {
+		span.AddEvent("skipping processed message")
+		return
+	}
+
+	err = handler(ctx, handlerMsg)
+	if err != nil && !IsPermanent(err) && attempt < s.config.MaxAttempts {
+		tracing.RecordError(span, err, "handling message")
+		s.retry(s.config.RetryDelay<<(attempt-1), func() {
+			s.handle(consumer, msg, handler, attempt+1)
+		})
+		return
+	}
+	if err != nil {
+		tracing.RecordError(span, err, "handling message")
+		s.deadLetter(span, consumer, msg, attempt, err)
+		return
+	}
+	err = s.processedRepo.MarkProcessed(ctx, consumer, messageID)
+	if err != nil {
+		span.RecordError(err, trace.WithAttributes(attribute.String("event", "marking message processed")))
+	}
+}
+
+// retry calls retry after delay, Close waits for the scheduled retries.
+func (s *Subscriber) retry(delay time.Duration, retry func()) {
+	s.retries.Add(1)
+	time.AfterFunc(delay, func() {
+		defer s.retries.Done()
+		retry()
+	})
+}
+
+// RunCleanup deletes the processed messages older than ProcessedRetention
+// every interval until ctx is done.
+func (s *Subscriber) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// errors are recorded on the span and the cleanup is
+			// retried on the next tick.
+			s.processedRepo.DeleteProcessedBefore(ctx, time.Now().Add(-s.config.ProcessedRetention))
+		}
+	}
+}
+
+// deadLetter publishes msg unchanged to the dead letter subject with the
+// error that prevented handling it.
+func (s *Subscriber) deadLetter(span trace.Span, consumer string, msg *nats.Msg, attempts int, handleErr error) {
+	header := nats.Header{}
+	for key, values := range msg.Header {
+		header[key] = values
+	}
+	header.Set(HeaderDeadLetterConsumer, consumer)
+	header.Set(HeaderDeadLetterError, handleErr.Error())
+	header.Set(HeaderDeadLetterAttempts, strconv.Itoa(attempts))
+	subject := s.config.DeadLetterPrefix + "." + msg.Subject
+	err := s.conn.PublishMsg(&nats.Msg{Subject: subject, Header: header, Data: msg.Data})
+	if err != nil {
+		span.RecordError(err, trace.WithAttributes(attribute.String("event", "nats."+subject)))
+		return
+	}
+	tracing.AddEvent(span, "moved to dead letter subject", attribute.String("dead_letter.subject", subject))
+}
+
+// MessageID returns the id of msg used to handle it only once, it is the
+// Nats-Msg-Id header or else a hash of the message data, which only
+// identifies the message within Config.HashDedupWindow.
+func MessageID(msg *nats.Msg) string {
+	if id := msg.Header.Get(nats.MsgIdHdr); id != "" {
+		return id
+	}
+	sum := sha256.Sum256(msg.Data)
+	return "sha256-" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,241 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fakeProcessedRepo keeps the processing time of messages in memory, the
+// mocks package cannot be used here since it imports this package.
+type fakeProcessedRepo struct {
+	mu        sync.Mutex
+	processed map[string]time.Time
+}
+
+func (r *fakeProcessedRepo) IsProcessed(ctx context.Context, consumer, messageID string, since time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	processedAt, ok := r.processed[consumer+" "+messageID]
+	return ok && processedAt.After(since), nil
+}
+
+func (r *fakeProcessedRepo) MarkProcessed(ctx context.Context, consumer, messageID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processed[consumer+" "+messageID] = time.Now()
+	return nil
+}
+
+func (r *fakeProcessedRepo) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for key, processedAt := range r.processed {
+		if processedAt.Before(before) {
+			delete(r.processed, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// runNats starts an embedded nats server and returns a connection to it.
+func runNats(t *testing.T) *nats.Conn {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("starting nats server: %v", err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server is not ready for connections")
+	}
+	t.Cleanup(s.Shutdown)
+	conn, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatalf("connecting to nats server: %v", err)
+	}
+	t.Cleanup(conn.Close)
+	return conn
+}
+
+func TestSubscriber_Handle(t *testing.T) {
+	conn := runNats(t)
+	deadLetters, err := conn.SubscribeSync("dlq.test.>")
+	if err != nil {
+		t.Fatalf("subscribing: %v", err)
+	}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	handled := make(chan string, 20)
+	handler := func(ctx context.Context, msg *nats.Msg) error {
+		mu.Lock()
+		calls[string(msg.Data)]++
+		attempt := calls[string(msg.Data)]
+		mu.Unlock()
+		defer func() { handled <- string(msg.Data) }()
+		switch string(msg.Data) {
+		case `"flaky"`:
+			if attempt == 1 {
+				return errors.New("database is unavailable")
+			}
+		case `"poison"`:
+			return Permanent(errors.New("malformed event"))
+		case `"failing"`:
+			return errors.New("database is unavailable")
+		}
+		return nil
+	}
+	s := NewSubscriber(conn, &fakeProcessedRepo{processed: map[string]time.Time{}}, trace.NewNoopTracerProvider().Tracer(""), Config{
+		Queue: "test", DeadLetterPrefix: "dlq.test", MaxAttempts: 3, RetryDelay: 20 * time.Millisecond,
+	})
+	err = s.Handle("test-consumer", "user.deleted", handler)
+	if err != nil {
+		t.Fatalf("Subscriber.Handle() error = %v", err)
+	}
+	defer s.Close(context.Background())
+
+	publish := func(id, data string) {
+		err := conn.PublishMsg(&nats.Msg{Subject: "user.deleted", Header: nats.Header{nats.MsgIdHdr: {id}}, Data: []byte(data)})
+		if err != nil {
+			t.Fatalf("publishing: %v", err)
+		}
+	}
+	publish("1", `"ok"`)
+	publish("1", `"ok"`) // duplicate delivery.
+	publish("2", `"flaky"`)
+	publish("3", `"poison"`)
+	publish("4", `"failing"`)
+	publish("5", `"done"`)
+	// the retries do not hold back the next messages.
+	order := []string{}
+	for len(order) < 8 {
+		select {
+		case data := <-handled:
+			order = append(order, data)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for messages to be handled, handled %v", order)
+		}
+	}
+	if order[len(order)-1] != `"failing"` || order[4] != `"done"` {
+		t.Errorf("handled messages = %v, want the retries after the other messages", order)
+	}
+
+	mu.Lock()
+	wantCalls := map[string]int{`"ok"`: 1, `"flaky"`: 2, `"poison"`: 1, `"failing"`: 3, `"done"`: 1}
+	for data, want := range wantCalls {
+		if calls[data] != want {
+			t.Errorf("handler calls for %s = %v, want %v", data, calls[data], want)
+		}
+	}
+	mu.Unlock()
+
+	for _, want := range []struct{ data, attempts string }{{`"poison"`, "1"}, {`"failing"`, "3"}} {
+		msg, err := deadLetters.NextMsg(time.Second)
+		if err != nil {
+			t.Fatalf("waiting for dead letter: %v", err)
+		}
+		if msg.Subject != "dlq.test.user.deleted" || string(msg.Data) != want.data ||
+			msg.Header.Get(HeaderDeadLetterAttempts) != want.attempts ||
+			msg.Header.Get(HeaderDeadLetterConsumer) != "test-consumer" {
+			t.Errorf("dead letter = %v %s %v, want %s after %s attempts", msg.Subject, msg.Data, msg.Header, want.data, want.attempts)
+		}
+	}
+}
+
+func TestSubscriber_Close(t *testing.T) {
+	conn := runNats(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	var handled bool
+	s := NewSubscriber(conn, &fakeProcessedRepo{processed: map[string]time.Time{}}, trace.NewNoopTracerProvider().Tracer(""), Config{Queue: "test"})
+	err := s.Handle("test-consumer", "order.placed", func(ctx context.Context, msg *nats.Msg) error {
+		close(started)
+		<-release
+		handled = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscriber.Handle() error = %v", err)
+	}
+	err = conn.Publish("order.placed", []byte("{}"))
+	if err != nil {
+		t.Fatalf("publishing: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = s.Close(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Subscriber.Close() error = %v, want %v while the message is handled", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	err = s.Close(context.Background())
+	if err != nil || !handled {
+		t.Errorf("Subscriber.Close() error = %v, handled = %v, want the message handled", err, handled)
+	}
+}
+
+func TestSubscriber_Handle_HashDedupWindow(t *testing.T) {
+	conn := runNats(t)
+	handled := make(chan struct{}, 10)
+	s := NewSubscriber(conn, &fakeProcessedRepo{processed: map[string]time.Time{}}, trace.NewNoopTracerProvider().Tracer(""), Config{
+		Queue: "test", HashDedupWindow: 100 * time.Millisecond,
+	})
+	err := s.Handle("test-consumer", "order.placed", func(ctx context.Context, msg *nats.Msg) error {
+		handled <- struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscriber.Handle() error = %v", err)
+	}
+	defer s.Close(context.Background())
+	publish := func() {
+		err := conn.Publish("order.placed", []byte(`{"orderId":"1"}`))
+		if err == nil {
+			err = conn.Flush()
+		}
+		if err != nil {
+			t.Fatalf("publishing: %v", err)
+		}
+	}
+	waitHandled := func(want bool) {
+		select {
+		case <-handled:
+			if !want {
+				t.Error("message without id handled again within the dedup window")
+			}
+		case <-time.After(50 * time.Millisecond):
+			if want {
+				t.Error("message without id not handled, want it handled")
+			}
+		}
+	}
+	publish()
+	waitHandled(true)
+	publish()
+	waitHandled(false)
+	time.Sleep(100 * time.Millisecond)
+	publish()
+	waitHandled(true)
+}
+
+func TestMessageID(t *testing.T) {
+	withHeader := &nats.Msg{Header: nats.Header{nats.MsgIdHdr: {"outbox-1"}}, Data: []byte("{}")}
+	if got := MessageID(withHeader); got != "outbox-1" {
+		t.Errorf("MessageID() = %v, want %v", got, "outbox-1")
+	}
+	first, second := MessageID(&nats.Msg{Data: []byte(`{"userId":"1"}`)}), MessageID(&nats.Msg{Data: []byte(`{"userId":"1"}`)})
+	if first != second || first == MessageID(&nats.Msg{Data: []byte(`{"userId":"2"}`)}) {
+		t.Errorf("MessageID() without header = %v %v, want the same id for the same data only", first, second)
+	}
+}
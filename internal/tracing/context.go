@@ -0,0 +1,41 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceParentHeader = "traceparent"
+
+// TraceParent returns the W3C traceparent of sc, it is empty when sc is
+// not valid.
+func TraceParent(sc trace.SpanContext) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(trace.ContextWithSpanContext(context.Background(), sc), carrier)
+	return carrier.Get(traceParentHeader)
+}
+
+// MarshalSpanContext returns sc in the format stored with outbox messages,
+// the W3C traceparent. It is nil when sc is not valid.
+func MarshalSpanContext(sc trace.SpanContext) []byte {
+	traceParent := TraceParent(sc)
+	if traceParent == "" {
+		return nil
+	}
+	return []byte(traceParent)
+}
+
+// UnmarshalSpanContext returns the remote span context stored by
+// MarshalSpanContext, span contexts stored in the legacy binary format
+// before the move to opentelemetry are also read.
+func UnmarshalSpanContext(data []byte) (trace.SpanContext, error) {
+	carrier := propagation.MapCarrier{traceParentHeader: string(data)}
+	sc := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+	if sc.IsValid() {
+		return sc, nil
+	}
+	sc, _, err := ReadLegacyTrace(data)
+	return sc, err
+}
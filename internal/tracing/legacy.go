@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxLegacyBaggage bounds the baggage items read from a legacy span
+// context, the same limit as the jaeger client.
+const maxLegacyBaggage = 128
+
+// ErrLegacyTraceCorrupted is returned when data does not start with a
+// legacy span context.
+var ErrLegacyTraceCorrupted = errors.New("legacy span context is corrupted")
+
+// WriteLegacyTrace writes sc in the opentracing binary format of the
+// jaeger client, it is the span context at the start of a not.TraceMsg
+// that consumers which have not moved to the traceparent header read.
+func WriteLegacyTrace(w io.Writer, sc trace.SpanContext) error {
+	traceID, spanID := sc.TraceID(), sc.SpanID()
+	var flags byte
+	if sc.IsSampled() {
+		flags = 1
+	}
+	for _, field := range []interface{}{traceID[:], spanID[:], uint64(0), flags, int32(0)} {
+		err := binary.Write(w, binary.BigEndian, field)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadLegacyTrace reads a span context in the opentracing binary format of
+// the jaeger client from the start of data, it returns the remote span
+// context and the rest of data. Baggage items are skipped.
+func ReadLegacyTrace(data []byte) (trace.SpanContext, []byte, error) {
+	r := bytes.NewReader(data)
+	var (
+		traceID  trace.TraceID
+		spanID   trace.SpanID
+		parentID uint64
+		flags    byte
+		baggage  int32
+	)
+	for _, field := range []interface{}{traceID[:], spanID[:], &parentID, &flags, &baggage} {
+		err := binary.Read(r, binary.BigEndian, field)
+		if err != nil {
+			return trace.SpanContext{}, data, ErrLegacyTraceCorrupted
+		}
+	}
+	if baggage < 0 || baggage > maxLegacyBaggage {
+		return trace.SpanContext{}, data, ErrLegacyTraceCorrupted
+	}
+	for i := 0; i < int(baggage)*2; i++ {
+		var length int32
+		err := binary.Read(r, binary.BigEndian, &length)
+		if err != nil || length < 0 || int64(length) > int64(r.Len()) {
+			return trace.SpanContext{}, data, ErrLegacyTraceCorrupted
+		}
+		r.Seek(int64(length), io.SeekCurrent)
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.TraceFlags(flags & 1),
+		Remote:     true,
+	})
+	if !sc.IsValid() {
+		return trace.SpanContext{}, data, ErrLegacyTraceCorrupted
+	}
+	return sc, data[len(data)-r.Len():], nil
+}
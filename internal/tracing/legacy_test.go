@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var testSpanContext = trace.NewSpanContext(trace.SpanContextConfig{
+	TraceID:    trace.TraceID{0x0a, 15: 0x0b},
+	SpanID:     trace.SpanID{0x0c, 7: 0x0d},
+	TraceFlags: trace.FlagsSampled,
+	Remote:     true,
+})
+
+// testLegacyTrace is testSpanContext in the binary format of the jaeger
+// client: trace id, span id, parent id, flags and baggage count.
+var testLegacyTrace = []byte{
+	0x0a, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x0b,
+	0x0c, 0, 0, 0, 0, 0, 0, 0x0d,
+	0, 0, 0, 0, 0, 0, 0, 0,
+	1,
+	0, 0, 0, 0,
+}
+
+func TestWriteLegacyTrace(t *testing.T) {
+	var got bytes.Buffer
+	err := WriteLegacyTrace(&got, testSpanContext)
+	if err != nil {
+		t.Fatalf("WriteLegacyTrace() error = %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), testLegacyTrace) {
+		t.Errorf("WriteLegacyTrace() = %v, want %v", got.Bytes(), testLegacyTrace)
+	}
+}
+
+func TestReadLegacyTrace(t *testing.T) {
+	withBaggage := append([]byte{}, testLegacyTrace[:33]...)
+	withBaggage = append(withBaggage, 0, 0, 0, 1, 0, 0, 0, 1, 'k', 0, 0, 0, 2, 'v', 'v')
+	tests := []struct {
+		name     string
+		data     []byte
+		want     trace.SpanContext
+		wantRest string
+		wantErr  bool
+	}{
+		{
+			name:     "span context and payload",
+			data:     append(append([]byte{}, testLegacyTrace...), `{"sku":"sku.1"}`...),
+			want:     testSpanContext,
+			wantRest: `{"sku":"sku.1"}`,
+		},
+		{
+			name:     "baggage is skipped",
+			data:     append(withBaggage, `{}`...),
+			want:     testSpanContext,
+			wantRest: `{}`,
+		},
+		{
+			name:     "truncated",
+			data:     testLegacyTrace[:20],
+			wantRest: string(testLegacyTrace[:20]),
+			wantErr:  true,
+		},
+		{
+			name:     "baggage longer than data",
+			data:     withBaggage[:40],
+			wantRest: string(withBaggage[:40]),
+			wantErr:  true,
+		},
+		{
+			name:     "zero trace id",
+			data:     make([]byte, len(testLegacyTrace)),
+			wantRest: string(make([]byte, len(testLegacyTrace))),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := ReadLegacyTrace(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadLegacyTrace() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) || string(rest) != tt.wantRest {
+				t.Errorf("ReadLegacyTrace() = %v %q, want %v %q", got, rest, tt.want, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestUnmarshalSpanContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    trace.SpanContext
+		wantErr bool
+	}{
+		{name: "traceparent", data: MarshalSpanContext(testSpanContext), want: testSpanContext},
+		{name: "legacy", data: testLegacyTrace, want: testSpanContext},
+		{name: "invalid", data: []byte("00-invalid"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalSpanContext(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UnmarshalSpanContext() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalSpanContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := MarshalSpanContext(trace.SpanContext{}); got != nil {
+		t.Errorf("MarshalSpanContext() of an invalid span context = %s, want nil", got)
+	}
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderCarrier adapts nats message headers to a
+// propagation.TextMapCarrier.
+type HeaderCarrier nats.Header
+
+// Get returns the first value of key.
+func (c HeaderCarrier) Get(key string) string {
+	return nats.Header(c).Get(key)
+}
+
+// Set sets key to value.
+func (c HeaderCarrier) Set(key, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+// Keys returns the keys of the headers.
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// InjectHeader adds the span context of ctx to header with the global
+// propagator, e.g the traceparent header.
+func InjectHeader(ctx context.Context, header nats.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, HeaderCarrier(header))
+}
+
+// ExtractMsg returns ctx with the remote span context of msg and the data
+// of msg. The span context is read from the headers, or from the legacy
+// binary span context at the start of a not.TraceMsg when legacy is true,
+// which is then removed from the data. Data that is valid json has no
+// legacy span context.
+func ExtractMsg(ctx context.Context, msg *nats.Msg, legacy bool) (context.Context, []byte) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, HeaderCarrier(msg.Header))
+	if !legacy || json.Valid(msg.Data) {
+		return ctx, msg.Data
+	}
+	sc, data, err := ReadLegacyTrace(msg.Data)
+	if err != nil {
+		return ctx, msg.Data
+	}
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, data
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc), data
+}
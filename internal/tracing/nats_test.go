@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestExtractMsg(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	header := nats.Header{}
+	InjectHeader(trace.ContextWithSpanContext(context.Background(), testSpanContext), header)
+	legacyData := append(append([]byte{}, testLegacyTrace...), `{"userId":"1"}`...)
+	tests := []struct {
+		name     string
+		msg      *nats.Msg
+		legacy   bool
+		want     trace.SpanContext
+		wantData string
+	}{
+		{
+			name:     "traceparent header",
+			msg:      &nats.Msg{Header: header, Data: []byte(`{"userId":"1"}`)},
+			want:     testSpanContext,
+			wantData: `{"userId":"1"}`,
+		},
+		{
+			name:     "legacy trace message",
+			msg:      &nats.Msg{Data: legacyData},
+			legacy:   true,
+			want:     testSpanContext,
+			wantData: `{"userId":"1"}`,
+		},
+		{
+			name:     "legacy trace message without compatibility",
+			msg:      &nats.Msg{Data: legacyData},
+			wantData: string(legacyData),
+		},
+		{
+			name:     "without span context",
+			msg:      &nats.Msg{Data: []byte(`{"userId":"1"}`)},
+			legacy:   true,
+			wantData: `{"userId":"1"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, data := ExtractMsg(context.Background(), tt.msg, tt.legacy)
+			got := trace.SpanContextFromContext(ctx)
+			if !reflect.DeepEqual(got, tt.want) || string(data) != tt.wantData {
+				t.Errorf("ExtractMsg() = %v %q, want %v %q", got, data, tt.want, tt.wantData)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package tracing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// Replacement values of the fields hidden by a policy.
+const (
+	RedactedValue   = "[REDACTED]"
+	truncatedSuffix = "...(truncated)"
+	hashPrefix      = "sha256:"
+)
+
+// Policy decides which span attributes are recorded and hides the
+// sensitive fields of their values. Fields are matched by name, ignoring
+// case, underscores and dashes, against the last segment of attribute keys
+// e.g param.email and against the fields of structured values, e.g the
+// email of the merchant user.
+type Policy struct {
+	// Allow is the attribute keys that are recorded, a key ending with *
+	// matches the keys with that prefix. Every key is recorded when it is
+	// empty.
+	Allow []string
+	// Redact is the fields whose value is replaced with [REDACTED].
+	Redact []string
+	// Hash is the fields whose value is replaced with a HMAC-SHA256 keyed
+	// with HashKey, so spans of the same e.g email can be correlated
+	// without recording it. The fields are redacted when HashKey is empty
+	// since an unkeyed hash can be reversed by hashing candidates.
+	Hash    []string
+	HashKey []byte
+	// MaxLength is the maximum length of string values in bytes, longer
+	// values are truncated. Values are not truncated when it is 0.
+	MaxLength int
+}
+
+// DefaultPolicy returns the policy used until SetPolicy is called, it
+// redacts credentials and contact details, the contact details are hashed
+// once a HashKey is set.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Redact:    []string{"password", "token", "jwtToken", "authorization"},
+		Hash:      []string{"email", "phone"},
+		MaxLength: 1024,
+	}
+}
+
+var (
+	policyMu     sync.RWMutex
+	globalPolicy = DefaultPolicy()
+)
+
+// SetPolicy sets the policy SetAttribute applies to span attributes.
+func SetPolicy(policy *Policy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	globalPolicy = policy
+}
+
+// GetPolicy returns the policy SetAttribute applies to span attributes.
+func GetPolicy() *Policy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return globalPolicy
+}
+
+// Attribute returns the span attribute of value with the sensitive fields
+// hidden, it returns false when key is not allowed.
+func (p *Policy) Attribute(key string, value interface{}) (attribute.KeyValue, bool) {
+	if !p.allowed(key) {
+		return attribute.KeyValue{}, false
+	}
+	field := key[strings.LastIndex(key, ".")+1:]
+	if hidden, ok := p.hide(field, value); ok {
+		return attribute.String(key, hidden), true
+	}
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, p.truncate(v)), true
+	case bool:
+		return attribute.Bool(key, v), true
+	case int:
+		return attribute.Int(key, v), true
+	case int32:
+		return attribute.Int64(key, int64(v)), true
+	case int64:
+		return attribute.Int64(key, v), true
+	case uint32:
+		return attribute.Int64(key, int64(v)), true
+	case float64:
+		return attribute.Float64(key, v), true
+	case []string:
+		values := make([]string, len(v))
+		for i, value := range v {
+			values[i] = p.truncate(value)
+		}
+		return attribute.StringSlice(key, values), true
+	case protobuf.Message:
+		return attribute.String(key, p.truncate(p.encode(value))), true
+	case fmt.Stringer:
+		return attribute.String(key, p.truncate(v.String())), true
+	}
+	if reflect.ValueOf(value).Kind() == reflect.String {
+		return attribute.String(key, p.truncate(reflect.ValueOf(value).String())), true
+	}
+	return attribute.String(key, p.truncate(p.encode(value))), true
+}
+
+// encode returns value as json with the sensitive fields hidden, protobuf
+// messages are encoded as protojson.
+func (p *Policy) encode(value interface{}) string {
+	var (
+		data []byte
+		err  error
+	)
+	if message, ok := value.(protobuf.Message); ok {
+		data, err = protojson.Marshal(message)
+	} else {
+		data, err = json.Marshal(value)
+	}
+	if err != nil {
+		return fmt.Sprintf("%+v", value)
+	}
+	var decoded interface{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		return string(data)
+	}
+	data, err = json.Marshal(p.hideFields(decoded))
+	if err != nil {
+		return RedactedValue
+	}
+	return string(data)
+}
+
+// hideFields hides the sensitive fields of the decoded json value.
+func (p *Policy) hideFields(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range v {
+			if hidden, ok := p.hide(field, fieldValue); ok {
+				v[field] = hidden
+			} else {
+				v[field] = p.hideFields(fieldValue)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = p.hideFields(item)
+		}
+	}
+	return value
+}
+
+// hide returns the replacement of value when field is redacted or hashed.
+func (p *Policy) hide(field string, value interface{}) (string, bool) {
+	if matchField(p.Redact, field) {
+		return RedactedValue, true
+	}
+	if matchField(p.Hash, field) {
+		if len(p.HashKey) == 0 {
+			return RedactedValue, true
+		}
+		return p.hash(value), true
+	}
+	return "", false
+}
+
+func (p *Policy) hash(value interface{}) string {
+	text, ok := value.(string)
+	if !ok {
+		text = fmt.Sprint(value)
+	}
+	mac := hmac.New(sha256.New, p.HashKey)
+	mac.Write([]byte(text))
+	return hashPrefix + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// truncate cuts value to MaxLength bytes without splitting a character.
+func (p *Policy) truncate(value string) string {
+	if p.MaxLength <= 0 || len(value) <= p.MaxLength {
+		return value
+	}
+	cut := p.MaxLength
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut] + truncatedSuffix
+}
+
+func (p *Policy) allowed(key string) bool {
+	if len(p.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range p.Allow {
+		if pattern == key || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(key, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchField reports whether field is one of fields, ignoring case,
+// underscores and dashes.
+func matchField(fields []string, field string) bool {
+	field = normalizeField(field)
+	for _, candidate := range fields {
+		if normalizeField(candidate) == field {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeField(field string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(field))
+}
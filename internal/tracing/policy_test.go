@@ -0,0 +1,192 @@
+package tracing
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type testStatus string
+
+func TestPolicy_Attribute(t *testing.T) {
+	policy := &Policy{
+		Allow:     []string{"param.*", "merchant", "request.body", "response.count"},
+		Redact:    []string{"password", "jwt_token"},
+		Hash:      []string{"email"},
+		MaxLength: 16,
+	}
+	tests := []struct {
+		name   string
+		policy *Policy
+		key    string
+		value  interface{}
+		want   attribute.KeyValue
+		wantOK bool
+	}{
+		{
+			name:   "allowed by prefix",
+			key:    "param.sku",
+			value:  "sku.1",
+			want:   attribute.String("param.sku", "sku.1"),
+			wantOK: true,
+		},
+		{
+			name:  "not allowed",
+			key:   "response.product",
+			value: "sku.1",
+		},
+		{
+			name:   "number",
+			key:    "response.count",
+			value:  int64(3),
+			want:   attribute.Int64("response.count", 3),
+			wantOK: true,
+		},
+		{
+			name:   "named string",
+			key:    "param.status",
+			value:  testStatus("placed"),
+			want:   attribute.String("param.status", "placed"),
+			wantOK: true,
+		},
+		{
+			name:   "redacted key",
+			key:    "param.password",
+			value:  "secret",
+			want:   attribute.String("param.password", RedactedValue),
+			wantOK: true,
+		},
+		{
+			name:   "hashed key without hash key",
+			key:    "param.email",
+			value:  "jane@example.com",
+			want:   attribute.String("param.email", RedactedValue),
+			wantOK: true,
+		},
+		{
+			name:   "keyed hash",
+			policy: &Policy{Hash: []string{"email"}, HashKey: []byte("secret")},
+			key:    "param.email",
+			value:  "jane@example.com",
+			want:   attribute.String("param.email", "sha256:fb817989d942e7ff"),
+			wantOK: true,
+		},
+		{
+			name:   "protobuf message fields",
+			policy: &Policy{Hash: []string{"email"}, HashKey: []byte("secret")},
+			key:    "merchant",
+			value:  &proto.User{Id: "user.1", Email: "jane@example.com"},
+			want:   attribute.String("merchant", `{"email":"sha256:fb817989d942e7ff","id":"user.1"}`),
+			wantOK: true,
+		},
+		{
+			name:   "nested fields",
+			policy: &Policy{Redact: []string{"jwt_token"}},
+			key:    "request.body",
+			value:  map[string]interface{}{"items": []map[string]string{{"jwtToken": "abc", "sku": "sku.1"}}},
+			want:   attribute.String("request.body", `{"items":[{"jwtToken":"[REDACTED]","sku":"sku.1"}]}`),
+			wantOK: true,
+		},
+		{
+			name:   "truncated",
+			key:    "param.name",
+			value:  "a very long product name",
+			want:   attribute.String("param.name", "a very long prod...(truncated)"),
+			wantOK: true,
+		},
+		{
+			name:   "truncated before a multi byte character",
+			policy: &Policy{MaxLength: 4},
+			key:    "param.name",
+			value:  "abcé",
+			want:   attribute.String("param.name", "abc...(truncated)"),
+			wantOK: true,
+		},
+		{
+			name:   "truncated slice values",
+			policy: &Policy{MaxLength: 3},
+			key:    "param.skus",
+			value:  []string{"sku.1", "s"},
+			want:   attribute.StringSlice("param.skus", []string{"sku...(truncated)", "s"}),
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := policy
+			if tt.policy != nil {
+				p = tt.policy
+			}
+			got, ok := p.Attribute(tt.key, tt.value)
+			if ok != tt.wantOK {
+				t.Errorf("Policy.Attribute() ok = %v, want %v", ok, tt.wantOK)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Policy.Attribute() = %v %v, want %v %v", got.Key, got.Value.Emit(), tt.want.Key, tt.want.Value.Emit())
+			}
+		})
+	}
+}
+
+func TestAddEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Policy
+		attrs  []attribute.KeyValue
+		want   []attribute.KeyValue
+	}{
+		{
+			name:   "allowed attributes",
+			policy: &Policy{Allow: []string{"user.id", "violations"}},
+			attrs:  []attribute.KeyValue{attribute.String("user.id", "user.1"), attribute.Int("violations", 2)},
+			want:   []attribute.KeyValue{attribute.String("user.id", "user.1"), attribute.Int64("violations", 2)},
+		},
+		{
+			name:   "not allowed",
+			policy: &Policy{Allow: []string{"param.*"}},
+			attrs:  []attribute.KeyValue{attribute.String("merchant.id", "user.1")},
+			want:   []attribute.KeyValue{},
+		},
+		{
+			name:   "redacted",
+			policy: &Policy{Redact: []string{"email"}},
+			attrs:  []attribute.KeyValue{attribute.String("user.email", "jane@example.com")},
+			want:   []attribute.KeyValue{attribute.String("user.email", RedactedValue)},
+		},
+		{
+			name:   "hashed without key",
+			policy: &Policy{Hash: []string{"id"}},
+			attrs:  []attribute.KeyValue{attribute.String("merchant.id", "user.1")},
+			want:   []attribute.KeyValue{attribute.String("merchant.id", RedactedValue)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := GetPolicy()
+			SetPolicy(tt.policy)
+			defer SetPolicy(previous)
+			recorder := tracetest.NewSpanRecorder()
+			_, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("").Start(context.Background(), "span")
+
+			AddEvent(span, "event", tt.attrs...)
+			span.End()
+			events := recorder.Ended()[0].Events()
+			if len(events) != 1 || events[0].Name != "event" {
+				t.Fatalf("AddEvent() events = %v, want one event", events)
+			}
+			got := events[0].Attributes
+			if got == nil {
+				got = []attribute.KeyValue{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AddEvent() attributes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetAttribute sets the attribute key to value on span with the policy of
+// SetPolicy, e.g the email of a user value is hashed and keys that are not
+// allowed are not recorded. Value is only encoded when the span is
+// recording.
+func SetAttribute(span trace.Span, key string, value interface{}) {
+	if !span.IsRecording() {
+		return
+	}
+	if kv, ok := GetPolicy().Attribute(key, value); ok {
+		span.SetAttributes(kv)
+	}
+}
+
+// AddEvent adds the event name with attrs to span, the attributes go
+// through the policy of SetPolicy like the attributes of SetAttribute.
+func AddEvent(span trace.Span, name string, attrs ...attribute.KeyValue) {
+	if !span.IsRecording() {
+		return
+	}
+	policy := GetPolicy()
+	allowed := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		if kv, ok := policy.Attribute(string(attr.Key), attr.Value.AsInterface()); ok {
+			allowed = append(allowed, kv)
+		}
+	}
+	span.AddEvent(name, trace.WithAttributes(allowed...))
+}
+
+// RecordError records err on span and marks the span as failed, event
+// names the operation that failed e.g gorm.db.Create.
+func RecordError(span trace.Span, err error, event string) {
+	span.RecordError(err, trace.WithAttributes(attribute.String("event", event)))
+	span.SetStatus(codes.Error, err.Error())
+}
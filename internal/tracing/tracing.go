@@ -0,0 +1,86 @@
+// Package tracing configures opentelemetry tracing and propagates span
+// contexts in nats messages, with the W3C traceparent header and the
+// legacy opentracing binary format of not.TraceMsg.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+// Config is the tracing configuration.
+type Config struct {
+	ServiceName string
+	// Endpoint is the host:port of the OTLP gRPC collector, spans are not
+	// exported when it is empty.
+	Endpoint string
+	Insecure bool
+	// Sampler is one of always_on, always_off, traceidratio,
+	// parentbased_always_on, parentbased_always_off or
+	// parentbased_traceidratio, SamplerArg is the ratio of the ratio
+	// samplers.
+	Sampler    string
+	SamplerArg float64
+	// Policy is the span attribute policy, DefaultPolicy is used when it
+	// is nil.
+	Policy *Policy
+}
+
+// Init installs the global tracer provider, the W3C trace context
+// propagator and the span attribute policy, the returned function flushes
+// the buffered spans and stops the exporter.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	sampler, err := NewSampler(cfg.Sampler, cfg.SamplerArg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Policy != nil {
+		SetPolicy(cfg.Policy)
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(cfg.ServiceName))),
+	}
+	if cfg.Endpoint != "" {
+		clientOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// NewSampler returns the sampler with the provided name, arg is the ratio
+// of the ratio samplers. An empty name is parentbased_always_on.
+func NewSampler(name string, arg float64) (sdktrace.Sampler, error) {
+	switch name {
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(arg), nil
+	case "", "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(arg)), nil
+	}
+	return nil, fmt.Errorf("unknown sampler %q", name)
+}
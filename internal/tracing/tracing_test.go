@@ -0,0 +1,29 @@
+package tracing
+
+import "testing"
+
+func TestNewSampler(t *testing.T) {
+	tests := []struct {
+		name        string
+		arg         float64
+		wantSampler string
+		wantErr     bool
+	}{
+		{name: "", wantSampler: "ParentBased{root:AlwaysOnSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}"},
+		{name: "always_off", wantSampler: "AlwaysOffSampler"},
+		{name: "traceidratio", arg: 0.25, wantSampler: "TraceIDRatioBased{0.25}"},
+		{name: "probabilistic", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSampler(tt.name, tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSampler() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != nil && got.Description() != tt.wantSampler {
+				t.Errorf("NewSampler() = %v, want %v", got.Description(), tt.wantSampler)
+			}
+		})
+	}
+}
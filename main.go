@@ -1,22 +1,43 @@
 package main
 
 import (
-	"log"
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
 	"net"
+	"net/http"
 	"os"
+	"syscall"
+	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/nats-io/nats.go"
-	otgrpc "github.com/opentracing-contrib/go-grpc"
-	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
-	"github.com/uber/jaeger-client-go"
-	"github.com/uber/jaeger-client-go/config"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
 	servers "github.com/wisdommatt/ecommerce-microservice-product-service/grpc/service-servers"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/config"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/health"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/inventory"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/lifecycle"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/metrics"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/publisher"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/stream"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/subscriber"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/nats/handlers"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/nats/rpc"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -27,73 +48,369 @@ func main() {
 	log.SetReportCaller(true)
 	log.SetOutput(os.Stdout)
 
-	mustLoadDotenv(log)
+	cfg := mustLoadConfig(log)
+	lc := lifecycle.NewManager(cfg.Shutdown.Timeout)
 
-	db, err := gorm.Open(mysql.Open(os.Getenv("MYSQL_CONNECTION")), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(cfg.MySQL.Connection), &gorm.Config{})
 	if err != nil {
-		log.WithError(err).Fatal("failed to connect database", os.Getenv("MYSQL_CONNECTION"))
+		log.WithError(err).Fatal("failed to connect database")
+	}
+	db.AutoMigrate(
+		&products.Product{}, &products.Option{}, &products.Variant{},
+		&inventory.StockLevel{}, &inventory.Adjustment{},
+		&inventory.Reservation{}, &inventory.ReservationItem{},
+		&inventory.Order{}, &inventory.OrderItem{}, &inventory.ProductSales{},
+		&outbox.Message{}, &subscriber.ProcessedMessage{},
+	)
+	err = products.MigrateLegacyPrices(db, cfg.Products.LegacyPriceCurrency)
+	if err != nil {
+		log.WithError(err).Fatal("an error occured while migrating legacy product prices")
+	}
+	err = inventory.MigrateVariantStock(db)
+	if err != nil {
+		log.WithError(err).Fatal("an error occured while migrating legacy variant stock")
 	}
-	db.AutoMigrate(&products.Product{})
 
-	natsConn, err := nats.Connect(os.Getenv("NATS_URI"))
+	// the connection keeps reconnecting in the background when nats is
+	// not reachable at startup, events are spooled until it is.
+	natsConn, err := nats.Connect(cfg.NATS.URI, nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1))
 	if err != nil {
-		log.WithField("nats_uri", os.Getenv("NATS_URI")).WithError(err).
-			Error("an error occured while connecting to nats")
+		log.WithError(err).Fatal("an error occured while connecting to nats")
 	}
-	defer natsConn.Close()
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "2424"
+	shutdownTracing := mustInitTracing(log, cfg.Tracing)
+
+	lis, err := net.Listen("tcp", ":"+cfg.Port)
+	if err != nil {
+		log.WithError(err).WithField("port", cfg.Port).Fatal("an error occured while listening to tcp conn")
 	}
-	tracer := initTracer("product-service")
-	opentracing.SetGlobalTracer(tracer)
 
-	lis, err := net.Listen("tcp", ":"+port)
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.WithError(err).WithField("port", port).Fatal("an error occured while listening to tcp conn")
+		log.WithError(err).Fatal("an error occured while getting database connection pool")
 	}
+	sqlDB.SetMaxOpenConns(cfg.MySQL.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MySQL.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.MySQL.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.MySQL.ConnMaxIdleTime)
+	prometheus.MustRegister(collectors.NewDBStatsCollector(sqlDB, "product_service"))
+	metricsServer := serveMetrics(log, lc, cfg.MetricsAddr)
 
-	userServiceConn, err := grpc.Dial(os.Getenv("USER_SERVICE_ADDR"), grpc.WithInsecure())
+	userServiceConn, err := grpc.Dial(
+		cfg.UserService.Addr, grpc.WithInsecure(),
+		grpc.WithChainUnaryInterceptor(
+			timeoutUnaryClientInterceptor(cfg.UserService.Timeout),
+			otelgrpc.UnaryClientInterceptor(),
+			metrics.NewGRPCClientMetrics(prometheus.DefaultRegisterer, "user_service").UnaryClientInterceptor(),
+		),
+	)
 	if err != nil {
-		log.WithField("userServiceAddr", os.Getenv("USER_SERVICE_ADDR")).WithError(err).
+		log.WithField("userServiceAddr", cfg.UserService.Addr).WithError(err).
 			Fatal("an error occured while connecting to user service")
 	}
 	userServiceClient := proto.NewUserServiceClient(userServiceConn)
-	productRepo := products.NewRepository(db, initTracer("mysql"))
-	productService := services.NewProductService(productRepo, userServiceClient, natsConn, initTracer("product.ServiceHandlers"))
+	var publisherConn publisher.Conn = natsConn
+	if cfg.Stream.Enabled {
+		publisherConn = mustGetStreamPublisher(log, natsConn, cfg.Stream)
+	}
+	outboxPublisher := mustGetPublisher(log, publisherConn, cfg.Publish)
+	lc.Go(func(ctx context.Context) {
+		outboxPublisher.Run(ctx, cfg.Publish.SpoolInterval)
+	})
+	outboxRelay := outbox.NewRelay(
+		outbox.NewRepository(db, otel.Tracer("mysql")), outboxPublisher, mustGetEventEncoder(log, cfg.Events),
+		cfg.Outbox.MaxAttempts, otel.Tracer("outbox.Relay"),
+	)
+	lc.Go(func(ctx context.Context) {
+		outboxRelay.Run(ctx, cfg.Outbox.RelayInterval)
+	})
+	productRepo := products.NewInstrumentedRepository(
+		products.NewRepository(db, otel.Tracer("mysql")), metrics.NewRepositoryMetrics(prometheus.DefaultRegisterer),
+	)
+	productService := services.NewProductService(
+		productRepo, userServiceClient, otel.Tracer("product.ServiceHandlers"),
+		cfg.Products.AdminUserIDs,
+		products.NewCursorCodec(mustGetCursorSecret(log, cfg.Products.CursorSecret)),
+	)
+	inventoryService := services.NewInventoryService(
+		inventory.NewRepository(db, otel.Tracer("mysql")), productRepo, userServiceClient,
+		otel.Tracer("inventory.ServiceHandlers"),
+		cfg.Inventory.ReservationTTL,
+	)
+	lc.Go(func(ctx context.Context) {
+		inventoryService.RunReservationSweeper(ctx, cfg.Inventory.ReservationSweepInterval)
+	})
 
+	natsSubscriber := subscriber.NewSubscriber(
+		natsConn, subscriber.NewRepository(db, otel.Tracer("mysql")), otel.Tracer("nats.Subscribers"),
+		subscriber.Config{
+			Queue:              cfg.Subscriber.Queue,
+			DeadLetterPrefix:   cfg.Subscriber.DeadLetterPrefix,
+			MaxAttempts:        cfg.Subscriber.MaxAttempts,
+			RetryDelay:         cfg.Subscriber.RetryDelay,
+			HashDedupWindow:    cfg.Subscriber.HashDedupWindow,
+			ProcessedRetention: cfg.Subscriber.ProcessedRetention,
+			LegacyTrace:        cfg.Tracing.LegacyPropagation,
+		},
+	)
+	lc.Go(func(ctx context.Context) {
+		natsSubscriber.RunCleanup(ctx, cfg.Subscriber.ProcessedCleanupInterval)
+	})
+	userHandler := handlers.NewUserHandler(productService)
+	for _, subject := range cfg.NATS.UserRemovedSubjects {
+		err = natsSubscriber.Handle("unpublish-merchant-products", subject, userHandler.UserRemoved)
+		if err != nil {
+			log.WithField("subject", subject).WithError(err).Fatal("an error occured while subscribing to nats")
+		}
+	}
+	orderHandler := handlers.NewOrderHandler(inventoryService)
+	err = natsSubscriber.Handle("sell-order-stock", cfg.NATS.OrderPlacedSubject, orderHandler.OrderPlaced)
+	if err != nil {
+		log.WithField("subject", cfg.NATS.OrderPlacedSubject).WithError(err).Fatal("an error occured while subscribing to nats")
+	}
+	err = natsSubscriber.Handle("restore-order-stock", cfg.NATS.OrderCancelledSubject, orderHandler.OrderCancelled)
+	if err != nil {
+		log.WithField("subject", cfg.NATS.OrderCancelledSubject).WithError(err).Fatal("an error occured while subscribing to nats")
+	}
+
+	rpcServer := rpc.NewProductServer(
+		natsConn, productService, otel.Tracer("nats.RPC"), cfg.Subscriber.Queue, cfg.Tracing.LegacyPropagation,
+	)
+	err = rpcServer.Serve(cfg.NATS.RPCSubjectPrefix)
+	if err != nil {
+		log.WithField("prefix", cfg.NATS.RPCSubjectPrefix).WithError(err).Fatal("an error occured while serving nats requests")
+	}
+
+	grpcMetrics := metrics.NewGRPCServerMetrics(prometheus.DefaultRegisterer)
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(otgrpc.OpenTracingServerInterceptor(tracer)),
-		grpc.StreamInterceptor(otgrpc.OpenTracingStreamServerInterceptor(tracer)),
+		grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), grpcMetrics.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor(), grpcMetrics.StreamServerInterceptor()),
 	)
 	proto.RegisterProductServiceServer(grpcServer, servers.NewProductServer(productService))
-	log.WithField("port", port).Info("app running")
-	grpcServer.Serve(lis)
+	proto.RegisterInventoryServiceServer(grpcServer, servers.NewInventoryServer(inventoryService))
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthMonitor := health.NewMonitor(
+		healthServer,
+		[]string{proto.ProductService_ServiceDesc.ServiceName, proto.InventoryService_ServiceDesc.ServiceName},
+		health.Probe{
+			Name: "mysql", Check: health.PingCheck(sqlDB),
+			Timeout: cfg.Health.MySQLTimeout, TTL: cfg.Health.MySQLCacheTTL,
+		},
+		health.Probe{
+			Name: "nats", Check: health.NATSCheck(natsConn),
+			Timeout: cfg.Health.NATSTimeout, TTL: cfg.Health.NATSCacheTTL,
+		},
+		health.Probe{
+			Name: "user-service", Check: health.ClientConnCheck(userServiceConn),
+			Timeout: cfg.Health.UserServiceTimeout, TTL: cfg.Health.UserServiceCacheTTL,
+		},
+	)
+	lc.Go(func(ctx context.Context) {
+		healthMonitor.Run(ctx, cfg.Health.CheckInterval)
+	})
+	go func() {
+		// Serve returns nil once the server is stopped.
+		err := grpcServer.Serve(lis)
+		if err != nil {
+			lc.Fail(fmt.Errorf("serving grpc: %w", err))
+		}
+	}()
+	log.WithField("port", cfg.Port).Info("app running")
+
+	// the service is reported NOT_SERVING and new requests and messages
+	// are refused first, the in-flight ones are drained, then the events
+	// they saved are published before the connections are closed.
+	lc.OnShutdown("health", func(ctx context.Context) error {
+		healthMonitor.Shutdown()
+		return nil
+	})
+	lc.OnShutdown("shutdown delay", lifecycle.Delay(cfg.Shutdown.Delay))
+	lc.OnShutdown("grpc server", lifecycle.GracefulStop(grpcServer, cfg.Shutdown.DrainTimeout))
+	lc.OnShutdown("nats rpc server", rpcServer.Close)
+	lc.OnShutdown("nats subscriber", natsSubscriber.Close)
+	lc.OnShutdown("background workers", lc.StopWorkers)
+	lc.OnShutdown("outbox relay", outboxRelay.Flush)
+	lc.OnShutdown("publisher", func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		return outboxPublisher.Flush(time.Until(deadline))
+	})
+	lc.OnShutdown("nats connection", func(ctx context.Context) error {
+		natsConn.Close()
+		return nil
+	})
+	lc.OnShutdown("user service connection", func(ctx context.Context) error {
+		return userServiceConn.Close()
+	})
+	lc.OnShutdown("metrics server", metricsServer.Shutdown)
+	lc.OnShutdown("tracing", shutdownTracing)
+	lc.OnShutdown("database", func(ctx context.Context) error {
+		return sqlDB.Close()
+	})
+
+	sig, err := lc.Wait(syscall.SIGTERM, os.Interrupt)
+	if err != nil {
+		log.WithError(err).Error("shutting down after a failure")
+	} else {
+		log.WithField("signal", sig.String()).Info("shutting down")
+	}
+	shutdownErr := lc.Shutdown()
+	var hookErrs lifecycle.ShutdownError
+	if errors.As(shutdownErr, &hookErrs) {
+		for _, hookErr := range hookErrs {
+			log.WithField("component", hookErr.Name).WithError(hookErr.Err).Error("an error occured while shutting down")
+		}
+	}
+	if err != nil || shutdownErr != nil {
+		os.Exit(1)
+	}
+	log.Info("app stopped")
 }
 
-func mustLoadDotenv(log *logrus.Logger) {
-	err := godotenv.Load(".env", ".env-defaults")
+// mustLoadConfig loads the configuration from the command line flags, the
+// environment and the optional dotenv file. The configuration is printed
+// with secrets masked and the program exits when --print-config is set.
+func mustLoadConfig(log *logrus.Logger) *config.Config {
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if cfg != nil && cfg.PrintConfig {
+		cfg.Print(os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 	if err != nil {
-		log.WithError(err).Fatal("Unable to load env files")
+		log.WithError(err).Fatal("an error occured while loading the configuration")
 	}
+	return cfg
 }
 
-func initTracer(serviceName string) opentracing.Tracer {
-	return initJaegerTracer(serviceName)
+// timeoutUnaryClientInterceptor sets timeout on the calls that do not
+// already have a shorter deadline.
+func timeoutUnaryClientInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
 }
 
-func initJaegerTracer(serviceName string) opentracing.Tracer {
-	cfg := &config.Configuration{
-		ServiceName: serviceName,
-		Sampler: &config.SamplerConfig{
-			Type:  "const",
-			Param: 1,
-		},
+// mustGetCursorSecret returns the secret used to sign product list cursors,
+// a random secret is generated when none is configured.
+func mustGetCursorSecret(log *logrus.Logger, secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+	log.Warn("PRODUCT_CURSOR_SECRET is not set, product list cursors will not be valid across restarts or replicas")
+	randomSecret := make([]byte, 32)
+	_, err := rand.Read(randomSecret)
+	if err != nil {
+		log.WithError(err).Fatal("an error occured while generating cursor secret")
 	}
-	tracer, _, err := cfg.NewTracer(config.Logger(jaeger.StdLogger))
+	return randomSecret
+}
+
+// mustGetEventEncoder returns the encoder of the published events, the
+// events on the legacy subjects always use the legacy encoding.
+func mustGetEventEncoder(log *logrus.Logger, cfg config.EventsConfig) outbox.Encoder {
+	encoder, err := outbox.NewEncoder(cfg.Encoding, cfg.Source)
+	if err != nil {
+		log.WithError(err).Fatal("invalid EVENT_ENCODING")
+	}
+	return outbox.SubjectEncoder{
+		Subjects: cfg.LegacySubjects,
+		Matched:  outbox.LegacyEncoder{},
+		Default:  encoder,
+	}
+}
+
+// serveMetrics serves the prometheus metrics on /metrics at addr, lc is
+// shut down when the server fails.
+func serveMetrics(log *logrus.Logger, lc *lifecycle.Manager, addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{Addr: addr, Handler: mux}
+	log.WithField("addr", addr).Info("serving metrics")
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			lc.Fail(fmt.Errorf("serving metrics: %w", err))
+		}
+	}()
+	return server
+}
+
+// mustGetPublisher returns a publisher that retries failed publishes to
+// conn and spools the messages that still fail in the spool directory.
+func mustGetPublisher(log *logrus.Logger, conn publisher.Conn, cfg config.PublishConfig) *publisher.Publisher {
+	spool, err := publisher.NewSpool(cfg.SpoolDir)
+	if err != nil {
+		log.WithField("dir", cfg.SpoolDir).WithError(err).Fatal("an error occured while opening publish spool")
+	}
+	p, err := publisher.NewPublisher(conn, spool, publisher.NewMetrics(prometheus.DefaultRegisterer), publisher.Config{
+		MaxAttempts:      cfg.MaxAttempts,
+		RetryDelay:       cfg.RetryDelay,
+		MaxRetryDelay:    cfg.MaxRetryDelay,
+		DeadLetterPrefix: cfg.DeadLetterPrefix,
+	})
+	if err != nil {
+		log.WithError(err).Fatal("an error occured while reading publish spool")
+	}
+	return p
+}
+
+// mustGetStreamPublisher creates or updates the jetstream events stream
+// and returns a publisher that publishes the events it captures to it.
+func mustGetStreamPublisher(log *logrus.Logger, natsConn *nats.Conn, streamCfg config.StreamConfig) *stream.Publisher {
+	retention, err := stream.ParseRetention(streamCfg.Retention)
+	if err != nil {
+		log.WithError(err).Fatal("invalid NATS_STREAM_RETENTION")
+	}
+	cfg := stream.Config{
+		Name:            streamCfg.Name,
+		Subjects:        streamCfg.Subjects,
+		Retention:       retention,
+		MaxAge:          streamCfg.MaxAge,
+		MaxMsgs:         streamCfg.MaxMsgs,
+		MaxBytes:        streamCfg.MaxBytes,
+		DuplicateWindow: streamCfg.DuplicateWindow,
+		Replicas:        streamCfg.Replicas,
+	}
+	js, err := natsConn.JetStream()
+	if err != nil {
+		log.WithError(err).Fatal("an error occured while getting jetstream context")
+	}
+	_, err = stream.EnsureStream(js, cfg)
+	if err != nil {
+		log.WithField("stream", cfg.Name).WithError(err).Fatal("an error occured while creating jetstream stream")
+	}
+	return stream.NewPublisher(natsConn, js, cfg.Subjects)
+}
+
+// mustInitTracing installs the opentelemetry tracer provider, spans are
+// exported to the OTLP collector of cfg. The returned hook flushes the
+// spans that were not exported yet.
+func mustInitTracing(log *logrus.Logger, cfg config.TracingConfig) lifecycle.Hook {
+	shutdown, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName: cfg.ServiceName,
+		Endpoint:    cfg.OTLPEndpoint,
+		Insecure:    cfg.OTLPInsecure,
+		Sampler:     cfg.Sampler,
+		SamplerArg:  cfg.SamplerArg,
+		Policy: &tracing.Policy{
+			Allow:     cfg.AttributesAllow,
+			Redact:    cfg.AttributesRedact,
+			Hash:      cfg.AttributesHash,
+			HashKey:   []byte(cfg.AttributesHashKey),
+			MaxLength: cfg.AttributesMaxLength,
+		},
+	})
 	if err != nil {
-		log.Fatal("ERROR: cannot init Jaeger", err)
+		log.WithError(err).Fatal("an error occured while initializing tracing")
 	}
-	return tracer
+	return shutdown
 }
@@ -0,0 +1,40 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	nats "github.com/nats-io/nats.go"
+	mock "github.com/stretchr/testify/mock"
+
+	outbox "github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+)
+
+// Encoder is an autogenerated mock type for the Encoder type
+type Encoder struct {
+	mock.Mock
+}
+
+// Encode provides a mock function with given fields: ctx, message
+func (_m *Encoder) Encode(ctx context.Context, message *outbox.Message) (*nats.Msg, error) {
+	ret := _m.Called(ctx, message)
+
+	var r0 *nats.Msg
+	if rf, ok := ret.Get(0).(func(context.Context, *outbox.Message) *nats.Msg); ok {
+		r0 = rf(ctx, message)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*nats.Msg)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *outbox.Message) error); ok {
+		r1 = rf(ctx, message)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
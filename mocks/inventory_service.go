@@ -0,0 +1,158 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	inventory "github.com/wisdommatt/ecommerce-microservice-product-service/internal/inventory"
+)
+
+// InventoryService is an autogenerated mock type for the InventoryService type
+type InventoryService struct {
+	mock.Mock
+}
+
+// AdjustStock provides a mock function with given fields: ctx, jwtToken, sku, kind, quantity, reason
+func (_m *InventoryService) AdjustStock(ctx context.Context, jwtToken string, sku string, kind inventory.AdjustmentKind, quantity int64, reason string) (*inventory.StockLevel, error) {
+	ret := _m.Called(ctx, jwtToken, sku, kind, quantity, reason)
+
+	var r0 *inventory.StockLevel
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, inventory.AdjustmentKind, int64, string) *inventory.StockLevel); ok {
+		r0 = rf(ctx, jwtToken, sku, kind, quantity, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.StockLevel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, inventory.AdjustmentKind, int64, string) error); ok {
+		r1 = rf(ctx, jwtToken, sku, kind, quantity, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelOrder provides a mock function with given fields: ctx, orderID
+func (_m *InventoryService) CancelOrder(ctx context.Context, orderID string) error {
+	ret := _m.Called(ctx, orderID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CommitReservation provides a mock function with given fields: ctx, jwtToken, orderID
+func (_m *InventoryService) CommitReservation(ctx context.Context, jwtToken string, orderID string) (*inventory.Reservation, error) {
+	ret := _m.Called(ctx, jwtToken, orderID)
+
+	var r0 *inventory.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *inventory.Reservation); ok {
+		r0 = rf(ctx, jwtToken, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, jwtToken, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStock provides a mock function with given fields: ctx, sku
+func (_m *InventoryService) GetStock(ctx context.Context, sku string) (*inventory.StockLevel, error) {
+	ret := _m.Called(ctx, sku)
+
+	var r0 *inventory.StockLevel
+	if rf, ok := ret.Get(0).(func(context.Context, string) *inventory.StockLevel); ok {
+		r0 = rf(ctx, sku)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.StockLevel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, sku)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlaceOrder provides a mock function with given fields: ctx, orderID, userID, items
+func (_m *InventoryService) PlaceOrder(ctx context.Context, orderID string, userID string, items []inventory.OrderItem) error {
+	ret := _m.Called(ctx, orderID, userID, items)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []inventory.OrderItem) error); ok {
+		r0 = rf(ctx, orderID, userID, items)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseReservation provides a mock function with given fields: ctx, jwtToken, orderID
+func (_m *InventoryService) ReleaseReservation(ctx context.Context, jwtToken string, orderID string) (*inventory.Reservation, error) {
+	ret := _m.Called(ctx, jwtToken, orderID)
+
+	var r0 *inventory.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *inventory.Reservation); ok {
+		r0 = rf(ctx, jwtToken, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, jwtToken, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReserveStock provides a mock function with given fields: ctx, jwtToken, orderID, items
+func (_m *InventoryService) ReserveStock(ctx context.Context, jwtToken string, orderID string, items []inventory.ReservationItem) (*inventory.Reservation, error) {
+	ret := _m.Called(ctx, jwtToken, orderID, items)
+
+	var r0 *inventory.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []inventory.ReservationItem) *inventory.Reservation); ok {
+		r0 = rf(ctx, jwtToken, orderID, items)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []inventory.ReservationItem) error); ok {
+		r1 = rf(ctx, jwtToken, orderID, items)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,168 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	grpc "google.golang.org/grpc"
+
+	mock "github.com/stretchr/testify/mock"
+
+	proto "github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+)
+
+// InventoryServiceClient is an autogenerated mock type for the InventoryServiceClient type
+type InventoryServiceClient struct {
+	mock.Mock
+}
+
+// AdjustStock provides a mock function with given fields: ctx, in, opts
+func (_m *InventoryServiceClient) AdjustStock(ctx context.Context, in *proto.AdjustStockInput, opts ...grpc.CallOption) (*proto.StockLevel, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.StockLevel
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.AdjustStockInput, ...grpc.CallOption) *proto.StockLevel); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.StockLevel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.AdjustStockInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CommitReservation provides a mock function with given fields: ctx, in, opts
+func (_m *InventoryServiceClient) CommitReservation(ctx context.Context, in *proto.CommitReservationInput, opts ...grpc.CallOption) (*proto.Reservation, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.CommitReservationInput, ...grpc.CallOption) *proto.Reservation); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.CommitReservationInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStock provides a mock function with given fields: ctx, in, opts
+func (_m *InventoryServiceClient) GetStock(ctx context.Context, in *proto.GetStockInput, opts ...grpc.CallOption) (*proto.StockLevel, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.StockLevel
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.GetStockInput, ...grpc.CallOption) *proto.StockLevel); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.StockLevel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.GetStockInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseReservation provides a mock function with given fields: ctx, in, opts
+func (_m *InventoryServiceClient) ReleaseReservation(ctx context.Context, in *proto.ReleaseReservationInput, opts ...grpc.CallOption) (*proto.Reservation, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.ReleaseReservationInput, ...grpc.CallOption) *proto.Reservation); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.ReleaseReservationInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReserveStock provides a mock function with given fields: ctx, in, opts
+func (_m *InventoryServiceClient) ReserveStock(ctx context.Context, in *proto.ReserveStockInput, opts ...grpc.CallOption) (*proto.Reservation, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.ReserveStockInput, ...grpc.CallOption) *proto.Reservation); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.ReserveStockInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,135 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	proto "github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+)
+
+// InventoryServiceServer is an autogenerated mock type for the InventoryServiceServer type
+type InventoryServiceServer struct {
+	mock.Mock
+}
+
+// AdjustStock provides a mock function with given fields: _a0, _a1
+func (_m *InventoryServiceServer) AdjustStock(_a0 context.Context, _a1 *proto.AdjustStockInput) (*proto.StockLevel, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.StockLevel
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.AdjustStockInput) *proto.StockLevel); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.StockLevel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.AdjustStockInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CommitReservation provides a mock function with given fields: _a0, _a1
+func (_m *InventoryServiceServer) CommitReservation(_a0 context.Context, _a1 *proto.CommitReservationInput) (*proto.Reservation, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.CommitReservationInput) *proto.Reservation); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.CommitReservationInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStock provides a mock function with given fields: _a0, _a1
+func (_m *InventoryServiceServer) GetStock(_a0 context.Context, _a1 *proto.GetStockInput) (*proto.StockLevel, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.StockLevel
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.GetStockInput) *proto.StockLevel); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.StockLevel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.GetStockInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseReservation provides a mock function with given fields: _a0, _a1
+func (_m *InventoryServiceServer) ReleaseReservation(_a0 context.Context, _a1 *proto.ReleaseReservationInput) (*proto.Reservation, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.ReleaseReservationInput) *proto.Reservation); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.ReleaseReservationInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReserveStock provides a mock function with given fields: _a0, _a1
+func (_m *InventoryServiceServer) ReserveStock(_a0 context.Context, _a1 *proto.ReserveStockInput) (*proto.Reservation, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.ReserveStockInput) *proto.Reservation); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.ReserveStockInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// mustEmbedUnimplementedInventoryServiceServer provides a mock function with given fields:
+func (_m *InventoryServiceServer) mustEmbedUnimplementedInventoryServiceServer() {
+	_m.Called()
+}
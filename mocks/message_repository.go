@@ -0,0 +1,67 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+	outbox "github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+)
+
+// MessageRepository is an autogenerated mock type for the MessageRepository type
+type MessageRepository struct {
+	mock.Mock
+}
+
+// ClaimPending provides a mock function with given fields: ctx, limit, lease
+func (_m *MessageRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*outbox.Message, error) {
+	ret := _m.Called(ctx, limit, lease)
+
+	var r0 []*outbox.Message
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration) []*outbox.Message); ok {
+		r0 = rf(ctx, limit, lease)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*outbox.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Duration) error); ok {
+		r1 = rf(ctx, limit, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkSent provides a mock function with given fields: ctx, messages
+func (_m *MessageRepository) MarkSent(ctx context.Context, messages []*outbox.Message) error {
+	ret := _m.Called(ctx, messages)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*outbox.Message) error); ok {
+		r0 = rf(ctx, messages)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reschedule provides a mock function with given fields: ctx, messages
+func (_m *MessageRepository) Reschedule(ctx context.Context, messages []*outbox.Message) error {
+	ret := _m.Called(ctx, messages)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*outbox.Message) error); ok {
+		r0 = rf(ctx, messages)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,72 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ProcessedMessageRepository is an autogenerated mock type for the ProcessedMessageRepository type
+type ProcessedMessageRepository struct {
+	mock.Mock
+}
+
+// DeleteProcessedBefore provides a mock function with given fields: ctx, before
+func (_m *ProcessedMessageRepository) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsProcessed provides a mock function with given fields: ctx, consumer, messageID, since
+func (_m *ProcessedMessageRepository) IsProcessed(ctx context.Context, consumer string, messageID string, since time.Time) (bool, error) {
+	ret := _m.Called(ctx, consumer, messageID, since)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) bool); ok {
+		r0 = rf(ctx, consumer, messageID, since)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
+		r1 = rf(ctx, consumer, messageID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkProcessed provides a mock function with given fields: ctx, consumer, messageID
+func (_m *ProcessedMessageRepository) MarkProcessed(ctx context.Context, consumer string, messageID string) error {
+	ret := _m.Called(ctx, consumer, messageID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, consumer, messageID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -37,22 +37,202 @@ func (_m *ProductService) AddProduct(ctx context.Context, jwtToken string, newPr
 	return r0, r1
 }
 
-// GetProduct provides a mock function with given fields: ctx, sku
-func (_m *ProductService) GetProduct(ctx context.Context, sku string) (*products.Product, error) {
-	ret := _m.Called(ctx, sku)
+// DeleteProduct provides a mock function with given fields: ctx, jwtToken, sku
+func (_m *ProductService) DeleteProduct(ctx context.Context, jwtToken string, sku string) (*products.Product, error) {
+	ret := _m.Called(ctx, jwtToken, sku)
 
 	var r0 *products.Product
-	if rf, ok := ret.Get(0).(func(context.Context, string) *products.Product); ok {
-		r0 = rf(ctx, sku)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *products.Product); ok {
+		r0 = rf(ctx, jwtToken, sku)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*products.Product)
 		}
 	}
 
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, jwtToken, sku)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateVariants provides a mock function with given fields: ctx, jwtToken, sku, options
+func (_m *ProductService) GenerateVariants(ctx context.Context, jwtToken string, sku string, options []products.Option) (*products.Product, error) {
+	ret := _m.Called(ctx, jwtToken, sku, options)
+
+	var r0 *products.Product
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []products.Option) *products.Product); ok {
+		r0 = rf(ctx, jwtToken, sku, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*products.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []products.Option) error); ok {
+		r1 = rf(ctx, jwtToken, sku, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProduct provides a mock function with given fields: ctx, jwtToken, sku, includeDeleted
+func (_m *ProductService) GetProduct(ctx context.Context, jwtToken string, sku string, includeDeleted bool) (*products.Product, error) {
+	ret := _m.Called(ctx, jwtToken, sku, includeDeleted)
+
+	var r0 *products.Product
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool) *products.Product); ok {
+		r0 = rf(ctx, jwtToken, sku, includeDeleted)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*products.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, bool) error); ok {
+		r1 = rf(ctx, jwtToken, sku, includeDeleted)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProducts provides a mock function with given fields: ctx, skus
+func (_m *ProductService) GetProducts(ctx context.Context, skus []string) ([]*products.Product, error) {
+	ret := _m.Called(ctx, skus)
+
+	var r0 []*products.Product
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*products.Product); ok {
+		r0 = rf(ctx, skus)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*products.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, skus)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListProducts provides a mock function with given fields: ctx, filter, after
+func (_m *ProductService) ListProducts(ctx context.Context, filter products.ListFilter, after string) ([]*products.Product, string, error) {
+	ret := _m.Called(ctx, filter, after)
+
+	var r0 []*products.Product
+	if rf, ok := ret.Get(0).(func(context.Context, products.ListFilter, string) []*products.Product); ok {
+		r0 = rf(ctx, filter, after)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*products.Product)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, products.ListFilter, string) string); ok {
+		r1 = rf(ctx, filter, after)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, products.ListFilter, string) error); ok {
+		r2 = rf(ctx, filter, after)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// PurgeProduct provides a mock function with given fields: ctx, jwtToken, sku
+func (_m *ProductService) PurgeProduct(ctx context.Context, jwtToken string, sku string) error {
+	ret := _m.Called(ctx, jwtToken, sku)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, jwtToken, sku)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreProduct provides a mock function with given fields: ctx, jwtToken, sku
+func (_m *ProductService) RestoreProduct(ctx context.Context, jwtToken string, sku string) (*products.Product, error) {
+	ret := _m.Called(ctx, jwtToken, sku)
+
+	var r0 *products.Product
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *products.Product); ok {
+		r0 = rf(ctx, jwtToken, sku)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*products.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, jwtToken, sku)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnpublishMerchantProducts provides a mock function with given fields: ctx, merchantID
+func (_m *ProductService) UnpublishMerchantProducts(ctx context.Context, merchantID string) (int, error) {
+	ret := _m.Called(ctx, merchantID)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, merchantID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
 	var r1 error
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, sku)
+		r1 = rf(ctx, merchantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateProduct provides a mock function with given fields: ctx, jwtToken, sku, update, fields
+func (_m *ProductService) UpdateProduct(ctx context.Context, jwtToken string, sku string, update *products.Product, fields []string) (*products.Product, error) {
+	ret := _m.Called(ctx, jwtToken, sku, update, fields)
+
+	var r0 *products.Product
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *products.Product, []string) *products.Product); ok {
+		r0 = rf(ctx, jwtToken, sku, update, fields)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*products.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *products.Product, []string) error); ok {
+		r1 = rf(ctx, jwtToken, sku, update, fields)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -6,6 +6,7 @@ import (
 	context "context"
 
 	grpc "google.golang.org/grpc"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 
 	mock "github.com/stretchr/testify/mock"
 
@@ -47,6 +48,66 @@ func (_m *ProductServiceClient) AddProduct(ctx context.Context, in *proto.NewPro
 	return r0, r1
 }
 
+// DeleteProduct provides a mock function with given fields: ctx, in, opts
+func (_m *ProductServiceClient) DeleteProduct(ctx context.Context, in *proto.DeleteProductInput, opts ...grpc.CallOption) (*proto.Product, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.Product
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.DeleteProductInput, ...grpc.CallOption) *proto.Product); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.DeleteProductInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateVariants provides a mock function with given fields: ctx, in, opts
+func (_m *ProductServiceClient) GenerateVariants(ctx context.Context, in *proto.GenerateVariantsInput, opts ...grpc.CallOption) (*proto.Product, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.Product
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.GenerateVariantsInput, ...grpc.CallOption) *proto.Product); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.GenerateVariantsInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetProduct provides a mock function with given fields: ctx, in, opts
 func (_m *ProductServiceClient) GetProduct(ctx context.Context, in *proto.GetProductInput, opts ...grpc.CallOption) (*proto.Product, error) {
 	_va := make([]interface{}, len(opts))
@@ -76,3 +137,153 @@ func (_m *ProductServiceClient) GetProduct(ctx context.Context, in *proto.GetPro
 
 	return r0, r1
 }
+
+// GetProducts provides a mock function with given fields: ctx, in, opts
+func (_m *ProductServiceClient) GetProducts(ctx context.Context, in *proto.GetProductsInput, opts ...grpc.CallOption) (*proto.GetProductsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.GetProductsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.GetProductsInput, ...grpc.CallOption) *proto.GetProductsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.GetProductsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.GetProductsInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListProducts provides a mock function with given fields: ctx, in, opts
+func (_m *ProductServiceClient) ListProducts(ctx context.Context, in *proto.ListProductsInput, opts ...grpc.CallOption) (*proto.ListProductsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.ListProductsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.ListProductsInput, ...grpc.CallOption) *proto.ListProductsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.ListProductsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.ListProductsInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeProduct provides a mock function with given fields: ctx, in, opts
+func (_m *ProductServiceClient) PurgeProduct(ctx context.Context, in *proto.PurgeProductInput, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.PurgeProductInput, ...grpc.CallOption) *emptypb.Empty); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.PurgeProductInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreProduct provides a mock function with given fields: ctx, in, opts
+func (_m *ProductServiceClient) RestoreProduct(ctx context.Context, in *proto.RestoreProductInput, opts ...grpc.CallOption) (*proto.Product, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.Product
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.RestoreProductInput, ...grpc.CallOption) *proto.Product); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.RestoreProductInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateProduct provides a mock function with given fields: ctx, in, opts
+func (_m *ProductServiceClient) UpdateProduct(ctx context.Context, in *proto.UpdateProductInput, opts ...grpc.CallOption) (*proto.Product, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *proto.Product
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.UpdateProductInput, ...grpc.CallOption) *proto.Product); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.UpdateProductInput, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
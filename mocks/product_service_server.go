@@ -6,6 +6,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+
 	proto "github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
 )
 
@@ -37,6 +39,52 @@ func (_m *ProductServiceServer) AddProduct(_a0 context.Context, _a1 *proto.NewPr
 	return r0, r1
 }
 
+// DeleteProduct provides a mock function with given fields: _a0, _a1
+func (_m *ProductServiceServer) DeleteProduct(_a0 context.Context, _a1 *proto.DeleteProductInput) (*proto.Product, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.Product
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.DeleteProductInput) *proto.Product); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.DeleteProductInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateVariants provides a mock function with given fields: _a0, _a1
+func (_m *ProductServiceServer) GenerateVariants(_a0 context.Context, _a1 *proto.GenerateVariantsInput) (*proto.Product, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.Product
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.GenerateVariantsInput) *proto.Product); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.GenerateVariantsInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetProduct provides a mock function with given fields: _a0, _a1
 func (_m *ProductServiceServer) GetProduct(_a0 context.Context, _a1 *proto.GetProductInput) (*proto.Product, error) {
 	ret := _m.Called(_a0, _a1)
@@ -60,6 +108,121 @@ func (_m *ProductServiceServer) GetProduct(_a0 context.Context, _a1 *proto.GetPr
 	return r0, r1
 }
 
+// GetProducts provides a mock function with given fields: _a0, _a1
+func (_m *ProductServiceServer) GetProducts(_a0 context.Context, _a1 *proto.GetProductsInput) (*proto.GetProductsResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.GetProductsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.GetProductsInput) *proto.GetProductsResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.GetProductsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.GetProductsInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListProducts provides a mock function with given fields: _a0, _a1
+func (_m *ProductServiceServer) ListProducts(_a0 context.Context, _a1 *proto.ListProductsInput) (*proto.ListProductsResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.ListProductsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.ListProductsInput) *proto.ListProductsResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.ListProductsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.ListProductsInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeProduct provides a mock function with given fields: _a0, _a1
+func (_m *ProductServiceServer) PurgeProduct(_a0 context.Context, _a1 *proto.PurgeProductInput) (*emptypb.Empty, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *emptypb.Empty
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.PurgeProductInput) *emptypb.Empty); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emptypb.Empty)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.PurgeProductInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreProduct provides a mock function with given fields: _a0, _a1
+func (_m *ProductServiceServer) RestoreProduct(_a0 context.Context, _a1 *proto.RestoreProductInput) (*proto.Product, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.Product
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.RestoreProductInput) *proto.Product); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.RestoreProductInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateProduct provides a mock function with given fields: _a0, _a1
+func (_m *ProductServiceServer) UpdateProduct(_a0 context.Context, _a1 *proto.UpdateProductInput) (*proto.Product, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *proto.Product
+	if rf, ok := ret.Get(0).(func(context.Context, *proto.UpdateProductInput) *proto.Product); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *proto.UpdateProductInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // mustEmbedUnimplementedProductServiceServer provides a mock function with given fields:
 func (_m *ProductServiceServer) mustEmbedUnimplementedProductServiceServer() {
 	_m.Called()
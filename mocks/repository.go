@@ -6,6 +6,7 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+	outbox "github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
 	products "github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
 )
 
@@ -14,19 +15,116 @@ type Repository struct {
 	mock.Mock
 }
 
-// GetProductBySKU provides a mock function with given fields: ctx, sku
-func (_m *Repository) GetProductBySKU(ctx context.Context, sku string) (*products.Product, error) {
-	ret := _m.Called(ctx, sku)
+// DeleteProduct provides a mock function with given fields: ctx, product, messages
+func (_m *Repository) DeleteProduct(ctx context.Context, product *products.Product, messages []*outbox.Message) error {
+	ret := _m.Called(ctx, product, messages)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *products.Product, []*outbox.Message) error); ok {
+		r0 = rf(ctx, product, messages)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteProducts provides a mock function with given fields: ctx, _a1, messages
+func (_m *Repository) DeleteProducts(ctx context.Context, _a1 []*products.Product, messages []*outbox.Message) error {
+	ret := _m.Called(ctx, _a1, messages)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*products.Product, []*outbox.Message) error); ok {
+		r0 = rf(ctx, _a1, messages)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetProductBySKU provides a mock function with given fields: ctx, sku, includeDeleted
+func (_m *Repository) GetProductBySKU(ctx context.Context, sku string, includeDeleted bool) (*products.Product, error) {
+	ret := _m.Called(ctx, sku, includeDeleted)
 
 	var r0 *products.Product
-	if rf, ok := ret.Get(0).(func(context.Context, string) *products.Product); ok {
-		r0 = rf(ctx, sku)
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *products.Product); ok {
+		r0 = rf(ctx, sku, includeDeleted)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*products.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, sku, includeDeleted)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProductByVariantSKU provides a mock function with given fields: ctx, sku, includeDeleted
+func (_m *Repository) GetProductByVariantSKU(ctx context.Context, sku string, includeDeleted bool) (*products.Product, error) {
+	ret := _m.Called(ctx, sku, includeDeleted)
+
+	var r0 *products.Product
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *products.Product); ok {
+		r0 = rf(ctx, sku, includeDeleted)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*products.Product)
 		}
 	}
 
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, sku, includeDeleted)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProductsBySKUs provides a mock function with given fields: ctx, skus
+func (_m *Repository) GetProductsBySKUs(ctx context.Context, skus []string) ([]*products.Product, error) {
+	ret := _m.Called(ctx, skus)
+
+	var r0 []*products.Product
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*products.Product); ok {
+		r0 = rf(ctx, skus)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*products.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, skus)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVariantBySKU provides a mock function with given fields: ctx, sku
+func (_m *Repository) GetVariantBySKU(ctx context.Context, sku string) (*products.Variant, error) {
+	ret := _m.Called(ctx, sku)
+
+	var r0 *products.Variant
+	if rf, ok := ret.Get(0).(func(context.Context, string) *products.Variant); ok {
+		r0 = rf(ctx, sku)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*products.Variant)
+		}
+	}
+
 	var r1 error
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
 		r1 = rf(ctx, sku)
@@ -37,13 +135,92 @@ func (_m *Repository) GetProductBySKU(ctx context.Context, sku string) (*product
 	return r0, r1
 }
 
-// SaveProduct provides a mock function with given fields: ctx, product
-func (_m *Repository) SaveProduct(ctx context.Context, product *products.Product) error {
-	ret := _m.Called(ctx, product)
+// ListProducts provides a mock function with given fields: ctx, filter
+func (_m *Repository) ListProducts(ctx context.Context, filter products.ListFilter) ([]*products.Product, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*products.Product
+	if rf, ok := ret.Get(0).(func(context.Context, products.ListFilter) []*products.Product); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*products.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, products.ListFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeProduct provides a mock function with given fields: ctx, product, messages
+func (_m *Repository) PurgeProduct(ctx context.Context, product *products.Product, messages []*outbox.Message) error {
+	ret := _m.Called(ctx, product, messages)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *products.Product, []*outbox.Message) error); ok {
+		r0 = rf(ctx, product, messages)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceVariants provides a mock function with given fields: ctx, product, options, variants, messages
+func (_m *Repository) ReplaceVariants(ctx context.Context, product *products.Product, options []products.Option, variants []products.Variant, messages []*outbox.Message) error {
+	ret := _m.Called(ctx, product, options, variants, messages)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *products.Product, []products.Option, []products.Variant, []*outbox.Message) error); ok {
+		r0 = rf(ctx, product, options, variants, messages)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreProduct provides a mock function with given fields: ctx, product, messages
+func (_m *Repository) RestoreProduct(ctx context.Context, product *products.Product, messages []*outbox.Message) error {
+	ret := _m.Called(ctx, product, messages)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *products.Product, []*outbox.Message) error); ok {
+		r0 = rf(ctx, product, messages)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveProduct provides a mock function with given fields: ctx, product, messages
+func (_m *Repository) SaveProduct(ctx context.Context, product *products.Product, messages []*outbox.Message) error {
+	ret := _m.Called(ctx, product, messages)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *products.Product, []*outbox.Message) error); ok {
+		r0 = rf(ctx, product, messages)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateProduct provides a mock function with given fields: ctx, product, fields, messages
+func (_m *Repository) UpdateProduct(ctx context.Context, product *products.Product, fields []string, messages []*outbox.Message) error {
+	ret := _m.Called(ctx, product, fields, messages)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *products.Product) error); ok {
-		r0 = rf(ctx, product)
+	if rf, ok := ret.Get(0).(func(context.Context, *products.Product, []string, []*outbox.Message) error); ok {
+		r0 = rf(ctx, product, fields, messages)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,208 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	inventory "github.com/wisdommatt/ecommerce-microservice-product-service/internal/inventory"
+
+	outbox "github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+
+	time "time"
+)
+
+// StockRepository is an autogenerated mock type for the StockRepository type
+type StockRepository struct {
+	mock.Mock
+}
+
+// AdjustStock provides a mock function with given fields: ctx, adjustment
+func (_m *StockRepository) AdjustStock(ctx context.Context, adjustment *inventory.Adjustment) (*inventory.StockLevel, error) {
+	ret := _m.Called(ctx, adjustment)
+
+	var r0 *inventory.StockLevel
+	if rf, ok := ret.Get(0).(func(context.Context, *inventory.Adjustment) *inventory.StockLevel); ok {
+		r0 = rf(ctx, adjustment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.StockLevel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *inventory.Adjustment) error); ok {
+		r1 = rf(ctx, adjustment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelOrder provides a mock function with given fields: ctx, orderID
+func (_m *StockRepository) CancelOrder(ctx context.Context, orderID string) (*inventory.Order, error) {
+	ret := _m.Called(ctx, orderID)
+
+	var r0 *inventory.Order
+	if rf, ok := ret.Get(0).(func(context.Context, string) *inventory.Order); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CommitReservation provides a mock function with given fields: ctx, orderID, messages
+func (_m *StockRepository) CommitReservation(ctx context.Context, orderID string, messages []*outbox.Message) (*inventory.Reservation, error) {
+	ret := _m.Called(ctx, orderID, messages)
+
+	var r0 *inventory.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, string, []*outbox.Message) *inventory.Reservation); ok {
+		r0 = rf(ctx, orderID, messages)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []*outbox.Message) error); ok {
+		r1 = rf(ctx, orderID, messages)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReservation provides a mock function with given fields: ctx, orderID
+func (_m *StockRepository) GetReservation(ctx context.Context, orderID string) (*inventory.Reservation, error) {
+	ret := _m.Called(ctx, orderID)
+
+	var r0 *inventory.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, string) *inventory.Reservation); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStockLevel provides a mock function with given fields: ctx, sku
+func (_m *StockRepository) GetStockLevel(ctx context.Context, sku string) (*inventory.StockLevel, error) {
+	ret := _m.Called(ctx, sku)
+
+	var r0 *inventory.StockLevel
+	if rf, ok := ret.Get(0).(func(context.Context, string) *inventory.StockLevel); ok {
+		r0 = rf(ctx, sku)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.StockLevel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, sku)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListExpiredReservations provides a mock function with given fields: ctx, now, limit
+func (_m *StockRepository) ListExpiredReservations(ctx context.Context, now time.Time, limit int) ([]*inventory.Reservation, error) {
+	ret := _m.Called(ctx, now, limit)
+
+	var r0 []*inventory.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []*inventory.Reservation); ok {
+		r0 = rf(ctx, now, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*inventory.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlaceOrder provides a mock function with given fields: ctx, order
+func (_m *StockRepository) PlaceOrder(ctx context.Context, order *inventory.Order) error {
+	ret := _m.Called(ctx, order)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *inventory.Order) error); ok {
+		r0 = rf(ctx, order)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseReservation provides a mock function with given fields: ctx, orderID, status, messages
+func (_m *StockRepository) ReleaseReservation(ctx context.Context, orderID string, status inventory.ReservationStatus, messages []*outbox.Message) (*inventory.Reservation, error) {
+	ret := _m.Called(ctx, orderID, status, messages)
+
+	var r0 *inventory.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, string, inventory.ReservationStatus, []*outbox.Message) *inventory.Reservation); ok {
+		r0 = rf(ctx, orderID, status, messages)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, inventory.ReservationStatus, []*outbox.Message) error); ok {
+		r1 = rf(ctx, orderID, status, messages)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReserveStock provides a mock function with given fields: ctx, reservation
+func (_m *StockRepository) ReserveStock(ctx context.Context, reservation *inventory.Reservation) error {
+	ret := _m.Called(ctx, reservation)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *inventory.Reservation) error); ok {
+		r0 = rf(ctx, reservation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,15 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// UnsafeInventoryServiceServer is an autogenerated mock type for the UnsafeInventoryServiceServer type
+type UnsafeInventoryServiceServer struct {
+	mock.Mock
+}
+
+// mustEmbedUnimplementedInventoryServiceServer provides a mock function with given fields:
+func (_m *UnsafeInventoryServiceServer) mustEmbedUnimplementedInventoryServiceServer() {
+	_m.Called()
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/inventory"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/subscriber"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+)
+
+// OrderHandler handles the order events of the checkout service.
+type OrderHandler struct {
+	inventoryService services.InventoryService
+}
+
+// NewOrderHandler returns a new order events handler object.
+func NewOrderHandler(inventoryService services.InventoryService) *OrderHandler {
+	return &OrderHandler{
+		inventoryService: inventoryService,
+	}
+}
+
+// orderEvent is the payload of the events of an order being placed or
+// cancelled, cancelled events do not need the items of the order.
+type orderEvent struct {
+	OrderID string           `json:"orderId"`
+	UserID  string           `json:"userId"`
+	Items   []orderEventItem `json:"items"`
+}
+
+type orderEventItem struct {
+	Sku      string `json:"sku"`
+	Quantity int64  `json:"quantity"`
+}
+
+// OrderPlaced sells the stock of the items of a placed order.
+func (h *OrderHandler) OrderPlaced(ctx context.Context, msg *nats.Msg) error {
+	event, err := decodeOrderEvent(msg)
+	if err != nil {
+		return err
+	}
+	items := make([]inventory.OrderItem, 0, len(event.Items))
+	for _, item := range event.Items {
+		items = append(items, inventory.OrderItem{Sku: item.Sku, Quantity: item.Quantity})
+	}
+	return orderServiceError(h.inventoryService.PlaceOrder(ctx, event.OrderID, event.UserID, items))
+}
+
+// OrderCancelled gives back the stock sold by a cancelled order.
+func (h *OrderHandler) OrderCancelled(ctx context.Context, msg *nats.Msg) error {
+	event, err := decodeOrderEvent(msg)
+	if err != nil {
+		return err
+	}
+	return orderServiceError(h.inventoryService.CancelOrder(ctx, event.OrderID))
+}
+
+func decodeOrderEvent(msg *nats.Msg) (*orderEvent, error) {
+	event := &orderEvent{}
+	err := json.Unmarshal(msg.Data, event)
+	if err != nil {
+		return nil, subscriber.Permanent(fmt.Errorf("decoding %s event: %w", msg.Subject, err))
+	}
+	return event, nil
+}
+
+// orderServiceError marks the errors of the inventory service that
+// handling the order event again cannot fix as permanent, only unavailable
+// dependencies are retried.
+func orderServiceError(err error) error {
+	var serviceErr *services.Error
+	if errors.As(err, &serviceErr) && serviceErr.Code != services.ErrorCodeUnavailable {
+		return subscriber.Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/mock"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/inventory"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/subscriber"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/mocks"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+)
+
+func TestOrderHandler_OrderPlaced(t *testing.T) {
+	inventoryService := &mocks.InventoryService{}
+	inventoryService.On("PlaceOrder", mock.Anything, "", "user.1", []inventory.OrderItem{{Sku: "sku.1", Quantity: 1}}).
+		Return(services.NewInvalidArgumentError("order is invalid"))
+	inventoryService.On("PlaceOrder", mock.Anything, "order.error", "user.1", []inventory.OrderItem{{Sku: "sku.1", Quantity: 1}}).
+		Return(services.NewUnavailableError("an error occured", errors.New("an error occured")))
+	inventoryService.On("PlaceOrder", mock.Anything, "order.valid", "user.1", []inventory.OrderItem{
+		{Sku: "sku.1", Quantity: 1}, {Sku: "sku.2", Quantity: 3},
+	}).Return(nil)
+
+	tests := []struct {
+		name          string
+		data          string
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{name: "malformed event", data: `{"orderId":`, wantErr: true, wantPermanent: true},
+		{
+			name: "invalid order", data: `{"userId":"user.1","items":[{"sku":"sku.1","quantity":1}]}`,
+			wantErr: true, wantPermanent: true,
+		},
+		{
+			name: "PlaceOrder service implementation with error",
+			data: `{"orderId":"order.error","userId":"user.1","items":[{"sku":"sku.1","quantity":1}]}`, wantErr: true,
+		},
+		{
+			name: "PlaceOrder service implementation without error",
+			data: `{"orderId":"order.valid","userId":"user.1","items":[{"sku":"sku.1","quantity":1},{"sku":"sku.2","quantity":3}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewOrderHandler(inventoryService)
+			err := h.OrderPlaced(context.Background(), &nats.Msg{Subject: "order.placed", Data: []byte(tt.data)})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("OrderHandler.OrderPlaced() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotPermanent := subscriber.IsPermanent(err); gotPermanent != tt.wantPermanent {
+				t.Errorf("OrderHandler.OrderPlaced() permanent error = %v, want %v", gotPermanent, tt.wantPermanent)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_OrderCancelled(t *testing.T) {
+	inventoryService := &mocks.InventoryService{}
+	inventoryService.On("CancelOrder", mock.Anything, "").Return(services.NewInvalidArgumentError("order is invalid"))
+	inventoryService.On("CancelOrder", mock.Anything, "order.error").
+		Return(services.NewUnavailableError("an error occured", errors.New("an error occured")))
+	inventoryService.On("CancelOrder", mock.Anything, "order.valid").Return(nil)
+
+	tests := []struct {
+		name          string
+		data          string
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{name: "malformed event", data: `{"orderId":`, wantErr: true, wantPermanent: true},
+		{name: "event without order id", data: `{}`, wantErr: true, wantPermanent: true},
+		{name: "CancelOrder service implementation with error", data: `{"orderId":"order.error"}`, wantErr: true},
+		{name: "CancelOrder service implementation without error", data: `{"orderId":"order.valid"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewOrderHandler(inventoryService)
+			err := h.OrderCancelled(context.Background(), &nats.Msg{Subject: "order.cancelled", Data: []byte(tt.data)})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("OrderHandler.OrderCancelled() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotPermanent := subscriber.IsPermanent(err); gotPermanent != tt.wantPermanent {
+				t.Errorf("OrderHandler.OrderCancelled() permanent error = %v, want %v", gotPermanent, tt.wantPermanent)
+			}
+		})
+	}
+}
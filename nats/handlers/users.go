@@ -0,0 +1,46 @@
+// Package handlers contains the handlers of the nats messages the product
+// service subscribes to.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/subscriber"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+)
+
+// UserHandler handles the events of the user service.
+type UserHandler struct {
+	productService services.ProductService
+}
+
+// NewUserHandler returns a new user events handler object.
+func NewUserHandler(productService services.ProductService) *UserHandler {
+	return &UserHandler{
+		productService: productService,
+	}
+}
+
+// userRemoved is the payload of the events of a user account being
+// deleted or suspended.
+type userRemoved struct {
+	UserID string `json:"userId"`
+}
+
+// UserRemoved unpublishes the products of a deleted or suspended user.
+func (h *UserHandler) UserRemoved(ctx context.Context, msg *nats.Msg) error {
+	var event userRemoved
+	err := json.Unmarshal(msg.Data, &event)
+	if err != nil {
+		return subscriber.Permanent(fmt.Errorf("decoding %s event: %w", msg.Subject, err))
+	}
+	if event.UserID == "" {
+		return subscriber.Permanent(errors.New("userId is required"))
+	}
+	_, err = h.productService.UnpublishMerchantProducts(ctx, event.UserID)
+	return err
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/mock"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/subscriber"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/mocks"
+)
+
+func TestUserHandler_UserRemoved(t *testing.T) {
+	productService := &mocks.ProductService{}
+	productService.On("UnpublishMerchantProducts", mock.Anything, "user.error").Return(0, errors.New("an error occured"))
+	productService.On("UnpublishMerchantProducts", mock.Anything, "user.valid").Return(3, nil)
+
+	tests := []struct {
+		name          string
+		data          string
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{name: "malformed event", data: `{"userId":`, wantErr: true, wantPermanent: true},
+		{name: "event without user id", data: `{}`, wantErr: true, wantPermanent: true},
+		{name: "UnpublishMerchantProducts service implementation with error", data: `{"userId":"user.error"}`, wantErr: true},
+		{name: "UnpublishMerchantProducts service implementation without error", data: `{"userId":"user.valid"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewUserHandler(productService)
+			err := h.UserRemoved(context.Background(), &nats.Msg{Subject: "user.deleted", Data: []byte(tt.data)})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserHandler.UserRemoved() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotPermanent := subscriber.IsPermanent(err); gotPermanent != tt.wantPermanent {
+				t.Errorf("UserHandler.UserRemoved() permanent error = %v, want %v", gotPermanent, tt.wantPermanent)
+			}
+		})
+	}
+}
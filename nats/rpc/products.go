@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	serviceservers "github.com/wisdommatt/ecommerce-microservice-product-service/grpc/service-servers"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+	"go.opentelemetry.io/otel/trace"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// ProductServer serves the product read endpoints of the grpc
+// ProductService, e.g a proto.GetProductInput sent to <prefix>.get is
+// answered with a proto.Product.
+type ProductServer struct {
+	server
+	productService services.ProductService
+}
+
+// NewProductServer returns a new product server object, requests are
+// shared between the replicas that subscribe with the same queue group.
+// legacyTrace enables reading the span context of requests sent as a
+// not.TraceMsg.
+func NewProductServer(conn *nats.Conn, productService services.ProductService, tracer trace.Tracer, queue string, legacyTrace bool) *ProductServer {
+	return &ProductServer{
+		server:         server{conn: conn, tracer: tracer, queue: queue, legacyTrace: legacyTrace},
+		productService: productService,
+	}
+}
+
+// Serve subscribes the endpoints to the subjects under prefix, e.g
+// products.rpc.get, products.rpc.getMany and products.rpc.list.
+func (s *ProductServer) Serve(prefix string) error {
+	endpoints := map[string]endpoint{
+		"get":     s.GetProduct,
+		"getMany": s.GetProducts,
+		"list":    s.ListProducts,
+	}
+	for name, endpoint := range endpoints {
+		err := s.handle(prefix+"."+name, endpoint)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetProduct answers a proto.GetProductInput with a proto.Product.
+func (s *ProductServer) GetProduct(ctx context.Context, data []byte) (protobuf.Message, error) {
+	input := &proto.GetProductInput{}
+	err := decodeRequest(data, input)
+	if err != nil {
+		return nil, err
+	}
+	// requests are not authenticated, deleted products are only
+	// retrieved through grpc.
+	product, err := s.productService.GetProduct(ctx, "", input.Sku, input.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return serviceservers.InternalProductToProto(product), nil
+}
+
+// GetProducts answers a proto.GetProductsInput with a
+// proto.GetProductsResponse.
+func (s *ProductServer) GetProducts(ctx context.Context, data []byte) (protobuf.Message, error) {
+	input := &proto.GetProductsInput{}
+	err := decodeRequest(data, input)
+	if err != nil {
+		return nil, err
+	}
+	productList, err := s.productService.GetProducts(ctx, input.Skus)
+	if err != nil {
+		return nil, err
+	}
+	response := &proto.GetProductsResponse{
+		Results: make([]*proto.GetProductsResult, 0, len(productList)),
+	}
+	for i, product := range productList {
+		result := &proto.GetProductsResult{Sku: input.Skus[i]}
+		if product == nil {
+			result.Error = "product does not exist"
+		} else {
+			result.Product = serviceservers.InternalProductToProto(product)
+		}
+		response.Results = append(response.Results, result)
+	}
+	return response, nil
+}
+
+// ListProducts answers a proto.ListProductsInput with a
+// proto.ListProductsResponse.
+func (s *ProductServer) ListProducts(ctx context.Context, data []byte) (protobuf.Message, error) {
+	input := &proto.ListProductsInput{}
+	err := decodeRequest(data, input)
+	if err != nil {
+		return nil, err
+	}
+	productList, nextCursor, err := s.productService.ListProducts(ctx, serviceservers.ProtoListProductsInputToFilter(input), input.After)
+	if err != nil {
+		return nil, err
+	}
+	response := &proto.ListProductsResponse{
+		Products:   make([]*proto.Product, 0, len(productList)),
+		NextCursor: nextCursor,
+	}
+	for _, product := range productList {
+		response.Products = append(response.Products, serviceservers.InternalProductToProto(product))
+	}
+	return response, nil
+}
@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/mock"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/mocks"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// runNats starts an embedded nats server and returns a connection to it.
+func runNats(t *testing.T) *nats.Conn {
+	t.Helper()
+	s, err := natsserver.NewServer(&natsserver.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("starting nats server: %v", err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server is not ready for connections")
+	}
+	t.Cleanup(s.Shutdown)
+	conn, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatalf("connecting to nats server: %v", err)
+	}
+	t.Cleanup(conn.Close)
+	return conn
+}
+
+func TestProductServer_Serve(t *testing.T) {
+	productService := &mocks.ProductService{}
+	productService.On("GetProduct", mock.Anything, "", "valid.sku", false).
+		Return(&products.Product{Sku: "valid.sku", Name: "Shoe"}, nil)
+	productService.On("GetProduct", mock.Anything, "", "unknown.sku", false).
+		Return(nil, services.NewNotFoundError("PRODUCT_NOT_FOUND", "product does not exist"))
+	productService.On("GetProduct", mock.Anything, "", "error.sku", false).Return(nil, errors.New("an error occured"))
+	productService.On("GetProducts", mock.Anything, []string{"valid.sku", "unknown.sku"}).
+		Return([]*products.Product{{Sku: "valid.sku"}, nil}, nil)
+	productService.On("ListProducts", mock.Anything, products.ListFilter{Brand: "nike", SortBy: products.SortByTimeAdded, Limit: 2}, "").
+		Return([]*products.Product{{Sku: "valid.sku"}}, "next", nil)
+	productService.On("ListProducts", mock.Anything, products.ListFilter{SortBy: products.SortByTimeAdded, Limit: 1000}, "").
+		Return(nil, "", services.NewInvalidArgumentError("filter is invalid", services.FieldViolation{
+			Field: "limit", Description: "limit must not be greater than 100",
+		}))
+
+	conn := runNats(t)
+	s := NewProductServer(conn, productService, trace.NewNoopTracerProvider().Tracer(""), "test", true)
+	err := s.Serve("products.rpc")
+	if err != nil {
+		t.Fatalf("ProductServer.Serve() error = %v", err)
+	}
+	defer s.Close(context.Background())
+
+	tests := []struct {
+		name       string
+		subject    string
+		request    string
+		wantResult string
+		wantError  *Error
+	}{
+		{
+			name: "get product", subject: "products.rpc.get", request: `{"sku":"valid.sku"}`,
+			wantResult: `{"sku":"valid.sku","name":"Shoe"}`,
+		},
+		{
+			name: "get unknown product", subject: "products.rpc.get", request: `{"sku":"unknown.sku"}`,
+			wantError: &Error{Code: services.ErrorCodeNotFound, Reason: "PRODUCT_NOT_FOUND", Message: "product does not exist"},
+		},
+		{
+			name: "GetProduct service implementation with unexpected error", subject: "products.rpc.get", request: `{"sku":"error.sku"}`,
+			wantError: &Error{Code: services.ErrorCodeInternal, Reason: "INTERNAL", Message: "an unexpected error occured, please try again later"},
+		},
+		{
+			name: "malformed request", subject: "products.rpc.get", request: `{"sku":`,
+			wantError: &Error{Code: services.ErrorCodeInvalidArgument, Reason: "INVALID_ARGUMENT"},
+		},
+		{
+			name: "get many products", subject: "products.rpc.getMany", request: `{"skus":["valid.sku","unknown.sku"]}`,
+			wantResult: `{"results":[{"sku":"valid.sku","product":{"sku":"valid.sku"}},{"sku":"unknown.sku","error":"product does not exist"}]}`,
+		},
+		{
+			name: "list products", subject: "products.rpc.list", request: `{"brand":"nike","limit":2}`,
+			wantResult: `{"products":[{"sku":"valid.sku"}],"nextCursor":"next"}`,
+		},
+		{
+			name: "list products with invalid filter", subject: "products.rpc.list", request: `{"limit":1000}`,
+			wantError: &Error{
+				Code: services.ErrorCodeInvalidArgument, Reason: "INVALID_ARGUMENT", Message: "filter is invalid",
+				Violations: []FieldViolation{{Field: "limit", Description: "limit must not be greater than 100"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := conn.Request(tt.subject, []byte(tt.request), 5*time.Second)
+			if err != nil {
+				t.Fatalf("requesting %s: %v", tt.subject, err)
+			}
+			response := &Response{}
+			err = json.Unmarshal(msg.Data, response)
+			if err != nil {
+				t.Fatalf("decoding response %s: %v", msg.Data, err)
+			}
+			if tt.wantError != nil {
+				// the messages of malformed requests come from protojson.
+				if tt.wantError.Message == "" && response.Error != nil {
+					response.Error.Message = ""
+				}
+				if !reflect.DeepEqual(response.Error, tt.wantError) {
+					t.Errorf("%s error = %+v, want %+v", tt.subject, response.Error, tt.wantError)
+				}
+				return
+			}
+			if response.Error != nil || !jsonEqual(response.Result, []byte(tt.wantResult)) {
+				t.Errorf("%s = %s %+v, want %s", tt.subject, response.Result, response.Error, tt.wantResult)
+			}
+		})
+	}
+}
+
+func jsonEqual(a, b []byte) bool {
+	var x, y interface{}
+	return json.Unmarshal(a, &x) == nil && json.Unmarshal(b, &y) == nil && reflect.DeepEqual(x, y)
+}
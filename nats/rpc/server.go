@@ -0,0 +1,167 @@
+// Package rpc serves read endpoints of the product service over nats
+// request-reply for the workers that cannot use grpc. Requests and
+// results are the protojson encoding of the grpc messages, and replies are
+// wrapped in a Response envelope.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/services"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// Response is the envelope of every reply, it has either a result or an
+// error.
+type Response struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// Error is the error of a failed request, it mirrors services.Error.
+type Error struct {
+	Code       services.ErrorCode `json:"code"`
+	Reason     string             `json:"reason"`
+	Message    string             `json:"message"`
+	Violations []FieldViolation   `json:"violations,omitempty"`
+}
+
+// FieldViolation describes why a request field is invalid.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// drainPollInterval is how often Close checks whether the subscriptions
+// are drained.
+const drainPollInterval = 10 * time.Millisecond
+
+// endpoint handles the request data and returns the result of the request.
+type endpoint func(ctx context.Context, data []byte) (protobuf.Message, error)
+
+// server subscribes endpoints to nats subjects with a queue group, so that
+// every request is handled by a single replica of the service.
+// legacyTrace enables reading the span context of requests sent as a
+// not.TraceMsg.
+type server struct {
+	conn        *nats.Conn
+	tracer      trace.Tracer
+	queue       string
+	legacyTrace bool
+
+	mu   sync.Mutex
+	subs []*nats.Subscription
+}
+
+func (s *server) handle(subject string, endpoint endpoint) error {
+	sub, err := s.conn.QueueSubscribe(subject, s.queue, func(msg *nats.Msg) {
+		s.serve(msg, endpoint)
+	})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.subs = append(s.subs, sub)
+	s.mu.Unlock()
+	return nil
+}
+
+// Close drains the subscriptions, the requests that were already received
+// are still answered. It waits until they are answered or ctx is done.
+func (s *server) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subs {
+		err := sub.Drain()
+		if err != nil {
+			return err
+		}
+	}
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for _, sub := range s.subs {
+		// a drained subscription is closed once its pending messages
+		// were handled.
+		for sub.IsValid() {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+	s.subs = nil
+	return nil
+}
+
+func (s *server) serve(msg *nats.Msg, endpoint endpoint) {
+	ctx, data := tracing.ExtractMsg(context.Background(), msg, s.legacyTrace)
+	ctx, span := s.tracer.Start(ctx, msg.Subject, trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+	span.SetAttributes(semconv.MessagingDestinationKey.String(msg.Subject))
+
+	if msg.Reply == "" {
+		span.AddEvent("ignoring request without reply subject")
+		return
+	}
+	response := &Response{}
+	result, err := endpoint(ctx, data)
+	if err == nil {
+		response.Result, err = protojson.Marshal(result)
+	}
+	if err != nil {
+		tracing.RecordError(span, err, "handling request")
+		response = &Response{Error: toResponseError(err)}
+	}
+	reply, err := json.Marshal(response)
+	if err != nil {
+		tracing.RecordError(span, err, "json.Marshal")
+		return
+	}
+	err = msg.Respond(reply)
+	if err != nil {
+		tracing.RecordError(span, err, "nats.Msg.Respond")
+	}
+}
+
+// decodeRequest decodes the protojson request data into input, empty data
+// is an empty request.
+func decodeRequest(data []byte, input protobuf.Message) error {
+	if len(data) == 0 {
+		return nil
+	}
+	err := protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
+	if err != nil {
+		return services.NewInvalidArgumentError("request is malformed: " + err.Error())
+	}
+	return nil
+}
+
+// toResponseError converts err to a response error, errors that are not
+// service errors are not exposed to clients.
+func toResponseError(err error) *Error {
+	var serviceErr *services.Error
+	if !errors.As(err, &serviceErr) {
+		return &Error{
+			Code:    services.ErrorCodeInternal,
+			Reason:  "INTERNAL",
+			Message: "an unexpected error occured, please try again later",
+		}
+	}
+	responseErr := &Error{Code: serviceErr.Code, Reason: serviceErr.Reason, Message: serviceErr.Message}
+	for _, violation := range serviceErr.Violations {
+		responseErr.Violations = append(responseErr.Violations, FieldViolation{
+			Field: violation.Field, Description: violation.Description,
+		})
+	}
+	return responseErr
+}
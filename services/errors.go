@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorCode is the category of an error returned by a service.
+type ErrorCode string
+
+const (
+	ErrorCodeNotFound           ErrorCode = "NOT_FOUND"
+	ErrorCodeUnauthenticated    ErrorCode = "UNAUTHENTICATED"
+	ErrorCodePermissionDenied   ErrorCode = "PERMISSION_DENIED"
+	ErrorCodeInvalidArgument    ErrorCode = "INVALID_ARGUMENT"
+	ErrorCodeFailedPrecondition ErrorCode = "FAILED_PRECONDITION"
+	ErrorCodeUnavailable        ErrorCode = "UNAVAILABLE"
+	ErrorCodeInternal           ErrorCode = "INTERNAL"
+)
+
+// errSKURequired is returned when a request does not contain a sku.
+var errSKURequired = NewInvalidArgumentError("sku must be provided", FieldViolation{
+	Field: "sku", Description: "sku must be provided",
+})
+
+// FieldViolation describes why a request field is invalid.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// Error is the error returned by services, transports use its code and
+// reason to build their own error responses.
+type Error struct {
+	Code ErrorCode
+	// Reason is a short machine readable identifier of the error
+	// e.g PRODUCT_NOT_FOUND.
+	Reason     string
+	Message    string
+	Violations []FieldViolation
+	// Cause is the underlying error, it is never exposed to clients.
+	Cause error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// NewNotFoundError returns an error for a resource that does not exist.
+func NewNotFoundError(reason, message string) *Error {
+	return &Error{Code: ErrorCodeNotFound, Reason: reason, Message: message}
+}
+
+// NewUnauthenticatedError returns an error for a request without valid
+// credentials.
+func NewUnauthenticatedError(message string, cause error) *Error {
+	return &Error{Code: ErrorCodeUnauthenticated, Reason: "UNAUTHENTICATED", Message: message, Cause: cause}
+}
+
+// NewPermissionDeniedError returns an error for an authenticated user
+// that is not allowed to perform an action.
+func NewPermissionDeniedError(reason, message string) *Error {
+	return &Error{Code: ErrorCodePermissionDenied, Reason: reason, Message: message}
+}
+
+// NewInvalidArgumentError returns an error for a request with invalid
+// fields.
+func NewInvalidArgumentError(message string, violations ...FieldViolation) *Error {
+	return &Error{Code: ErrorCodeInvalidArgument, Reason: "INVALID_ARGUMENT", Message: message, Violations: violations}
+}
+
+// NewFailedPreconditionError returns an error for a request that cannot
+// be performed in the current state of a resource.
+func NewFailedPreconditionError(reason, message string) *Error {
+	return &Error{Code: ErrorCodeFailedPrecondition, Reason: reason, Message: message}
+}
+
+// NewUnavailableError returns an error for a dependency that cannot be
+// reached, the request can be retried later.
+func NewUnavailableError(message string, cause error) *Error {
+	return &Error{Code: ErrorCodeUnavailable, Reason: "DEPENDENCY_UNAVAILABLE", Message: message, Cause: cause}
+}
+
+// NewInternalError returns an error for an unexpected failure in the
+// service itself.
+func NewInternalError(message string, cause error) *Error {
+	return &Error{Code: ErrorCodeInternal, Reason: "INTERNAL", Message: message, Cause: cause}
+}
+
+// repositoryError converts an error returned by the product repository
+// to a service error.
+func repositoryError(err error, message string) *Error {
+	if errors.Is(err, products.ErrProductNotFound) {
+		return NewNotFoundError("PRODUCT_NOT_FOUND", "product does not exist")
+	}
+	return NewUnavailableError(message, err)
+}
+
+// productValidationError converts the field errors of an invalid product
+// to a service error.
+func productValidationError(fieldErrors []products.FieldError) *Error {
+	violations := make([]FieldViolation, 0, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		violations = append(violations, FieldViolation{Field: fieldError.Field, Description: fieldError.Description})
+	}
+	return NewInvalidArgumentError("product is invalid", violations...)
+}
+
+// userServiceError converts an error returned by the user service while
+// retrieving the user of a jwt token to a service error.
+func userServiceError(err error) *Error {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return NewUnavailableError("unable to verify your identity, please try again later", err)
+	}
+	return NewUnauthenticatedError("you are not authenticated", err)
+}
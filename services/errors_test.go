@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_repositoryError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode ErrorCode
+	}{
+		{name: "product not found", err: products.ErrProductNotFound, wantCode: ErrorCodeNotFound},
+		{name: "database error", err: errors.New("connection refused"), wantCode: ErrorCodeUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repositoryError(tt.err, "an error occured"); got.Code != tt.wantCode {
+				t.Errorf("repositoryError() = %v, want %v", got.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func Test_userServiceError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode ErrorCode
+	}{
+		{name: "invalid jwt", err: status.Error(codes.Unauthenticated, "invalid jwt"), wantCode: ErrorCodeUnauthenticated},
+		{name: "plain error", err: errors.New("invalid jwt"), wantCode: ErrorCodeUnauthenticated},
+		{name: "user service down", err: status.Error(codes.Unavailable, "connection refused"), wantCode: ErrorCodeUnavailable},
+		{name: "user service timeout", err: status.Error(codes.DeadlineExceeded, "deadline exceeded"), wantCode: ErrorCodeUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := userServiceError(tt.err); got.Code != tt.wantCode {
+				t.Errorf("userServiceError() = %v, want %v", got.Code, tt.wantCode)
+			}
+		})
+	}
+}
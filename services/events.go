@@ -0,0 +1,30 @@
+package services
+
+import (
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/events"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// newEventMessage returns an outbox message that publishes payload as
+// protojson to the provided nats subject with the span context of span
+// saved with it.
+func newEventMessage(span trace.Span, subject string, payload protobuf.Message) (*outbox.Message, error) {
+	payloadJSON, err := events.Marshal(payload)
+	if err != nil {
+		tracing.SetAttribute(span, "object", payload)
+		tracing.RecordError(span, err, "converting object to json")
+		return nil, NewInternalError("an unexpected error occured, please try again later", err)
+	}
+	tracing.AddEvent(span, "event message", attribute.String("event.subject", subject))
+	return &outbox.Message{
+		Subject:       subject,
+		Payload:       payloadJSON,
+		Schema:        events.Schema(payload),
+		SchemaVersion: events.SchemaVersion,
+		TraceContext:  tracing.MarshalSpanContext(span.SpanContext()),
+	}, nil
+}
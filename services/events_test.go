@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// eventSubjects matches outbox messages with the provided subjects.
+func eventSubjects(subjects ...string) interface{} {
+	return mock.MatchedBy(func(messages []*outbox.Message) bool {
+		if len(messages) != len(subjects) {
+			return false
+		}
+		for i, message := range messages {
+			if message.Subject != subjects[i] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func Test_newEventMessage(t *testing.T) {
+	_, span := trace.NewNoopTracerProvider().Tracer("").Start(context.Background(), "test")
+	defer span.End()
+
+	got, err := newEventMessage(span, "products.ProductDeleted", &proto.ProductLifecycleChanged{Sku: "sku.1"})
+	if err != nil {
+		t.Errorf("newEventMessage() unexpected error = %v", err)
+		return
+	}
+	if got.Subject != "products.ProductDeleted" {
+		t.Errorf("newEventMessage() subject = %v, want %v", got.Subject, "products.ProductDeleted")
+	}
+	if got.Schema != "ProductLifecycleChanged" || got.SchemaVersion != "1" {
+		t.Errorf("newEventMessage() schema = %v %v, want %v %v", got.Schema, got.SchemaVersion, "ProductLifecycleChanged", "1")
+	}
+	payload := map[string]string{}
+	err = json.Unmarshal(got.Payload, &payload)
+	if err != nil || !reflect.DeepEqual(payload, map[string]string{"sku": "sku.1", "merchantId": ""}) {
+		t.Errorf("newEventMessage() payload = %s, err = %v", got.Payload, err)
+	}
+
+	_, err = newEventMessage(span, "products.ProductDeleted", &proto.ProductLifecycleChanged{Sku: "\xff"})
+	if err == nil {
+		t.Errorf("newEventMessage() with an invalid payload returned no error")
+	}
+}
@@ -0,0 +1,472 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/events"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/inventory"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InventoryService is the interface that describes an inventory service.
+type InventoryService interface {
+	AdjustStock(ctx context.Context, jwtToken, sku string, kind inventory.AdjustmentKind, quantity int64, reason string) (*inventory.StockLevel, error)
+	GetStock(ctx context.Context, sku string) (*inventory.StockLevel, error)
+	ReserveStock(ctx context.Context, jwtToken, orderID string, items []inventory.ReservationItem) (*inventory.Reservation, error)
+	CommitReservation(ctx context.Context, jwtToken, orderID string) (*inventory.Reservation, error)
+	ReleaseReservation(ctx context.Context, jwtToken, orderID string) (*inventory.Reservation, error)
+	PlaceOrder(ctx context.Context, orderID, userID string, items []inventory.OrderItem) error
+	CancelOrder(ctx context.Context, orderID string) error
+}
+
+const (
+	maxAdjustmentReasonLength = 200
+	maxOrderIDLength          = 64
+	maxReservationItems       = 100
+	maxOrderItems             = 100
+	// expiredReservationsBatchSize is the number of expired reservations
+	// released by the sweeper in one go.
+	expiredReservationsBatchSize = 100
+)
+
+// InventoryServiceImpl is the default implementation for InventoryService
+// interface.
+type InventoryServiceImpl struct {
+	inventoryRepo     inventory.StockRepository
+	productRepo       products.Repository
+	userServiceClient proto.UserServiceClient
+	tracer            trace.Tracer
+	reservationTTL    time.Duration
+}
+
+// NewInventoryService returns a new inventory service object, stock
+// reservations expire reservationTTL after they are made.
+func NewInventoryService(
+	inventoryRepo inventory.StockRepository,
+	productRepo products.Repository,
+	userServiceClient proto.UserServiceClient,
+	tracer trace.Tracer,
+	reservationTTL time.Duration,
+) *InventoryServiceImpl {
+	return &InventoryServiceImpl{
+		inventoryRepo:     inventoryRepo,
+		productRepo:       productRepo,
+		userServiceClient: userServiceClient,
+		tracer:            tracer,
+		reservationTTL:    reservationTTL,
+	}
+}
+
+// AdjustStock records a stock adjustment of the provided kind for sku and
+// returns the resulting stock level, only the merchant that owns the
+// product of the sku can adjust its stock.
+func (s *InventoryServiceImpl) AdjustStock(ctx context.Context, jwtToken, sku string, kind inventory.AdjustmentKind, quantity int64, reason string) (*inventory.StockLevel, error) {
+	ctx, span := s.tracer.Start(ctx, "AdjustStock")
+	defer span.End()
+	tracing.SetAttribute(span, "param.sku", sku)
+	tracing.SetAttribute(span, "param.kind", kind)
+	tracing.SetAttribute(span, "param.quantity", quantity)
+	if sku == "" {
+		return nil, errSKURequired
+	}
+	if violations := validateAdjustment(kind, quantity, reason); len(violations) > 0 {
+		return nil, NewInvalidArgumentError("stock adjustment is invalid", violations...)
+	}
+	userResponse, err := s.userServiceClient.GetUserFromJWT(ctx, &proto.GetUserFromJWTInput{JwtToken: jwtToken})
+	if err != nil {
+		tracing.RecordError(span, err, "retrieving merchant details from jwt")
+		return nil, userServiceError(err)
+	}
+	product, err := s.getStockedProduct(ctx, sku, false)
+	if err != nil {
+		return nil, err
+	}
+	if product.MerchantID != userResponse.User.Id {
+		tracing.AddEvent(span, "merchant does not own product", attribute.String("merchant.id", userResponse.User.Id))
+		span.SetStatus(codes.Error, "merchant does not own product")
+		return nil, NewPermissionDeniedError("NOT_PRODUCT_OWNER", "you are not allowed to modify this product")
+	}
+	level, err := s.inventoryRepo.AdjustStock(ctx, &inventory.Adjustment{
+		Sku:      sku,
+		Kind:     kind,
+		Quantity: kind.Delta(quantity),
+		Reason:   reason,
+		Actor:    userResponse.User.Id,
+	})
+	if errors.Is(err, inventory.ErrInsufficientStock) {
+		return nil, NewFailedPreconditionError("INSUFFICIENT_STOCK", "there is not enough stock for this adjustment")
+	}
+	if err != nil {
+		return nil, NewUnavailableError("an error occured while adjusting stock, please try again later", err)
+	}
+	return level, nil
+}
+
+// validateAdjustment returns the violations of an invalid stock adjustment.
+func validateAdjustment(kind inventory.AdjustmentKind, quantity int64, reason string) []FieldViolation {
+	violations := []FieldViolation{}
+	if !kind.Valid() {
+		violations = append(violations, FieldViolation{
+			Field: "kind", Description: "kind must be one of receive, sell, return or correction",
+		})
+	} else if kind == inventory.KindCorrection && quantity == 0 {
+		violations = append(violations, FieldViolation{Field: "quantity", Description: "quantity must not be 0"})
+	} else if kind != inventory.KindCorrection && quantity <= 0 {
+		violations = append(violations, FieldViolation{Field: "quantity", Description: "quantity must be greater than 0"})
+	}
+	if reason == "" {
+		violations = append(violations, FieldViolation{Field: "reason", Description: "reason is required"})
+	} else if len(reason) > maxAdjustmentReasonLength {
+		violations = append(violations, FieldViolation{
+			Field: "reason", Description: fmt.Sprintf("reason must not be longer than %d characters", maxAdjustmentReasonLength),
+		})
+	}
+	return violations
+}
+
+// GetStock retrieves the stock level of sku, skus that have never been
+// stocked have no stock on hand.
+func (s *InventoryServiceImpl) GetStock(ctx context.Context, sku string) (*inventory.StockLevel, error) {
+	ctx, span := s.tracer.Start(ctx, "GetStock")
+	defer span.End()
+	tracing.SetAttribute(span, "param.sku", sku)
+	if sku == "" {
+		return nil, errSKURequired
+	}
+	level, err := s.inventoryRepo.GetStockLevel(ctx, sku)
+	if err == nil {
+		return level, nil
+	}
+	if !errors.Is(err, inventory.ErrStockLevelNotFound) {
+		return nil, NewUnavailableError("an error occured while retrieving stock, please try again later", err)
+	}
+	_, err = s.getStockedProduct(ctx, sku, false)
+	if err != nil {
+		return nil, err
+	}
+	return &inventory.StockLevel{Sku: sku}, nil
+}
+
+// getStockedProduct retrieves the product of sku, which is either the sku
+// of a product without variants or the sku of a variant. Products with
+// variants have their stock tracked per variant. Soft deleted products are
+// only retrieved when includeDeleted is true.
+func (s *InventoryServiceImpl) getStockedProduct(ctx context.Context, sku string, includeDeleted bool) (*products.Product, error) {
+	product, err := s.productRepo.GetProductBySKU(ctx, sku, includeDeleted)
+	if err == nil {
+		if len(product.Variants) > 0 {
+			return nil, NewFailedPreconditionError(
+				"STOCK_TRACKED_PER_VARIANT", "the stock of a product with variants is tracked per variant",
+			)
+		}
+		return product, nil
+	}
+	if !errors.Is(err, products.ErrProductNotFound) {
+		return nil, repositoryError(err, "an error occured while retrieving product, please try again later")
+	}
+	product, err = s.productRepo.GetProductByVariantSKU(ctx, sku, includeDeleted)
+	if err != nil {
+		return nil, repositoryError(err, "an error occured while retrieving product, please try again later")
+	}
+	return product, nil
+}
+
+// ReserveStock holds the stock of items for the provided order until the
+// reservation is committed, released or expires. Either every item is
+// reserved or none of them, reserving an order again returns its pending
+// reservation until it expires so that checkouts can safely retry.
+func (s *InventoryServiceImpl) ReserveStock(ctx context.Context, jwtToken, orderID string, items []inventory.ReservationItem) (*inventory.Reservation, error) {
+	ctx, span := s.tracer.Start(ctx, "ReserveStock")
+	defer span.End()
+	tracing.SetAttribute(span, "param.orderID", orderID)
+	tracing.SetAttribute(span, "param.items.count", len(items))
+	if violations := validateReservation(orderID, items); len(violations) > 0 {
+		return nil, NewInvalidArgumentError("stock reservation is invalid", violations...)
+	}
+	userResponse, err := s.userServiceClient.GetUserFromJWT(ctx, &proto.GetUserFromJWTInput{JwtToken: jwtToken})
+	if err != nil {
+		tracing.RecordError(span, err, "retrieving user details from jwt")
+		return nil, userServiceError(err)
+	}
+	reservation := &inventory.Reservation{
+		OrderID:   orderID,
+		UserID:    userResponse.User.Id,
+		Items:     items,
+		ExpiresAt: time.Now().Add(s.reservationTTL),
+	}
+	err = s.inventoryRepo.ReserveStock(ctx, reservation)
+	if errors.Is(err, inventory.ErrReservationExists) {
+		existing, getErr := s.inventoryRepo.GetReservation(ctx, orderID)
+		if getErr != nil {
+			return nil, reservationRepositoryError(getErr, "an error occured while reserving stock, please try again later")
+		}
+		if existing.UserID == userResponse.User.Id && existing.Status == inventory.ReservationPending &&
+			existing.ExpiresAt.After(time.Now()) {
+			span.AddEvent("returning existing reservation")
+			return existing, nil
+		}
+		return nil, NewFailedPreconditionError("RESERVATION_EXISTS", "the order already has a reservation")
+	}
+	if err != nil {
+		return nil, reservationRepositoryError(err, "an error occured while reserving stock, please try again later")
+	}
+	return reservation, nil
+}
+
+// validateOrderID returns the violations of an invalid order id.
+func validateOrderID(orderID string) []FieldViolation {
+	if orderID == "" {
+		return []FieldViolation{{Field: "orderId", Description: "orderId is required"}}
+	}
+	if len(orderID) > maxOrderIDLength {
+		return []FieldViolation{{
+			Field: "orderId", Description: fmt.Sprintf("orderId must not be longer than %d characters", maxOrderIDLength),
+		}}
+	}
+	return []FieldViolation{}
+}
+
+// validateReservation returns the violations of an invalid reservation.
+func validateReservation(orderID string, items []inventory.ReservationItem) []FieldViolation {
+	violations := validateOrderID(orderID)
+	if len(items) == 0 {
+		violations = append(violations, FieldViolation{Field: "items", Description: "at least one item is required"})
+	} else if len(items) > maxReservationItems {
+		violations = append(violations, FieldViolation{
+			Field: "items", Description: fmt.Sprintf("at most %d items can be reserved at once", maxReservationItems),
+		})
+	}
+	for i, item := range items {
+		field := fmt.Sprintf("items[%d]", i)
+		if item.Sku == "" {
+			violations = append(violations, FieldViolation{Field: field + ".sku", Description: "sku is required"})
+		}
+		if item.Quantity <= 0 {
+			violations = append(violations, FieldViolation{Field: field + ".quantity", Description: "quantity must be greater than 0"})
+		}
+	}
+	return violations
+}
+
+// CommitReservation sells the stock held by the reservation of the
+// provided order, only the user that made the reservation can commit it.
+func (s *InventoryServiceImpl) CommitReservation(ctx context.Context, jwtToken, orderID string) (*inventory.Reservation, error) {
+	ctx, span := s.tracer.Start(ctx, "CommitReservation")
+	defer span.End()
+	tracing.SetAttribute(span, "param.orderID", orderID)
+	reservation, err := s.getUserReservation(ctx, span, jwtToken, orderID)
+	if err != nil {
+		return nil, err
+	}
+	committed := events.NewReservationEvent(reservation)
+	committed.Status = string(inventory.ReservationCommitted)
+	committedMessage, err := newEventMessage(span, events.ReservationCommitted, committed)
+	if err != nil {
+		return nil, err
+	}
+	reservation, err = s.inventoryRepo.CommitReservation(ctx, orderID, []*outbox.Message{committedMessage})
+	if err != nil {
+		return nil, reservationRepositoryError(err, "an error occured while committing reservation, please try again later")
+	}
+	return reservation, nil
+}
+
+// ReleaseReservation gives back the stock held by the reservation of the
+// provided order, only the user that made the reservation can release it.
+func (s *InventoryServiceImpl) ReleaseReservation(ctx context.Context, jwtToken, orderID string) (*inventory.Reservation, error) {
+	ctx, span := s.tracer.Start(ctx, "ReleaseReservation")
+	defer span.End()
+	tracing.SetAttribute(span, "param.orderID", orderID)
+	_, err := s.getUserReservation(ctx, span, jwtToken, orderID)
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := s.inventoryRepo.ReleaseReservation(ctx, orderID, inventory.ReservationReleased, nil)
+	if err != nil {
+		return nil, reservationRepositoryError(err, "an error occured while releasing reservation, please try again later")
+	}
+	return reservation, nil
+}
+
+// getUserReservation retrieves the reservation of the provided order and
+// makes sure it was made by the user the jwt token belongs to.
+func (s *InventoryServiceImpl) getUserReservation(ctx context.Context, span trace.Span, jwtToken, orderID string) (*inventory.Reservation, error) {
+	if orderID == "" {
+		return nil, NewInvalidArgumentError("orderId must be provided", FieldViolation{
+			Field: "orderId", Description: "orderId must be provided",
+		})
+	}
+	userResponse, err := s.userServiceClient.GetUserFromJWT(ctx, &proto.GetUserFromJWTInput{JwtToken: jwtToken})
+	if err != nil {
+		tracing.RecordError(span, err, "retrieving user details from jwt")
+		return nil, userServiceError(err)
+	}
+	reservation, err := s.inventoryRepo.GetReservation(ctx, orderID)
+	if err != nil {
+		return nil, reservationRepositoryError(err, "an error occured while retrieving reservation, please try again later")
+	}
+	if reservation.UserID != userResponse.User.Id {
+		tracing.AddEvent(span, "user does not own reservation", attribute.String("user.id", userResponse.User.Id))
+		span.SetStatus(codes.Error, "user does not own reservation")
+		return nil, NewPermissionDeniedError("NOT_RESERVATION_OWNER", "you are not allowed to modify this reservation")
+	}
+	return reservation, nil
+}
+
+// ReleaseExpiredReservations releases the pending reservations that have
+// expired and adds them to the outbox to be published, it returns the
+// number of reservations that were released.
+func (s *InventoryServiceImpl) ReleaseExpiredReservations(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "ReleaseExpiredReservations")
+	defer span.End()
+
+	released := 0
+	for {
+		reservations, err := s.inventoryRepo.ListExpiredReservations(ctx, time.Now(), expiredReservationsBatchSize)
+		if err != nil {
+			return released, err
+		}
+		for _, reservation := range reservations {
+			expired := events.NewReservationEvent(reservation)
+			expired.Status = string(inventory.ReservationExpired)
+			expiredMessage, err := newEventMessage(span, events.ReservationExpired, expired)
+			if err != nil {
+				return released, err
+			}
+			_, err = s.inventoryRepo.ReleaseReservation(
+				ctx, reservation.OrderID, inventory.ReservationExpired, []*outbox.Message{expiredMessage},
+			)
+			// the reservation was committed, released or expired by
+			// another replica after it was listed.
+			if errors.Is(err, inventory.ErrReservationNotPending) {
+				continue
+			}
+			if err != nil {
+				return released, err
+			}
+			released++
+		}
+		if len(reservations) < expiredReservationsBatchSize {
+			tracing.SetAttribute(span, "released.count", released)
+			return released, nil
+		}
+	}
+}
+
+// RunReservationSweeper releases expired reservations every interval until
+// ctx is done.
+func (s *InventoryServiceImpl) RunReservationSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// errors are recorded on the span and the sweep is retried on
+			// the next tick.
+			s.ReleaseExpiredReservations(ctx)
+		}
+	}
+}
+
+// PlaceOrder sells the stock of the items of an order placed by the
+// checkout service and adds their units to the sales of their products,
+// placing an order again does nothing since order events can be delivered
+// more than once.
+func (s *InventoryServiceImpl) PlaceOrder(ctx context.Context, orderID, userID string, items []inventory.OrderItem) error {
+	ctx, span := s.tracer.Start(ctx, "PlaceOrder")
+	defer span.End()
+	tracing.SetAttribute(span, "param.orderID", orderID)
+	tracing.SetAttribute(span, "param.items.count", len(items))
+	if violations := validateOrder(orderID, items); len(violations) > 0 {
+		return NewInvalidArgumentError("order is invalid", violations...)
+	}
+	// the products of an order can be deleted or unpublished after it was
+	// placed, their stock is sold all the same.
+	for i := range items {
+		product, err := s.getStockedProduct(ctx, items[i].Sku, true)
+		if err != nil {
+			return err
+		}
+		items[i].ProductSku = product.Sku
+	}
+	err := s.inventoryRepo.PlaceOrder(ctx, &inventory.Order{ID: orderID, UserID: userID, Items: items})
+	if errors.Is(err, inventory.ErrOrderExists) {
+		span.AddEvent("order has already been placed or cancelled")
+		return nil
+	}
+	if err != nil {
+		return NewUnavailableError("an error occured while placing order, please try again later", err)
+	}
+	return nil
+}
+
+// validateOrder returns the violations of an invalid order.
+func validateOrder(orderID string, items []inventory.OrderItem) []FieldViolation {
+	violations := validateOrderID(orderID)
+	if len(items) == 0 {
+		violations = append(violations, FieldViolation{Field: "items", Description: "at least one item is required"})
+	} else if len(items) > maxOrderItems {
+		violations = append(violations, FieldViolation{
+			Field: "items", Description: fmt.Sprintf("an order must not have more than %d items", maxOrderItems),
+		})
+	}
+	for i, item := range items {
+		field := fmt.Sprintf("items[%d]", i)
+		if item.Sku == "" {
+			violations = append(violations, FieldViolation{Field: field + ".sku", Description: "sku is required"})
+		}
+		if item.Quantity <= 0 {
+			violations = append(violations, FieldViolation{Field: field + ".quantity", Description: "quantity must be greater than 0"})
+		}
+	}
+	return violations
+}
+
+// CancelOrder gives back the stock sold by an order cancelled by the
+// checkout service and removes its units from the sales of their
+// products, cancelling an order again does nothing.
+func (s *InventoryServiceImpl) CancelOrder(ctx context.Context, orderID string) error {
+	ctx, span := s.tracer.Start(ctx, "CancelOrder")
+	defer span.End()
+	tracing.SetAttribute(span, "param.orderID", orderID)
+	if violations := validateOrderID(orderID); len(violations) > 0 {
+		return NewInvalidArgumentError("order is invalid", violations...)
+	}
+	_, err := s.inventoryRepo.CancelOrder(ctx, orderID)
+	if errors.Is(err, inventory.ErrOrderCancelled) {
+		span.AddEvent("order has already been cancelled")
+		return nil
+	}
+	if err != nil {
+		return NewUnavailableError("an error occured while cancelling order, please try again later", err)
+	}
+	return nil
+}
+
+// reservationRepositoryError converts an error returned by the inventory
+// repository while handling a reservation to a service error.
+func reservationRepositoryError(err error, message string) *Error {
+	var shortage *inventory.InsufficientStockError
+	switch {
+	case errors.As(err, &shortage):
+		return NewFailedPreconditionError("INSUFFICIENT_STOCK", fmt.Sprintf("there is not enough stock of %s", shortage.Sku))
+	case errors.Is(err, inventory.ErrReservationNotFound):
+		return NewNotFoundError("RESERVATION_NOT_FOUND", "reservation does not exist")
+	case errors.Is(err, inventory.ErrReservationNotPending):
+		return NewFailedPreconditionError("RESERVATION_NOT_PENDING", "reservation has already been committed, released or expired")
+	case errors.Is(err, inventory.ErrReservationExpired):
+		return NewFailedPreconditionError("RESERVATION_EXPIRED", "reservation has expired")
+	}
+	return NewUnavailableError(message, err)
+}
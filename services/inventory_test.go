@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/inventory"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/mocks"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newInventoryTestProductRepo() *mocks.Repository {
+	productRepo := &mocks.Repository{}
+	// deleted.sku is a product that was deleted, it is only found when
+	// the deleted products are included.
+	productRepo.On("GetProductBySKU", mock.Anything, "deleted.sku", true).
+		Return(&products.Product{ID: 4, Sku: "deleted.sku", MerchantID: "valid.user"}, nil)
+	for _, includeDeleted := range []bool{false, true} {
+		productRepo.On("GetProductBySKU", mock.Anything, "product.sku", includeDeleted).
+			Return(&products.Product{ID: 1, Sku: "product.sku", MerchantID: "valid.user"}, nil)
+		productRepo.On("GetProductBySKU", mock.Anything, "other.sku", includeDeleted).
+			Return(&products.Product{ID: 2, Sku: "other.sku", MerchantID: "other.user"}, nil)
+		productRepo.On("GetProductBySKU", mock.Anything, "variants.sku", includeDeleted).
+			Return(&products.Product{ID: 3, Sku: "variants.sku", MerchantID: "valid.user", Variants: []products.Variant{
+				{ID: 1, ProductID: 3, Sku: "variant.sku"},
+			}}, nil)
+		productRepo.On("GetProductBySKU", mock.Anything, mock.Anything, includeDeleted).Return(nil, products.ErrProductNotFound)
+		productRepo.On("GetProductByVariantSKU", mock.Anything, "variant.sku", includeDeleted).
+			Return(&products.Product{ID: 3, Sku: "variants.sku", MerchantID: "valid.user"}, nil)
+		productRepo.On("GetProductByVariantSKU", mock.Anything, mock.Anything, includeDeleted).Return(nil, products.ErrProductNotFound)
+	}
+	return productRepo
+}
+
+func TestInventoryServiceImpl_AdjustStock(t *testing.T) {
+	productRepo := newInventoryTestProductRepo()
+	inventoryRepo := &mocks.StockRepository{}
+	inventoryRepo.On("AdjustStock", mock.Anything, &inventory.Adjustment{
+		Sku: "product.sku", Kind: inventory.KindSell, Quantity: -10, Reason: "sold in store", Actor: "valid.user",
+	}).Return(nil, inventory.ErrInsufficientStock)
+	inventoryRepo.On("AdjustStock", mock.Anything, &inventory.Adjustment{
+		Sku: "product.sku", Kind: inventory.KindReturn, Quantity: 1, Reason: "returned", Actor: "valid.user",
+	}).Return(nil, errors.New("an error occured"))
+	inventoryRepo.On("AdjustStock", mock.Anything, &inventory.Adjustment{
+		Sku: "variant.sku", Kind: inventory.KindReceive, Quantity: 5, Reason: "restock", Actor: "valid.user",
+	}).Return(&inventory.StockLevel{Sku: "variant.sku", OnHand: 5}, nil)
+	inventoryRepo.On("AdjustStock", mock.Anything, &inventory.Adjustment{
+		Sku: "product.sku", Kind: inventory.KindCorrection, Quantity: -2, Reason: "stock count", Actor: "valid.user",
+	}).Return(&inventory.StockLevel{Sku: "product.sku", OnHand: 8}, nil)
+
+	userServiceClient := &mocks.UserServiceClient{}
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "invalidJwt"}).
+		Return(nil, errors.New("invalid jwt"))
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "validJwt"}).
+		Return(&proto.GetUserFromJWTResponse{User: &proto.User{Id: "valid.user"}}, nil)
+
+	type args struct {
+		jwtToken string
+		sku      string
+		kind     inventory.AdjustmentKind
+		quantity int64
+		reason   string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     *inventory.StockLevel
+		wantCode ErrorCode
+	}{
+		{
+			name:     "empty sku",
+			args:     args{jwtToken: "validJwt", kind: inventory.KindReceive, quantity: 1, reason: "restock"},
+			wantCode: ErrorCodeInvalidArgument,
+		},
+		{
+			name:     "unknown kind",
+			args:     args{jwtToken: "validJwt", sku: "product.sku", kind: "stolen", quantity: 1, reason: "restock"},
+			wantCode: ErrorCodeInvalidArgument,
+		},
+		{
+			name:     "negative receive quantity",
+			args:     args{jwtToken: "validJwt", sku: "product.sku", kind: inventory.KindReceive, quantity: -1, reason: "restock"},
+			wantCode: ErrorCodeInvalidArgument,
+		},
+		{
+			name:     "zero correction",
+			args:     args{jwtToken: "validJwt", sku: "product.sku", kind: inventory.KindCorrection, reason: "stock count"},
+			wantCode: ErrorCodeInvalidArgument,
+		},
+		{
+			name:     "missing reason",
+			args:     args{jwtToken: "validJwt", sku: "product.sku", kind: inventory.KindReceive, quantity: 1},
+			wantCode: ErrorCodeInvalidArgument,
+		},
+		{
+			name:     "invalid jwt token",
+			args:     args{jwtToken: "invalidJwt", sku: "product.sku", kind: inventory.KindReceive, quantity: 1, reason: "restock"},
+			wantCode: ErrorCodeUnauthenticated,
+		},
+		{
+			name:     "unknown sku",
+			args:     args{jwtToken: "validJwt", sku: "unknown.sku", kind: inventory.KindReceive, quantity: 1, reason: "restock"},
+			wantCode: ErrorCodeNotFound,
+		},
+		{
+			name:     "product owned by another merchant",
+			args:     args{jwtToken: "validJwt", sku: "other.sku", kind: inventory.KindReceive, quantity: 1, reason: "restock"},
+			wantCode: ErrorCodePermissionDenied,
+		},
+		{
+			name:     "product with variants",
+			args:     args{jwtToken: "validJwt", sku: "variants.sku", kind: inventory.KindReceive, quantity: 1, reason: "restock"},
+			wantCode: ErrorCodeFailedPrecondition,
+		},
+		{
+			name:     "insufficient stock",
+			args:     args{jwtToken: "validJwt", sku: "product.sku", kind: inventory.KindSell, quantity: 10, reason: "sold in store"},
+			wantCode: ErrorCodeFailedPrecondition,
+		},
+		{
+			name:     "AdjustStock repo implementation with error",
+			args:     args{jwtToken: "validJwt", sku: "product.sku", kind: inventory.KindReturn, quantity: 1, reason: "returned"},
+			wantCode: ErrorCodeUnavailable,
+		},
+		{
+			name: "variant sku",
+			args: args{jwtToken: "validJwt", sku: "variant.sku", kind: inventory.KindReceive, quantity: 5, reason: "restock"},
+			want: &inventory.StockLevel{Sku: "variant.sku", OnHand: 5},
+		},
+		{
+			name: "negative correction",
+			args: args{jwtToken: "validJwt", sku: "product.sku", kind: inventory.KindCorrection, quantity: -2, reason: "stock count"},
+			want: &inventory.StockLevel{Sku: "product.sku", OnHand: 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewInventoryService(inventoryRepo, productRepo, userServiceClient, trace.NewNoopTracerProvider().Tracer(""), time.Minute)
+			got, err := s.AdjustStock(context.Background(), tt.args.jwtToken, tt.args.sku, tt.args.kind, tt.args.quantity, tt.args.reason)
+			var serviceErr *Error
+			if tt.wantCode != "" && (!errors.As(err, &serviceErr) || serviceErr.Code != tt.wantCode) {
+				t.Errorf("InventoryServiceImpl.AdjustStock() error = %v, wantCode %v", err, tt.wantCode)
+				return
+			}
+			if tt.wantCode == "" && err != nil {
+				t.Errorf("InventoryServiceImpl.AdjustStock() unexpected error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InventoryServiceImpl.AdjustStock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryServiceImpl_GetStock(t *testing.T) {
+	productRepo := newInventoryTestProductRepo()
+	inventoryRepo := &mocks.StockRepository{}
+	inventoryRepo.On("GetStockLevel", mock.Anything, "product.sku").Return(&inventory.StockLevel{Sku: "product.sku", OnHand: 4}, nil)
+	inventoryRepo.On("GetStockLevel", mock.Anything, "other.sku").Return(nil, errors.New("an error occured"))
+	inventoryRepo.On("GetStockLevel", mock.Anything, mock.Anything).Return(nil, inventory.ErrStockLevelNotFound)
+
+	tests := []struct {
+		name     string
+		sku      string
+		want     *inventory.StockLevel
+		wantCode ErrorCode
+	}{
+		{name: "empty sku", wantCode: ErrorCodeInvalidArgument},
+		{name: "GetStockLevel repo implementation with error", sku: "other.sku", wantCode: ErrorCodeUnavailable},
+		{name: "unknown sku", sku: "unknown.sku", wantCode: ErrorCodeNotFound},
+		{name: "product with variants", sku: "variants.sku", wantCode: ErrorCodeFailedPrecondition},
+		{name: "stocked sku", sku: "product.sku", want: &inventory.StockLevel{Sku: "product.sku", OnHand: 4}},
+		{name: "variant that has never been stocked", sku: "variant.sku", want: &inventory.StockLevel{Sku: "variant.sku"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewInventoryService(inventoryRepo, productRepo, nil, trace.NewNoopTracerProvider().Tracer(""), time.Minute)
+			got, err := s.GetStock(context.Background(), tt.sku)
+			var serviceErr *Error
+			if tt.wantCode != "" && (!errors.As(err, &serviceErr) || serviceErr.Code != tt.wantCode) {
+				t.Errorf("InventoryServiceImpl.GetStock() error = %v, wantCode %v", err, tt.wantCode)
+				return
+			}
+			if tt.wantCode == "" && err != nil {
+				t.Errorf("InventoryServiceImpl.GetStock() unexpected error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InventoryServiceImpl.GetStock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryServiceImpl_ReserveStock(t *testing.T) {
+	inventoryRepo := &mocks.StockRepository{}
+	isReservation := func(orderID string) interface{} {
+		return mock.MatchedBy(func(r *inventory.Reservation) bool { return r.OrderID == orderID })
+	}
+	inventoryRepo.On("ReserveStock", mock.Anything, isReservation("order.shortage")).
+		Return(&inventory.InsufficientStockError{Sku: "sku.1", Available: 1, Requested: 2})
+	inventoryRepo.On("ReserveStock", mock.Anything, isReservation("order.error")).Return(errors.New("an error occured"))
+	inventoryRepo.On("ReserveStock", mock.Anything, isReservation("order.retry")).Return(inventory.ErrReservationExists)
+	inventoryRepo.On("ReserveStock", mock.Anything, isReservation("order.taken")).Return(inventory.ErrReservationExists)
+	inventoryRepo.On("ReserveStock", mock.Anything, isReservation("order.expired")).Return(inventory.ErrReservationExists)
+	inventoryRepo.On("ReserveStock", mock.Anything, isReservation("order.valid")).Return(nil)
+	inventoryRepo.On("GetReservation", mock.Anything, "order.retry").Return(&inventory.Reservation{
+		OrderID: "order.retry", UserID: "valid.user", Status: inventory.ReservationPending, ExpiresAt: time.Now().Add(time.Minute),
+	}, nil)
+	inventoryRepo.On("GetReservation", mock.Anything, "order.expired").Return(&inventory.Reservation{
+		OrderID: "order.expired", UserID: "valid.user", Status: inventory.ReservationPending, ExpiresAt: time.Now().Add(-time.Minute),
+	}, nil)
+	inventoryRepo.On("GetReservation", mock.Anything, "order.taken").Return(&inventory.Reservation{
+		OrderID: "order.taken", UserID: "other.user", Status: inventory.ReservationPending,
+	}, nil)
+
+	userServiceClient := &mocks.UserServiceClient{}
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "invalidJwt"}).
+		Return(nil, errors.New("invalid jwt"))
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "validJwt"}).
+		Return(&proto.GetUserFromJWTResponse{User: &proto.User{Id: "valid.user"}}, nil)
+
+	items := []inventory.ReservationItem{{Sku: "sku.1", Quantity: 2}}
+	tests := []struct {
+		name        string
+		jwtToken    string
+		orderID     string
+		items       []inventory.ReservationItem
+		wantOrderID string
+		wantCode    ErrorCode
+	}{
+		{name: "empty order id", jwtToken: "validJwt", items: items, wantCode: ErrorCodeInvalidArgument},
+		{name: "no items", jwtToken: "validJwt", orderID: "order.valid", wantCode: ErrorCodeInvalidArgument},
+		{
+			name: "invalid item", jwtToken: "validJwt", orderID: "order.valid",
+			items: []inventory.ReservationItem{{Sku: "sku.1"}}, wantCode: ErrorCodeInvalidArgument,
+		},
+		{name: "invalid jwt token", jwtToken: "invalidJwt", orderID: "order.valid", items: items, wantCode: ErrorCodeUnauthenticated},
+		{name: "insufficient stock", jwtToken: "validJwt", orderID: "order.shortage", items: items, wantCode: ErrorCodeFailedPrecondition},
+		{name: "ReserveStock repo implementation with error", jwtToken: "validJwt", orderID: "order.error", items: items, wantCode: ErrorCodeUnavailable},
+		{name: "order reserved by another user", jwtToken: "validJwt", orderID: "order.taken", items: items, wantCode: ErrorCodeFailedPrecondition},
+		{name: "retried expired reservation", jwtToken: "validJwt", orderID: "order.expired", items: items, wantCode: ErrorCodeFailedPrecondition},
+		{name: "retried reservation", jwtToken: "validJwt", orderID: "order.retry", items: items, wantOrderID: "order.retry"},
+		{name: "valid reservation", jwtToken: "validJwt", orderID: "order.valid", items: items, wantOrderID: "order.valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewInventoryService(inventoryRepo, nil, userServiceClient, trace.NewNoopTracerProvider().Tracer(""), time.Minute)
+			got, err := s.ReserveStock(context.Background(), tt.jwtToken, tt.orderID, tt.items)
+			var serviceErr *Error
+			if tt.wantCode != "" {
+				if !errors.As(err, &serviceErr) || serviceErr.Code != tt.wantCode {
+					t.Errorf("InventoryServiceImpl.ReserveStock() error = %v, wantCode %v", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("InventoryServiceImpl.ReserveStock() unexpected error = %v", err)
+				return
+			}
+			if got.OrderID != tt.wantOrderID || got.UserID != "valid.user" {
+				t.Errorf("InventoryServiceImpl.ReserveStock() = %v, want order %v of valid.user", got, tt.wantOrderID)
+			}
+		})
+	}
+}
+
+func TestInventoryServiceImpl_CommitReservation(t *testing.T) {
+	inventoryRepo := &mocks.StockRepository{}
+	for _, orderID := range []string{"order.valid", "order.committed", "order.error"} {
+		inventoryRepo.On("GetReservation", mock.Anything, orderID).
+			Return(&inventory.Reservation{OrderID: orderID, UserID: "valid.user"}, nil)
+	}
+	inventoryRepo.On("GetReservation", mock.Anything, "order.other").
+		Return(&inventory.Reservation{OrderID: "order.other", UserID: "other.user"}, nil)
+	inventoryRepo.On("GetReservation", mock.Anything, mock.Anything).Return(nil, inventory.ErrReservationNotFound)
+	inventoryRepo.On("CommitReservation", mock.Anything, "order.committed", eventSubjects("inventory.ReservationCommitted")).
+		Return(nil, inventory.ErrReservationNotPending)
+	inventoryRepo.On("CommitReservation", mock.Anything, "order.error", eventSubjects("inventory.ReservationCommitted")).
+		Return(nil, errors.New("an error occured"))
+	inventoryRepo.On("CommitReservation", mock.Anything, "order.valid", eventSubjects("inventory.ReservationCommitted")).
+		Return(&inventory.Reservation{OrderID: "order.valid", UserID: "valid.user", Status: inventory.ReservationCommitted}, nil)
+
+	userServiceClient := &mocks.UserServiceClient{}
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "validJwt"}).
+		Return(&proto.GetUserFromJWTResponse{User: &proto.User{Id: "valid.user"}}, nil)
+
+	tests := []struct {
+		name     string
+		orderID  string
+		want     *inventory.Reservation
+		wantCode ErrorCode
+	}{
+		{name: "empty order id", wantCode: ErrorCodeInvalidArgument},
+		{name: "unknown order", orderID: "order.unknown", wantCode: ErrorCodeNotFound},
+		{name: "reservation of another user", orderID: "order.other", wantCode: ErrorCodePermissionDenied},
+		{name: "reservation that is not pending", orderID: "order.committed", wantCode: ErrorCodeFailedPrecondition},
+		{name: "CommitReservation repo implementation with error", orderID: "order.error", wantCode: ErrorCodeUnavailable},
+		{
+			name: "pending reservation", orderID: "order.valid",
+			want: &inventory.Reservation{OrderID: "order.valid", UserID: "valid.user", Status: inventory.ReservationCommitted},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewInventoryService(inventoryRepo, nil, userServiceClient, trace.NewNoopTracerProvider().Tracer(""), time.Minute)
+			got, err := s.CommitReservation(context.Background(), "validJwt", tt.orderID)
+			var serviceErr *Error
+			if tt.wantCode != "" && (!errors.As(err, &serviceErr) || serviceErr.Code != tt.wantCode) {
+				t.Errorf("InventoryServiceImpl.CommitReservation() error = %v, wantCode %v", err, tt.wantCode)
+				return
+			}
+			if tt.wantCode == "" && err != nil {
+				t.Errorf("InventoryServiceImpl.CommitReservation() unexpected error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InventoryServiceImpl.CommitReservation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryServiceImpl_ReleaseExpiredReservations(t *testing.T) {
+	inventoryRepo := &mocks.StockRepository{}
+	inventoryRepo.On("ListExpiredReservations", mock.Anything, mock.Anything, expiredReservationsBatchSize).
+		Return([]*inventory.Reservation{{OrderID: "order.1"}, {OrderID: "order.2"}, {OrderID: "order.3"}}, nil)
+	inventoryRepo.On("ReleaseReservation", mock.Anything, "order.1", inventory.ReservationExpired, eventSubjects("inventory.ReservationExpired")).
+		Return(&inventory.Reservation{OrderID: "order.1", Status: inventory.ReservationExpired}, nil)
+	inventoryRepo.On("ReleaseReservation", mock.Anything, "order.2", inventory.ReservationExpired, eventSubjects("inventory.ReservationExpired")).
+		Return(nil, inventory.ErrReservationNotPending)
+	inventoryRepo.On("ReleaseReservation", mock.Anything, "order.3", inventory.ReservationExpired, eventSubjects("inventory.ReservationExpired")).
+		Return(&inventory.Reservation{OrderID: "order.3", Status: inventory.ReservationExpired}, nil)
+
+	s := NewInventoryService(inventoryRepo, nil, nil, trace.NewNoopTracerProvider().Tracer(""), time.Minute)
+	got, err := s.ReleaseExpiredReservations(context.Background())
+	if err != nil {
+		t.Errorf("InventoryServiceImpl.ReleaseExpiredReservations() unexpected error = %v", err)
+		return
+	}
+	if got != 2 {
+		t.Errorf("InventoryServiceImpl.ReleaseExpiredReservations() = %v, want %v", got, 2)
+	}
+}
+
+func TestInventoryServiceImpl_PlaceOrder(t *testing.T) {
+	productRepo := newInventoryTestProductRepo()
+	inventoryRepo := &mocks.StockRepository{}
+	inventoryRepo.On("PlaceOrder", mock.Anything, &inventory.Order{ID: "order.placed", UserID: "valid.user", Items: []inventory.OrderItem{
+		{Sku: "product.sku", ProductSku: "product.sku", Quantity: 1},
+	}}).Return(inventory.ErrOrderExists)
+	inventoryRepo.On("PlaceOrder", mock.Anything, &inventory.Order{ID: "order.error", UserID: "valid.user", Items: []inventory.OrderItem{
+		{Sku: "product.sku", ProductSku: "product.sku", Quantity: 1},
+	}}).Return(errors.New("an error occured"))
+	inventoryRepo.On("PlaceOrder", mock.Anything, &inventory.Order{ID: "order.valid", UserID: "valid.user", Items: []inventory.OrderItem{
+		{Sku: "product.sku", ProductSku: "product.sku", Quantity: 1},
+		{Sku: "variant.sku", ProductSku: "variants.sku", Quantity: 2},
+	}}).Return(nil)
+	inventoryRepo.On("PlaceOrder", mock.Anything, &inventory.Order{ID: "order.deleted", UserID: "valid.user", Items: []inventory.OrderItem{
+		{Sku: "deleted.sku", ProductSku: "deleted.sku", Quantity: 1},
+	}}).Return(nil)
+
+	tests := []struct {
+		name     string
+		orderID  string
+		items    []inventory.OrderItem
+		wantCode ErrorCode
+	}{
+		{name: "empty order id", items: []inventory.OrderItem{{Sku: "product.sku", Quantity: 1}}, wantCode: ErrorCodeInvalidArgument},
+		{name: "order without items", orderID: "order.valid", wantCode: ErrorCodeInvalidArgument},
+		{name: "item without quantity", orderID: "order.valid", items: []inventory.OrderItem{{Sku: "product.sku"}}, wantCode: ErrorCodeInvalidArgument},
+		{name: "unknown sku", orderID: "order.valid", items: []inventory.OrderItem{{Sku: "unknown.sku", Quantity: 1}}, wantCode: ErrorCodeNotFound},
+		{
+			name: "product with variants", orderID: "order.valid",
+			items: []inventory.OrderItem{{Sku: "variants.sku", Quantity: 1}}, wantCode: ErrorCodeFailedPrecondition,
+		},
+		{
+			name: "PlaceOrder repo implementation with error", orderID: "order.error",
+			items: []inventory.OrderItem{{Sku: "product.sku", Quantity: 1}}, wantCode: ErrorCodeUnavailable,
+		},
+		{name: "order that has already been placed", orderID: "order.placed", items: []inventory.OrderItem{{Sku: "product.sku", Quantity: 1}}},
+		{
+			name: "valid order", orderID: "order.valid",
+			items: []inventory.OrderItem{{Sku: "product.sku", Quantity: 1}, {Sku: "variant.sku", Quantity: 2}},
+		},
+		{
+			name: "product deleted after the order", orderID: "order.deleted",
+			items: []inventory.OrderItem{{Sku: "deleted.sku", Quantity: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewInventoryService(inventoryRepo, productRepo, nil, trace.NewNoopTracerProvider().Tracer(""), time.Minute)
+			err := s.PlaceOrder(context.Background(), tt.orderID, "valid.user", tt.items)
+			var serviceErr *Error
+			if tt.wantCode != "" && (!errors.As(err, &serviceErr) || serviceErr.Code != tt.wantCode) {
+				t.Errorf("InventoryServiceImpl.PlaceOrder() error = %v, wantCode %v", err, tt.wantCode)
+				return
+			}
+			if tt.wantCode == "" && err != nil {
+				t.Errorf("InventoryServiceImpl.PlaceOrder() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestInventoryServiceImpl_CancelOrder(t *testing.T) {
+	inventoryRepo := &mocks.StockRepository{}
+	inventoryRepo.On("CancelOrder", mock.Anything, "order.cancelled").Return(nil, inventory.ErrOrderCancelled)
+	inventoryRepo.On("CancelOrder", mock.Anything, "order.error").Return(nil, errors.New("an error occured"))
+	inventoryRepo.On("CancelOrder", mock.Anything, "order.valid").
+		Return(&inventory.Order{ID: "order.valid", Status: inventory.OrderCancelled}, nil)
+
+	tests := []struct {
+		name     string
+		orderID  string
+		wantCode ErrorCode
+	}{
+		{name: "empty order id", wantCode: ErrorCodeInvalidArgument},
+		{name: "CancelOrder repo implementation with error", orderID: "order.error", wantCode: ErrorCodeUnavailable},
+		{name: "order that has already been cancelled", orderID: "order.cancelled"},
+		{name: "valid order", orderID: "order.valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewInventoryService(inventoryRepo, nil, nil, trace.NewNoopTracerProvider().Tracer(""), time.Minute)
+			err := s.CancelOrder(context.Background(), tt.orderID)
+			var serviceErr *Error
+			if tt.wantCode != "" && (!errors.As(err, &serviceErr) || serviceErr.Code != tt.wantCode) {
+				t.Errorf("InventoryServiceImpl.CancelOrder() error = %v, wantCode %v", err, tt.wantCode)
+				return
+			}
+			if tt.wantCode == "" && err != nil {
+				t.Errorf("InventoryServiceImpl.CancelOrder() unexpected error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/events"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// productEventFields maps the internal product field names to their names
+// in product events.
+var productEventFields = map[string]string{
+	"Name":        "name",
+	"Description": "description",
+	"Category":    "category",
+	"Brand":       "brand",
+	"Price":       "price",
+	"ImageURL":    "imageUrl",
+}
+
+// domainEventMessage returns the outbox message of event, the span context
+// is added to the event so consumers can continue the trace.
+func (s *ProductServiceImpl) domainEventMessage(span trace.Span, event *proto.ProductEvent) (*outbox.Message, error) {
+	traceContext := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(trace.ContextWithSpan(context.Background(), span), traceContext)
+	if len(traceContext) > 0 {
+		event.TraceContext = traceContext
+	}
+	return newEventMessage(span, event.Type, event)
+}
+
+// productUpdateEventMessages returns the messages of the events about the
+// update of before to after, there are no events when nothing changed.
+func (s *ProductServiceImpl) productUpdateEventMessages(span trace.Span, actor string, before, after *products.Product, fields []string) ([]*outbox.Message, error) {
+	changedFields := changedProductFields(before, after, fields)
+	if len(changedFields) == 0 {
+		return nil, nil
+	}
+	updated := events.NewProductEvent(events.ProductUpdated, actor, after)
+	updated.ChangedFields = changedFields
+	updatedMessage, err := s.domainEventMessage(span, updated)
+	if err != nil {
+		return nil, err
+	}
+	messages := []*outbox.Message{updatedMessage}
+	if before.Price == after.Price {
+		return messages, nil
+	}
+	priceChanged := events.NewProductEvent(events.ProductPriceChanged, actor, after)
+	priceChanged.ChangedFields = []string{productEventFields["Price"]}
+	priceChanged.PreviousPrice = events.NewMoney(before.Price)
+	priceChangedMessage, err := s.domainEventMessage(span, priceChanged)
+	if err != nil {
+		return nil, err
+	}
+	return append(messages, priceChangedMessage), nil
+}
+
+// changedProductFields returns the event names of the fields whose value
+// is different in before and after.
+func changedProductFields(before, after *products.Product, fields []string) []string {
+	changed := []string{}
+	for _, field := range fields {
+		if productFieldChanged(before, after, field) {
+			changed = append(changed, productEventFields[field])
+		}
+	}
+	return changed
+}
+
+func productFieldChanged(before, after *products.Product, field string) bool {
+	switch field {
+	case "Name":
+		return before.Name != after.Name
+	case "Description":
+		return before.Description != after.Description
+	case "Category":
+		return before.Category != after.Category
+	case "Brand":
+		return before.Brand != after.Brand
+	case "Price":
+		return before.Price != after.Price
+	case "ImageURL":
+		return before.ImageURL != after.ImageURL
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Test_changedProductFields(t *testing.T) {
+	before := &products.Product{
+		Name:  "Product 1",
+		Brand: "Nike",
+		Price: products.Money{Amount: 10000, Currency: "USD"},
+	}
+	tests := []struct {
+		name   string
+		after  *products.Product
+		fields []string
+		want   []string
+	}{
+		{
+			name:   "no changes",
+			after:  &products.Product{Name: "Product 1", Brand: "Nike", Price: products.Money{Amount: 10000, Currency: "USD"}},
+			fields: []string{"Name", "Brand"},
+			want:   []string{},
+		},
+		{
+			name:   "unchanged fields are ignored",
+			after:  &products.Product{Name: "Product 2", Brand: "Nike", Price: products.Money{Amount: 10000, Currency: "USD"}},
+			fields: []string{"Name", "Brand"},
+			want:   []string{"name"},
+		},
+		{
+			name:   "changed fields not updated are ignored",
+			after:  &products.Product{Name: "Product 2", Brand: "Adidas", Price: products.Money{Amount: 10000, Currency: "USD"}},
+			fields: []string{"Brand"},
+			want:   []string{"brand"},
+		},
+		{
+			name:   "price and image url",
+			after:  &products.Product{Name: "Product 1", Brand: "Nike", Price: products.Money{Amount: 10000, Currency: "EUR"}, ImageURL: "https://example.com/1.png"},
+			fields: []string{"Price", "ImageURL"},
+			want:   []string{"price", "imageUrl"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changedProductFields(before, tt.after, tt.fields); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changedProductFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServiceImpl_productUpdateEventMessages(t *testing.T) {
+	before := &products.Product{Sku: "sku.1", Name: "Product 1", Price: products.Money{Amount: 10000, Currency: "USD"}}
+	tests := []struct {
+		name         string
+		after        *products.Product
+		fields       []string
+		wantSubjects []string
+	}{
+		{
+			name:   "nothing changed",
+			after:  &products.Product{Sku: "sku.1", Name: "Product 1", Price: products.Money{Amount: 10000, Currency: "USD"}},
+			fields: []string{"Name"},
+		},
+		{
+			name:         "name changed",
+			after:        &products.Product{Sku: "sku.1", Name: "Product 2", Price: products.Money{Amount: 10000, Currency: "USD"}},
+			fields:       []string{"Name"},
+			wantSubjects: []string{"products.v1.updated"},
+		},
+		{
+			name:         "price changed",
+			after:        &products.Product{Sku: "sku.1", Name: "Product 1", Price: products.Money{Amount: 9000, Currency: "USD"}},
+			fields:       []string{"Price"},
+			wantSubjects: []string{"products.v1.updated", "products.v1.price_changed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := trace.NewNoopTracerProvider().Tracer("")
+			s := NewProductService(nil, nil, tracer, nil, nil)
+			_, span := tracer.Start(context.Background(), "test")
+			got, err := s.productUpdateEventMessages(span, "user.1", before, tt.after, tt.fields)
+			if err != nil {
+				t.Fatalf("ProductServiceImpl.productUpdateEventMessages() error = %v", err)
+			}
+			var gotSubjects []string
+			for _, message := range got {
+				gotSubjects = append(gotSubjects, message.Subject)
+			}
+			if !reflect.DeepEqual(gotSubjects, tt.wantSubjects) {
+				t.Errorf("ProductServiceImpl.productUpdateEventMessages() subjects = %v, want %v", gotSubjects, tt.wantSubjects)
+			}
+		})
+	}
+}
@@ -2,119 +2,549 @@ package services
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
 
-	"github.com/nats-io/nats.go"
-	"github.com/nats-io/not.go"
-	"github.com/opentracing/opentracing-go"
-	"github.com/opentracing/opentracing-go/ext"
-	"github.com/opentracing/opentracing-go/log"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/events"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/outbox"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
+	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ProductService is the interface that describes a product service.
 type ProductService interface {
 	AddProduct(ctx context.Context, jwtToken string, newProduct *products.Product) (*products.Product, error)
-	GetProduct(ctx context.Context, sku string) (*products.Product, error)
+	GetProduct(ctx context.Context, jwtToken, sku string, includeDeleted bool) (*products.Product, error)
+	GetProducts(ctx context.Context, skus []string) ([]*products.Product, error)
+	UpdateProduct(ctx context.Context, jwtToken, sku string, update *products.Product, fields []string) (*products.Product, error)
+	DeleteProduct(ctx context.Context, jwtToken, sku string) (*products.Product, error)
+	RestoreProduct(ctx context.Context, jwtToken, sku string) (*products.Product, error)
+	PurgeProduct(ctx context.Context, jwtToken, sku string) error
+	ListProducts(ctx context.Context, filter products.ListFilter, after string) ([]*products.Product, string, error)
+	GenerateVariants(ctx context.Context, jwtToken, sku string, options []products.Option) (*products.Product, error)
+	UnpublishMerchantProducts(ctx context.Context, merchantID string) (int, error)
 }
 
+const (
+	defaultListProductsLimit = 20
+	maxListProductsLimit     = 100
+	maxGetProductsSKUs       = 100
+	// unpublishBatchSize is the number of products unpublished in a
+	// transaction.
+	unpublishBatchSize = 100
+	// systemActor is the actor of the events about changes the service
+	// makes on its own.
+	systemActor = "product-service"
+)
+
 // ProductServiceImpl is the default implementation for ProductService
 // interface.
 type ProductServiceImpl struct {
 	productRepo       products.Repository
 	userServiceClient proto.UserServiceClient
-	natsConn          *nats.Conn
-	tracer            opentracing.Tracer
+	tracer            trace.Tracer
+	adminIDs          map[string]bool
+	cursorCodec       *products.CursorCodec
 }
 
-// NewProductService returns a new product service object.
+// NewProductService returns a new product service object, adminIDs are the
+// ids of the users allowed to perform admin only actions and cursorCodec
+// is used to sign product list cursors.
 func NewProductService(
 	productRepo products.Repository,
 	userServiceClient proto.UserServiceClient,
-	natsConn *nats.Conn,
-	tracer opentracing.Tracer,
+	tracer trace.Tracer,
+	adminIDs []string,
+	cursorCodec *products.CursorCodec,
 ) *ProductServiceImpl {
+	admins := map[string]bool{}
+	for _, id := range adminIDs {
+		if id != "" {
+			admins[id] = true
+		}
+	}
 	return &ProductServiceImpl{
 		productRepo:       productRepo,
 		userServiceClient: userServiceClient,
-		natsConn:          natsConn,
 		tracer:            tracer,
+		adminIDs:          admins,
+		cursorCodec:       cursorCodec,
 	}
 }
 
 func (s *ProductServiceImpl) AddProduct(ctx context.Context, jwtToken string, newProduct *products.Product) (*products.Product, error) {
-	span, _ := opentracing.StartSpanFromContextWithTracer(ctx, s.tracer, "GetUsers")
-	defer span.Finish()
-	ctx = opentracing.ContextWithSpan(ctx, span)
+	ctx, span := s.tracer.Start(ctx, "GetUsers")
+	defer span.End()
+	if newProduct == nil {
+		return nil, NewInvalidArgumentError("product must be provided", FieldViolation{
+			Field: "product", Description: "product must be provided",
+		})
+	}
+	if fieldErrors := newProduct.Validate(); len(fieldErrors) > 0 {
+		tracing.AddEvent(span, "invalid product", attribute.Int("violations", len(fieldErrors)))
+		return nil, productValidationError(fieldErrors)
+	}
 	userResponse, err := s.userServiceClient.GetUserFromJWT(ctx, &proto.GetUserFromJWTInput{JwtToken: jwtToken})
 	if err != nil {
-		ext.Error.Set(span, true)
-		span.LogFields(log.Error(err), log.Event("retrieving merchant details from jwt"))
-		return nil, errors.New("you are not authenticated")
+		tracing.RecordError(span, err, "retrieving merchant details from jwt")
+		return nil, userServiceError(err)
 	}
-	span.SetTag("merchant", userResponse.User)
+	tracing.SetAttribute(span, "merchant", userResponse.User)
 	newProduct.MerchantID = userResponse.User.Id
-	err = s.productRepo.SaveProduct(ctx, newProduct)
+	newProduct.PrepareNew()
+	emailMessage, err := s.productAddedEmailMessage(span, userResponse.User.Email, newProduct)
+	if err != nil {
+		return nil, err
+	}
+	createdMessage, err := s.domainEventMessage(span, events.NewProductEvent(events.ProductCreated, userResponse.User.Id, newProduct))
 	if err != nil {
-		return nil, errors.New("an error occured while adding product, please try again later")
+		return nil, err
+	}
+	err = s.productRepo.SaveProduct(ctx, newProduct, []*outbox.Message{emailMessage, createdMessage})
+	if err != nil {
+		return nil, NewUnavailableError("an error occured while adding product, please try again later", err)
 	}
-	s.publishProductAddedEmailEvent(span, userResponse.User.Email, newProduct)
 	return newProduct, nil
 }
 
-func (s *ProductServiceImpl) publishProductAddedEmailEvent(span opentracing.Span, userEmail string, product *products.Product) {
-	span = opentracing.StartSpan("publish-product-added-email-event", opentracing.ChildOf(span.Context()))
-	defer span.Finish()
-	natsMessage := map[string]interface{}{
-		"to":      userEmail,
-		"subject": "Product added successfully",
-		"parameters": map[string]string{
+func (s *ProductServiceImpl) productAddedEmailMessage(span trace.Span, userEmail string, product *products.Product) (*outbox.Message, error) {
+	natsMessage := &proto.SendProductAddedEmail{
+		To:      userEmail,
+		Subject: "Product added successfully",
+		Parameters: map[string]string{
 			"productName":        product.Name,
 			"productImageUrl":    product.ImageURL,
 			"productCategory":    product.Category,
-			"productPrice":       fmt.Sprintf("%.2f", product.Price),
+			"productPrice":       product.Price.Decimal(),
+			"productCurrency":    product.Price.Currency,
 			"productDescription": product.Description,
 		},
 	}
-	var traceMsg not.TraceMsg
-	err := s.tracer.Inject(span.Context(), opentracing.Binary, &traceMsg)
+	return newEventMessage(span, "notification.SendProductAddedEmail", natsMessage)
+}
+
+// productLifecycleMessages returns the messages of a product being deleted
+// or restored, the event of legacySubject is kept for the consumers that
+// have not moved to the versioned events yet.
+func (s *ProductServiceImpl) productLifecycleMessages(span trace.Span, legacySubject string, event *proto.ProductEvent) ([]*outbox.Message, error) {
+	legacyMessage, err := newEventMessage(span, legacySubject, &proto.ProductLifecycleChanged{
+		Sku:        event.Product.Sku,
+		MerchantId: event.Product.MerchantId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	eventMessage, err := s.domainEventMessage(span, event)
+	if err != nil {
+		return nil, err
+	}
+	return []*outbox.Message{legacyMessage, eventMessage}, nil
+}
+
+// GetProduct retrieves the product with the provided sku. Soft deleted
+// products are only retrieved when includeDeleted is true and the jwt
+// token belongs to an admin or to the merchant that owns the product, the
+// token is not needed otherwise.
+func (s *ProductServiceImpl) GetProduct(ctx context.Context, jwtToken, sku string, includeDeleted bool) (*products.Product, error) {
+	ctx, span := s.tracer.Start(ctx, "GetProduct")
+	defer span.End()
+	if sku == "" {
+		return nil, errSKURequired
+	}
+	if !includeDeleted {
+		product, err := s.productRepo.GetProductBySKU(ctx, sku, false)
+		if err != nil {
+			return nil, repositoryError(err, "an error occured while retrieving product, please try again later")
+		}
+		return product, nil
+	}
+	if jwtToken == "" {
+		return nil, NewUnauthenticatedError("deleted products can only be retrieved by authenticated users", nil)
+	}
+	userResponse, err := s.userServiceClient.GetUserFromJWT(ctx, &proto.GetUserFromJWTInput{JwtToken: jwtToken})
+	if err != nil {
+		tracing.RecordError(span, err, "retrieving user details from jwt")
+		return nil, userServiceError(err)
+	}
+	product, err := s.productRepo.GetProductBySKU(ctx, sku, true)
+	if err != nil {
+		return nil, repositoryError(err, "an error occured while retrieving product, please try again later")
+	}
+	userID := userResponse.User.Id
+	if product.DeletedAt.Valid && !s.adminIDs[userID] && product.MerchantID != userID {
+		// the product is reported as missing, like it is to the users
+		// that do not ask for deleted products.
+		tracing.AddEvent(span, "user cannot retrieve deleted product", attribute.String("user.id", userID))
+		return nil, repositoryError(products.ErrProductNotFound, "")
+	}
+	return product, nil
+}
+
+// GetProducts retrieves the products with the provided skus, the returned
+// products are in the same order as skus and skus that do not exist have
+// a nil product.
+func (s *ProductServiceImpl) GetProducts(ctx context.Context, skus []string) ([]*products.Product, error) {
+	ctx, span := s.tracer.Start(ctx, "GetProducts")
+	defer span.End()
+	tracing.SetAttribute(span, "param.skus.count", len(skus))
+	if len(skus) == 0 {
+		return nil, NewInvalidArgumentError("at least one sku must be provided", FieldViolation{
+			Field: "skus", Description: "at least one sku must be provided",
+		})
+	}
+	if len(skus) > maxGetProductsSKUs {
+		message := fmt.Sprintf("at most %d skus can be retrieved at once", maxGetProductsSKUs)
+		return nil, NewInvalidArgumentError(message, FieldViolation{Field: "skus", Description: message})
+	}
+	uniqueSKUs := make([]string, 0, len(skus))
+	seen := map[string]bool{}
+	for _, sku := range skus {
+		if sku != "" && !seen[sku] {
+			seen[sku] = true
+			uniqueSKUs = append(uniqueSKUs, sku)
+		}
+	}
+	productsBySKU := map[string]*products.Product{}
+	if len(uniqueSKUs) > 0 {
+		productList, err := s.productRepo.GetProductsBySKUs(ctx, uniqueSKUs)
+		if err != nil {
+			return nil, NewUnavailableError("an error occured while retrieving products, please try again later", err)
+		}
+		for _, product := range productList {
+			productsBySKU[product.Sku] = product
+		}
+	}
+	result := make([]*products.Product, len(skus))
+	for i, sku := range skus {
+		result[i] = productsBySKU[sku]
+	}
+	return result, nil
+}
+
+// UpdateProduct applies the provided fields of update to the product with
+// the provided sku, only the merchant that owns the product can update it.
+func (s *ProductServiceImpl) UpdateProduct(ctx context.Context, jwtToken, sku string, update *products.Product, fields []string) (*products.Product, error) {
+	ctx, span := s.tracer.Start(ctx, "UpdateProduct")
+	defer span.End()
+	tracing.SetAttribute(span, "param.sku", sku)
+	tracing.SetAttribute(span, "param.fields", fields)
+	if sku == "" {
+		return nil, errSKURequired
+	}
+	if update == nil || len(fields) == 0 {
+		return nil, NewInvalidArgumentError("at least one field must be provided for update", FieldViolation{
+			Field: "updateMask", Description: "at least one field must be provided for update",
+		})
+	}
+	product, err := s.getMerchantProduct(ctx, span, jwtToken, sku, false)
+	if err != nil {
+		return nil, err
+	}
+	before := *product
+	err = applyProductUpdate(product, update, fields)
+	if err != nil {
+		return nil, err
+	}
+	if fieldErrors := product.Validate(fields...); len(fieldErrors) > 0 {
+		tracing.AddEvent(span, "invalid product", attribute.Int("violations", len(fieldErrors)))
+		return nil, productValidationError(fieldErrors)
+	}
+	messages, err := s.productUpdateEventMessages(span, product.MerchantID, &before, product, fields)
+	if err != nil {
+		return nil, err
+	}
+	err = s.productRepo.UpdateProduct(ctx, product, fields, messages)
+	if err != nil {
+		return nil, repositoryError(err, "an error occured while updating product, please try again later")
+	}
+	return product, nil
+}
+
+// applyProductUpdate copies the provided fields from update to product.
+func applyProductUpdate(product, update *products.Product, fields []string) error {
+	for _, field := range fields {
+		switch field {
+		case "Name":
+			product.Name = update.Name
+		case "Description":
+			product.Description = update.Description
+		case "Category":
+			product.Category = update.Category
+		case "Brand":
+			product.Brand = update.Brand
+		case "Price":
+			product.Price = update.Price
+		case "ImageURL":
+			product.ImageURL = update.ImageURL
+		default:
+			message := fmt.Sprintf("%s cannot be updated", field)
+			return NewInvalidArgumentError(message, FieldViolation{Field: "updateMask", Description: message})
+		}
+	}
+	return nil
+}
+
+// DeleteProduct soft deletes the product with the provided sku, only the
+// merchant that owns the product can delete it.
+func (s *ProductServiceImpl) DeleteProduct(ctx context.Context, jwtToken, sku string) (*products.Product, error) {
+	ctx, span := s.tracer.Start(ctx, "DeleteProduct")
+	defer span.End()
+	tracing.SetAttribute(span, "param.sku", sku)
+	if sku == "" {
+		return nil, errSKURequired
+	}
+	product, err := s.getMerchantProduct(ctx, span, jwtToken, sku, false)
+	if err != nil {
+		return nil, err
+	}
+	deleted := events.NewProductEvent(events.ProductDeleted, product.MerchantID, product)
+	deleted.Product.Deleted = true
+	messages, err := s.productLifecycleMessages(span, "products.ProductDeleted", deleted)
+	if err != nil {
+		return nil, err
+	}
+	err = s.productRepo.DeleteProduct(ctx, product, messages)
 	if err != nil {
-		ext.Error.Set(span, true)
-		span.LogFields(
-			log.Error(err),
-			log.Event("injecting trace message to tracer"),
-		)
-		return
+		return nil, repositoryError(err, "an error occured while deleting product, please try again later")
+	}
+	return product, nil
+}
+
+// RestoreProduct restores the soft deleted product with the provided sku,
+// only the merchant that owns the product can restore it.
+func (s *ProductServiceImpl) RestoreProduct(ctx context.Context, jwtToken, sku string) (*products.Product, error) {
+	ctx, span := s.tracer.Start(ctx, "RestoreProduct")
+	defer span.End()
+	tracing.SetAttribute(span, "param.sku", sku)
+	if sku == "" {
+		return nil, errSKURequired
 	}
-	natsMessageJSON, err := json.Marshal(natsMessage)
+	product, err := s.getMerchantProduct(ctx, span, jwtToken, sku, true)
 	if err != nil {
-		ext.Error.Set(span, true)
-		span.LogFields(log.Error(err), log.Event("converting object to json"), log.Object("object", natsMessage))
-		return
+		return nil, err
+	}
+	if !product.DeletedAt.Valid {
+		return nil, NewInvalidArgumentError("product is not deleted", FieldViolation{
+			Field: "sku", Description: "product is not deleted",
+		})
+	}
+	restored := events.NewProductEvent(events.ProductRestored, product.MerchantID, product)
+	restored.Product.Deleted = false
+	messages, err := s.productLifecycleMessages(span, "products.ProductRestored", restored)
+	if err != nil {
+		return nil, err
+	}
+	err = s.productRepo.RestoreProduct(ctx, product, messages)
+	if err != nil {
+		return nil, repositoryError(err, "an error occured while restoring product, please try again later")
+	}
+	return product, nil
+}
+
+// PurgeProduct permanently removes the product with the provided sku, only
+// admins can purge products.
+func (s *ProductServiceImpl) PurgeProduct(ctx context.Context, jwtToken, sku string) error {
+	ctx, span := s.tracer.Start(ctx, "PurgeProduct")
+	defer span.End()
+	tracing.SetAttribute(span, "param.sku", sku)
+	if sku == "" {
+		return errSKURequired
+	}
+	userResponse, err := s.userServiceClient.GetUserFromJWT(ctx, &proto.GetUserFromJWTInput{JwtToken: jwtToken})
+	if err != nil {
+		tracing.RecordError(span, err, "retrieving user details from jwt")
+		return userServiceError(err)
+	}
+	if !s.adminIDs[userResponse.User.Id] {
+		tracing.AddEvent(span, "user is not an admin", attribute.String("user.id", userResponse.User.Id))
+		span.SetStatus(codes.Error, "user is not an admin")
+		return NewPermissionDeniedError("ADMIN_REQUIRED", "only admins can purge products")
+	}
+	product, err := s.productRepo.GetProductBySKU(ctx, sku, true)
+	if err != nil {
+		return repositoryError(err, "an error occured while retrieving product, please try again later")
+	}
+	purgedMessage, err := s.domainEventMessage(span, events.NewProductEvent(events.ProductPurged, userResponse.User.Id, product))
+	if err != nil {
+		return err
+	}
+	err = s.productRepo.PurgeProduct(ctx, product, []*outbox.Message{purgedMessage})
+	if err != nil {
+		return repositoryError(err, "an error occured while purging product, please try again later")
+	}
+	return nil
+}
+
+// UnpublishMerchantProducts soft deletes every product of the merchant in
+// batches, it is used when the merchant account is deleted or suspended.
+// Products that are already deleted are skipped so it is safe to call it
+// again for the same merchant.
+func (s *ProductServiceImpl) UnpublishMerchantProducts(ctx context.Context, merchantID string) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "UnpublishMerchantProducts")
+	defer span.End()
+	tracing.SetAttribute(span, "param.merchantID", merchantID)
+	if merchantID == "" {
+		return 0, NewInvalidArgumentError("merchant id is required", FieldViolation{
+			Field: "merchantId", Description: "merchant id is required",
+		})
 	}
-	traceMsg.Write(natsMessageJSON)
+	unpublished := 0
+	for {
+		batch, err := s.productRepo.ListProducts(ctx, products.ListFilter{
+			MerchantID: merchantID, SortBy: products.SortByTimeAdded, Limit: unpublishBatchSize,
+		})
+		if err != nil {
+			return unpublished, NewUnavailableError("an error occured while retrieving merchant products, please try again later", err)
+		}
+		if len(batch) == 0 {
+			tracing.SetAttribute(span, "response.unpublished", unpublished)
+			return unpublished, nil
+		}
+		messages := []*outbox.Message{}
+		for _, product := range batch {
+			deleted := events.NewProductEvent(events.ProductDeleted, systemActor, product)
+			deleted.Product.Deleted = true
+			productMessages, err := s.productLifecycleMessages(span, "products.ProductDeleted", deleted)
+			if err != nil {
+				return unpublished, err
+			}
+			messages = append(messages, productMessages...)
+		}
+		err = s.productRepo.DeleteProducts(ctx, batch, messages)
+		if err != nil {
+			return unpublished, NewUnavailableError("an error occured while unpublishing merchant products, please try again later", err)
+		}
+		unpublished += len(batch)
+	}
+}
 
-	span.LogFields(log.String("nats.message", traceMsg.String()))
-	err = s.natsConn.Publish("notification.SendProductAddedEmail", traceMsg.Bytes())
+// ListProducts retrieves a page of the products matching filter, after is
+// the cursor returned with the previous page. The cursor of the next page
+// is returned when there are more products.
+func (s *ProductServiceImpl) ListProducts(ctx context.Context, filter products.ListFilter, after string) ([]*products.Product, string, error) {
+	ctx, span := s.tracer.Start(ctx, "ListProducts")
+	defer span.End()
+	if filter.SortBy == "" {
+		filter.SortBy = products.SortByTimeAdded
+	}
+	if !filter.SortBy.Valid() {
+		message := fmt.Sprintf("products cannot be sorted by %s", filter.SortBy)
+		return nil, "", NewInvalidArgumentError(message, FieldViolation{Field: "sortBy", Description: message})
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListProductsLimit
+	}
+	if filter.Limit > maxListProductsLimit {
+		filter.Limit = maxListProductsLimit
+	}
+	if after != "" {
+		cursor, err := s.cursorCodec.Decode(after)
+		if err != nil {
+			tracing.RecordError(span, err, "decoding cursor")
+			return nil, "", NewInvalidArgumentError("invalid cursor", FieldViolation{
+				Field: "after", Description: "cursor is malformed or has been modified",
+			})
+		}
+		if cursor.SortBy != filter.SortBy || cursor.Descending != filter.Descending {
+			return nil, "", NewInvalidArgumentError("cursor does not match the requested sort order", FieldViolation{
+				Field: "after", Description: "cursor was returned for another sort order",
+			})
+		}
+		filter.After = cursor
+	}
+	limit := filter.Limit
+	// one extra product is retrieved to know if there is a next page.
+	filter.Limit++
+	productList, err := s.productRepo.ListProducts(ctx, filter)
+	if err != nil {
+		return nil, "", NewUnavailableError("an error occured while retrieving products, please try again later", err)
+	}
+	if len(productList) <= limit {
+		return productList, "", nil
+	}
+	productList = productList[:limit]
+	next, err := s.cursorCodec.Encode(products.NewCursor(productList[limit-1], filter.SortBy, filter.Descending))
 	if err != nil {
-		ext.Error.Set(span, true)
-		span.LogFields(log.Error(err), log.Event("nats.notification.SendProductAddedEmail"))
+		tracing.RecordError(span, err, "encoding cursor")
+		return nil, "", NewInternalError("an error occured while retrieving products, please try again later", err)
 	}
+	return productList, next, nil
 }
 
-func (s *ProductServiceImpl) GetProduct(ctx context.Context, sku string) (*products.Product, error) {
-	span, _ := opentracing.StartSpanFromContextWithTracer(ctx, s.tracer, "GetProduct")
-	defer span.Finish()
-	ctx = opentracing.ContextWithSpan(ctx, span)
+// GenerateVariants replaces the options of the product with the provided
+// sku and generates a variant for every combination of the option values.
+// Existing variants with the same option values are kept with their sku,
+// price and image, which also keeps their stock since stock is tracked by
+// sku.
+func (s *ProductServiceImpl) GenerateVariants(ctx context.Context, jwtToken, sku string, options []products.Option) (*products.Product, error) {
+	ctx, span := s.tracer.Start(ctx, "GenerateVariants")
+	defer span.End()
+	tracing.SetAttribute(span, "param.sku", sku)
 	if sku == "" {
-		return nil, errors.New("sku must be provided")
+		return nil, errSKURequired
 	}
-	product, err := s.productRepo.GetProductBySKU(ctx, sku)
+	if len(options) == 0 {
+		return nil, NewInvalidArgumentError("at least one option must be provided", FieldViolation{
+			Field: "options", Description: "at least one option must be provided",
+		})
+	}
+	if fieldErrors := products.ValidateOptions(options); len(fieldErrors) > 0 {
+		return nil, productValidationError(fieldErrors)
+	}
+	product, err := s.getMerchantProduct(ctx, span, jwtToken, sku, false)
 	if err != nil {
-		return nil, errors.New("product does not exist")
+		return nil, err
+	}
+	existingVariants := map[string]products.Variant{}
+	for _, variant := range product.Variants {
+		existingVariants[variant.Options.Key()] = variant
+	}
+	variants := []products.Variant{}
+	for _, optionValues := range products.GenerateVariantOptions(options) {
+		variant, ok := existingVariants[optionValues.Key()]
+		if !ok {
+			variant = products.Variant{ProductID: product.ID}
+		}
+		variant.Options = optionValues
+		variants = append(variants, variant)
+	}
+	tracing.SetAttribute(span, "variants.count", len(variants))
+	products.PrepareVariants(options, variants)
+	updated := *product
+	updated.Options = options
+	updated.Variants = variants
+	updatedEvent := events.NewProductEvent(events.ProductUpdated, product.MerchantID, &updated)
+	updatedEvent.ChangedFields = []string{"options", "variants"}
+	updatedMessage, err := s.domainEventMessage(span, updatedEvent)
+	if err != nil {
+		return nil, err
+	}
+	err = s.productRepo.ReplaceVariants(ctx, product, options, variants, []*outbox.Message{updatedMessage})
+	if err != nil {
+		return nil, repositoryError(err, "an error occured while generating variants, please try again later")
+	}
+	return product, nil
+}
+
+// getMerchantProduct retrieves the product with the provided sku and makes
+// sure it is owned by the merchant the jwt token belongs to.
+func (s *ProductServiceImpl) getMerchantProduct(ctx context.Context, span trace.Span, jwtToken, sku string, includeDeleted bool) (*products.Product, error) {
+	userResponse, err := s.userServiceClient.GetUserFromJWT(ctx, &proto.GetUserFromJWTInput{JwtToken: jwtToken})
+	if err != nil {
+		tracing.RecordError(span, err, "retrieving merchant details from jwt")
+		return nil, userServiceError(err)
+	}
+	product, err := s.productRepo.GetProductBySKU(ctx, sku, includeDeleted)
+	if err != nil {
+		return nil, repositoryError(err, "an error occured while retrieving product, please try again later")
+	}
+	if product.MerchantID != userResponse.User.Id {
+		tracing.AddEvent(span, "merchant does not own product", attribute.String("merchant.id", userResponse.User.Id))
+		span.SetStatus(codes.Error, "merchant does not own product")
+		return nil, NewPermissionDeniedError("NOT_PRODUCT_OWNER", "you are not allowed to modify this product")
 	}
 	return product, nil
 }
@@ -5,29 +5,27 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
-	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/mock"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/grpc/proto"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/internal/products"
 	"github.com/wisdommatt/ecommerce-microservice-product-service/mocks"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
 func TestProductServiceImpl_AddProduct(t *testing.T) {
 	productRepo := &mocks.Repository{}
-	productRepo.On("SaveProduct", mock.Anything, &products.Product{
-		Sku:        "123456",
-		Name:       "Product 1",
-		Price:      10000,
-		MerchantID: "valid.user",
-	}).Return(errors.New("an error occured"))
-
-	productRepo.On("SaveProduct", mock.Anything, &products.Product{
-		Sku:        "123456",
-		Name:       "Product 2",
-		Price:      15000,
-		MerchantID: "valid.user",
-	}).Return(nil)
+	savedProduct := func(name string) interface{} {
+		return mock.MatchedBy(func(p *products.Product) bool {
+			return p.Name == name && p.MerchantID == "valid.user" && p.Sku != "" && !p.TimeAdded.IsZero()
+		})
+	}
+	productRepo.On("SaveProduct", mock.Anything, savedProduct("Product 1"), eventSubjects("notification.SendProductAddedEmail", "products.v1.created")).
+		Return(errors.New("an error occured"))
+	productRepo.On("SaveProduct", mock.Anything, savedProduct("Product 2"), eventSubjects("notification.SendProductAddedEmail", "products.v1.created")).
+		Return(nil)
 
 	userServiceClient := &mocks.UserServiceClient{}
 	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "invalidJwt"}).
@@ -52,42 +50,67 @@ func TestProductServiceImpl_AddProduct(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name:    "invalid jwt token",
-			args:    args{jwtToken: "invalidJwt", newProduct: nil},
+			name:    "nil product",
+			args:    args{jwtToken: "validJwt", newProduct: nil},
+			wantErr: true,
+		},
+		{
+			name: "invalid product",
+			args: args{jwtToken: "validJwt", newProduct: &products.Product{
+				Name:     "",
+				Category: "weapons",
+				Price:    products.Money{Amount: -1, Currency: "USD"},
+				ImageURL: "not a url",
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid jwt token",
+			args: args{jwtToken: "invalidJwt", newProduct: &products.Product{
+				Name:     "Product 3",
+				Category: "electronics",
+				Price:    products.Money{Amount: 1000, Currency: "USD"},
+			}},
 			wantErr: true,
 		},
 		{
 			name: "SaveProduct repo implementation with error",
 			args: args{jwtToken: "validJwt", newProduct: &products.Product{
-				Sku:   "123456",
-				Name:  "Product 1",
-				Price: 10000,
+				Sku:      "123456",
+				Name:     "Product 1",
+				Category: "electronics",
+				Price:    products.Money{Amount: 10000, Currency: "USD"},
 			}},
 			wantErr: true,
 		},
 		{
 			name: "SaveProduct repo implementation without error",
 			args: args{jwtToken: "validJwt", newProduct: &products.Product{
-				Sku:   "123456",
-				Name:  "Product 2",
-				Price: 15000,
+				Sku:      "123456",
+				Name:     "Product 2",
+				Category: "electronics",
+				Price:    products.Money{Amount: 15000, Currency: "USD"},
 			}},
 			want: &products.Product{
-				Sku:        "123456",
 				Name:       "Product 2",
-				Price:      15000,
+				Category:   "electronics",
+				Price:      products.Money{Amount: 15000, Currency: "USD"},
 				MerchantID: "valid.user",
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := NewProductService(productRepo, userServiceClient, nil, &opentracing.NoopTracer{})
+			s := NewProductService(productRepo, userServiceClient, trace.NewNoopTracerProvider().Tracer(""), nil, nil)
 			got, err := s.AddProduct(context.Background(), tt.args.jwtToken, tt.args.newProduct)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ProductServiceImpl.AddProduct() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if got != nil {
+				// sku and time added are generated by the service.
+				got.Sku, got.TimeAdded = "", time.Time{}
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ProductServiceImpl.AddProduct() = %v, want %v", got, tt.want)
 			}
@@ -96,15 +119,216 @@ func TestProductServiceImpl_AddProduct(t *testing.T) {
 }
 
 func TestProductServiceImpl_GetProduct(t *testing.T) {
+	deletedProduct := &products.Product{
+		Name:       "Deleted Watch",
+		Price:      products.Money{Amount: 1999288, Currency: "USD"},
+		MerchantID: "merchant.1",
+		DeletedAt:  gorm.DeletedAt{Valid: true},
+	}
 	productRepo := &mocks.Repository{}
-	productRepo.On("GetProductBySKU", mock.Anything, "sku.111222").Return(nil, errors.New("an erorr occured"))
-	productRepo.On("GetProductBySKU", mock.Anything, "sku.222333").Return(&products.Product{
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.111222", false).Return(nil, errors.New("an erorr occured"))
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.222333", false).Return(&products.Product{
+		Name:  "Apple Watch",
+		Price: products.Money{Amount: 1999288, Currency: "USD"},
+	}, nil)
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.222333", true).Return(&products.Product{
 		Name:  "Apple Watch",
-		Price: 1999288,
+		Price: products.Money{Amount: 1999288, Currency: "USD"},
+	}, nil)
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.333444", true).Return(deletedProduct, nil)
+
+	userServiceClient := &mocks.UserServiceClient{}
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "invalidJwt"}).
+		Return(nil, errors.New("invalid jwt"))
+	for _, userID := range []string{"merchant.1", "merchant.2", "admin.1"} {
+		userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: userID + "Jwt"}).
+			Return(&proto.GetUserFromJWTResponse{User: &proto.User{Id: userID}}, nil)
+	}
+
+	type args struct {
+		jwtToken       string
+		sku            string
+		includeDeleted bool
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     *products.Product
+		wantCode ErrorCode
+	}{
+		{
+			name:     "empty sku",
+			args:     args{sku: ""},
+			wantCode: ErrorCodeInvalidArgument,
+		},
+		{
+			name:     "GetProductBySKU repository implementation with error",
+			args:     args{sku: "sku.111222"},
+			wantCode: ErrorCodeUnavailable,
+		},
+		{
+			name: "GetProductBySKU repository implementation without error",
+			args: args{sku: "sku.222333"},
+			want: &products.Product{Name: "Apple Watch", Price: products.Money{Amount: 1999288, Currency: "USD"}},
+		},
+		{
+			name:     "anonymous caller including deleted products",
+			args:     args{sku: "sku.333444", includeDeleted: true},
+			wantCode: ErrorCodeUnauthenticated,
+		},
+		{
+			name:     "invalid jwt token including deleted products",
+			args:     args{jwtToken: "invalidJwt", sku: "sku.333444", includeDeleted: true},
+			wantCode: ErrorCodeUnauthenticated,
+		},
+		{
+			name:     "deleted product of another merchant",
+			args:     args{jwtToken: "merchant.2Jwt", sku: "sku.333444", includeDeleted: true},
+			wantCode: ErrorCodeNotFound,
+		},
+		{
+			name: "product that is not deleted including deleted products",
+			args: args{jwtToken: "merchant.2Jwt", sku: "sku.222333", includeDeleted: true},
+			want: &products.Product{Name: "Apple Watch", Price: products.Money{Amount: 1999288, Currency: "USD"}},
+		},
+		{
+			name: "deleted product of the merchant",
+			args: args{jwtToken: "merchant.1Jwt", sku: "sku.333444", includeDeleted: true},
+			want: deletedProduct,
+		},
+		{
+			name: "deleted product retrieved by an admin",
+			args: args{jwtToken: "admin.1Jwt", sku: "sku.333444", includeDeleted: true},
+			want: deletedProduct,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(productRepo, userServiceClient, trace.NewNoopTracerProvider().Tracer(""), []string{"admin.1"}, nil)
+			got, err := s.GetProduct(context.Background(), tt.args.jwtToken, tt.args.sku, tt.args.includeDeleted)
+			var serviceErr *Error
+			if tt.wantCode != "" {
+				if !errors.As(err, &serviceErr) || serviceErr.Code != tt.wantCode {
+					t.Errorf("ProductServiceImpl.GetProduct() error = %v, wantCode %v", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("ProductServiceImpl.GetProduct() unexpected error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServiceImpl.GetProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServiceImpl_GetProducts(t *testing.T) {
+	productRepo := &mocks.Repository{}
+	productRepo.On("GetProductsBySKUs", mock.Anything, []string{"sku.error"}).Return(nil, errors.New("an error occured"))
+	productRepo.On("GetProductsBySKUs", mock.Anything, []string{"sku.2", "sku.missing", "sku.1"}).Return([]*products.Product{
+		{Sku: "sku.1", Name: "Product 1"},
+		{Sku: "sku.2", Name: "Product 2"},
 	}, nil)
 
+	tooManySKUs := make([]string, maxGetProductsSKUs+1)
 	type args struct {
-		sku string
+		skus []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []*products.Product
+		wantErr bool
+	}{
+		{
+			name:    "no skus",
+			args:    args{skus: nil},
+			wantErr: true,
+		},
+		{
+			name:    "too many skus",
+			args:    args{skus: tooManySKUs},
+			wantErr: true,
+		},
+		{
+			name:    "GetProductsBySKUs repo implementation with error",
+			args:    args{skus: []string{"sku.error"}},
+			wantErr: true,
+		},
+		{
+			name: "GetProductsBySKUs repo implementation without error",
+			args: args{skus: []string{"sku.2", "sku.missing", "sku.1", "sku.2"}},
+			want: []*products.Product{
+				{Sku: "sku.2", Name: "Product 2"},
+				nil,
+				{Sku: "sku.1", Name: "Product 1"},
+				{Sku: "sku.2", Name: "Product 2"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(productRepo, nil, trace.NewNoopTracerProvider().Tracer(""), nil, nil)
+			got, err := s.GetProducts(context.Background(), tt.args.skus)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServiceImpl.GetProducts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServiceImpl.GetProducts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServiceImpl_UpdateProduct(t *testing.T) {
+	productRepo := &mocks.Repository{}
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.invalid", false).Return(nil, errors.New("an error occured"))
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.other", false).Return(&products.Product{
+		Sku:        "sku.other",
+		Name:       "Other Product",
+		MerchantID: "other.user",
+	}, nil)
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.error", false).Return(&products.Product{
+		Sku:        "sku.error",
+		Name:       "Product 1",
+		MerchantID: "valid.user",
+	}, nil)
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.valid", false).Return(&products.Product{
+		Sku:        "sku.valid",
+		Name:       "Product 2",
+		Brand:      "Nike",
+		Price:      products.Money{Amount: 15000, Currency: "USD"},
+		MerchantID: "valid.user",
+	}, nil)
+	productRepo.On("UpdateProduct", mock.Anything, &products.Product{
+		Sku:        "sku.error",
+		Name:       "Product 1 Updated",
+		MerchantID: "valid.user",
+	}, []string{"Name"}, eventSubjects("products.v1.updated")).Return(errors.New("an error occured"))
+	productRepo.On("UpdateProduct", mock.Anything, &products.Product{
+		Sku:        "sku.valid",
+		Name:       "Product 2",
+		Brand:      "Nike",
+		Price:      products.Money{Amount: 20000, Currency: "USD"},
+		MerchantID: "valid.user",
+	}, []string{"Price"}, eventSubjects("products.v1.updated", "products.v1.price_changed")).Return(nil)
+
+	userServiceClient := &mocks.UserServiceClient{}
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "invalidJwt"}).
+		Return(nil, errors.New("invalid jwt"))
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "validJwt"}).
+		Return(&proto.GetUserFromJWTResponse{
+			User: &proto.User{Id: "valid.user", FullName: "Valid User"},
+		}, nil)
+
+	type args struct {
+		jwtToken string
+		sku      string
+		update   *products.Product
+		fields   []string
 	}
 	tests := []struct {
 		name    string
@@ -114,30 +338,488 @@ func TestProductServiceImpl_GetProduct(t *testing.T) {
 	}{
 		{
 			name:    "empty sku",
-			args:    args{sku: ""},
+			args:    args{jwtToken: "validJwt", update: &products.Product{}, fields: []string{"Name"}},
 			wantErr: true,
 		},
 		{
-			name:    "GetProductBySKU repository implementation with error",
-			args:    args{sku: "sku.111222"},
+			name:    "no fields to update",
+			args:    args{jwtToken: "validJwt", sku: "sku.valid", update: &products.Product{}},
 			wantErr: true,
 		},
 		{
-			name: "GetProductBySKU repository implementation without error",
-			args: args{sku: "sku.222333"},
-			want: &products.Product{Name: "Apple Watch", Price: 1999288},
+			name:    "invalid jwt token",
+			args:    args{jwtToken: "invalidJwt", sku: "sku.valid", update: &products.Product{}, fields: []string{"Name"}},
+			wantErr: true,
+		},
+		{
+			name:    "GetProductBySKU repo implementation with error",
+			args:    args{jwtToken: "validJwt", sku: "sku.invalid", update: &products.Product{}, fields: []string{"Name"}},
+			wantErr: true,
+		},
+		{
+			name:    "product owned by another merchant",
+			args:    args{jwtToken: "validJwt", sku: "sku.other", update: &products.Product{Name: "Stolen"}, fields: []string{"Name"}},
+			wantErr: true,
+		},
+		{
+			name:    "field that cannot be updated",
+			args:    args{jwtToken: "validJwt", sku: "sku.valid", update: &products.Product{MerchantID: "other.user"}, fields: []string{"MerchantID"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid update",
+			args:    args{jwtToken: "validJwt", sku: "sku.valid", update: &products.Product{Price: products.Money{Amount: -20000, Currency: "USD"}}, fields: []string{"Price"}},
+			wantErr: true,
+		},
+		{
+			name:    "UpdateProduct repo implementation with error",
+			args:    args{jwtToken: "validJwt", sku: "sku.error", update: &products.Product{Name: "Product 1 Updated"}, fields: []string{"Name"}},
+			wantErr: true,
+		},
+		{
+			name: "UpdateProduct repo implementation without error",
+			args: args{jwtToken: "validJwt", sku: "sku.valid", update: &products.Product{Name: "Ignored", Price: products.Money{Amount: 20000, Currency: "USD"}}, fields: []string{"Price"}},
+			want: &products.Product{
+				Sku:        "sku.valid",
+				Name:       "Product 2",
+				Brand:      "Nike",
+				Price:      products.Money{Amount: 20000, Currency: "USD"},
+				MerchantID: "valid.user",
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := NewProductService(productRepo, nil, nil, &opentracing.NoopTracer{})
-			got, err := s.GetProduct(context.Background(), tt.args.sku)
+			s := NewProductService(productRepo, userServiceClient, trace.NewNoopTracerProvider().Tracer(""), nil, nil)
+			got, err := s.UpdateProduct(context.Background(), tt.args.jwtToken, tt.args.sku, tt.args.update, tt.args.fields)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("ProductServiceImpl.GetProduct() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("ProductServiceImpl.UpdateProduct() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("ProductServiceImpl.GetProduct() = %v, want %v", got, tt.want)
+				t.Errorf("ProductServiceImpl.UpdateProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServiceImpl_DeleteProduct(t *testing.T) {
+	productRepo := &mocks.Repository{}
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.invalid", false).Return(nil, errors.New("an error occured"))
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.other", false).Return(&products.Product{
+		Sku: "sku.other", MerchantID: "other.user",
+	}, nil)
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.error", false).Return(&products.Product{
+		Sku: "sku.error", MerchantID: "valid.user",
+	}, nil)
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.valid", false).Return(&products.Product{
+		Sku: "sku.valid", MerchantID: "valid.user",
+	}, nil)
+	productRepo.On("DeleteProduct", mock.Anything, &products.Product{Sku: "sku.error", MerchantID: "valid.user"}, eventSubjects("products.ProductDeleted", "products.v1.deleted")).
+		Return(errors.New("an error occured"))
+	productRepo.On("DeleteProduct", mock.Anything, &products.Product{Sku: "sku.valid", MerchantID: "valid.user"}, eventSubjects("products.ProductDeleted", "products.v1.deleted")).
+		Return(nil)
+
+	userServiceClient := &mocks.UserServiceClient{}
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "invalidJwt"}).
+		Return(nil, errors.New("invalid jwt"))
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "validJwt"}).
+		Return(&proto.GetUserFromJWTResponse{User: &proto.User{Id: "valid.user"}}, nil)
+
+	type args struct {
+		jwtToken string
+		sku      string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *products.Product
+		wantErr bool
+	}{
+		{
+			name:    "empty sku",
+			args:    args{jwtToken: "validJwt"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid jwt token",
+			args:    args{jwtToken: "invalidJwt", sku: "sku.valid"},
+			wantErr: true,
+		},
+		{
+			name:    "GetProductBySKU repo implementation with error",
+			args:    args{jwtToken: "validJwt", sku: "sku.invalid"},
+			wantErr: true,
+		},
+		{
+			name:    "product owned by another merchant",
+			args:    args{jwtToken: "validJwt", sku: "sku.other"},
+			wantErr: true,
+		},
+		{
+			name:    "DeleteProduct repo implementation with error",
+			args:    args{jwtToken: "validJwt", sku: "sku.error"},
+			wantErr: true,
+		},
+		{
+			name: "DeleteProduct repo implementation without error",
+			args: args{jwtToken: "validJwt", sku: "sku.valid"},
+			want: &products.Product{Sku: "sku.valid", MerchantID: "valid.user"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(productRepo, userServiceClient, trace.NewNoopTracerProvider().Tracer(""), nil, nil)
+			got, err := s.DeleteProduct(context.Background(), tt.args.jwtToken, tt.args.sku)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServiceImpl.DeleteProduct() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServiceImpl.DeleteProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServiceImpl_RestoreProduct(t *testing.T) {
+	deletedAt := gorm.DeletedAt{Valid: true}
+	productRepo := &mocks.Repository{}
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.active", true).Return(&products.Product{
+		Sku: "sku.active", MerchantID: "valid.user",
+	}, nil)
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.error", true).Return(&products.Product{
+		Sku: "sku.error", MerchantID: "valid.user", DeletedAt: deletedAt,
+	}, nil)
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.valid", true).Return(&products.Product{
+		Sku: "sku.valid", MerchantID: "valid.user", DeletedAt: deletedAt,
+	}, nil)
+	productRepo.On("RestoreProduct", mock.Anything, &products.Product{Sku: "sku.error", MerchantID: "valid.user", DeletedAt: deletedAt}, eventSubjects("products.ProductRestored", "products.v1.restored")).
+		Return(errors.New("an error occured"))
+	productRepo.On("RestoreProduct", mock.Anything, &products.Product{Sku: "sku.valid", MerchantID: "valid.user", DeletedAt: deletedAt}, eventSubjects("products.ProductRestored", "products.v1.restored")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*products.Product).DeletedAt = gorm.DeletedAt{}
+		}).Return(nil)
+
+	userServiceClient := &mocks.UserServiceClient{}
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "validJwt"}).
+		Return(&proto.GetUserFromJWTResponse{User: &proto.User{Id: "valid.user"}}, nil)
+
+	type args struct {
+		jwtToken string
+		sku      string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *products.Product
+		wantErr bool
+	}{
+		{
+			name:    "empty sku",
+			args:    args{jwtToken: "validJwt"},
+			wantErr: true,
+		},
+		{
+			name:    "product that is not deleted",
+			args:    args{jwtToken: "validJwt", sku: "sku.active"},
+			wantErr: true,
+		},
+		{
+			name:    "RestoreProduct repo implementation with error",
+			args:    args{jwtToken: "validJwt", sku: "sku.error"},
+			wantErr: true,
+		},
+		{
+			name: "RestoreProduct repo implementation without error",
+			args: args{jwtToken: "validJwt", sku: "sku.valid"},
+			want: &products.Product{Sku: "sku.valid", MerchantID: "valid.user"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(productRepo, userServiceClient, trace.NewNoopTracerProvider().Tracer(""), nil, nil)
+			got, err := s.RestoreProduct(context.Background(), tt.args.jwtToken, tt.args.sku)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServiceImpl.RestoreProduct() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServiceImpl.RestoreProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductServiceImpl_PurgeProduct(t *testing.T) {
+	productRepo := &mocks.Repository{}
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.invalid", true).Return(nil, errors.New("an error occured"))
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.error", true).Return(&products.Product{Sku: "sku.error"}, nil)
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.valid", true).Return(&products.Product{Sku: "sku.valid"}, nil)
+	productRepo.On("PurgeProduct", mock.Anything, &products.Product{Sku: "sku.error"}, eventSubjects("products.v1.purged")).Return(errors.New("an error occured"))
+	productRepo.On("PurgeProduct", mock.Anything, &products.Product{Sku: "sku.valid"}, eventSubjects("products.v1.purged")).Return(nil)
+
+	userServiceClient := &mocks.UserServiceClient{}
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "invalidJwt"}).
+		Return(nil, errors.New("invalid jwt"))
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "merchantJwt"}).
+		Return(&proto.GetUserFromJWTResponse{User: &proto.User{Id: "merchant.user"}}, nil)
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "adminJwt"}).
+		Return(&proto.GetUserFromJWTResponse{User: &proto.User{Id: "admin.user"}}, nil)
+
+	type args struct {
+		jwtToken string
+		sku      string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "empty sku",
+			args:    args{jwtToken: "adminJwt"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid jwt token",
+			args:    args{jwtToken: "invalidJwt", sku: "sku.valid"},
+			wantErr: true,
+		},
+		{
+			name:    "user that is not an admin",
+			args:    args{jwtToken: "merchantJwt", sku: "sku.valid"},
+			wantErr: true,
+		},
+		{
+			name:    "GetProductBySKU repo implementation with error",
+			args:    args{jwtToken: "adminJwt", sku: "sku.invalid"},
+			wantErr: true,
+		},
+		{
+			name:    "PurgeProduct repo implementation with error",
+			args:    args{jwtToken: "adminJwt", sku: "sku.error"},
+			wantErr: true,
+		},
+		{
+			name: "PurgeProduct repo implementation without error",
+			args: args{jwtToken: "adminJwt", sku: "sku.valid"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(productRepo, userServiceClient, trace.NewNoopTracerProvider().Tracer(""), []string{"admin.user"}, nil)
+			err := s.PurgeProduct(context.Background(), tt.args.jwtToken, tt.args.sku)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServiceImpl.PurgeProduct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProductServiceImpl_ListProducts(t *testing.T) {
+	codec := products.NewCursorCodec([]byte("secret"))
+	priceCursor, _ := codec.Encode(&products.Cursor{SortBy: products.SortByPrice, Price: 100, ID: 2})
+
+	productRepo := &mocks.Repository{}
+	productRepo.On("ListProducts", mock.Anything, products.ListFilter{
+		Brand: "Broken", SortBy: products.SortByTimeAdded, Limit: 21,
+	}).Return(nil, errors.New("an error occured"))
+	productRepo.On("ListProducts", mock.Anything, products.ListFilter{
+		Brand: "Nike", SortBy: products.SortByTimeAdded, Limit: 3,
+	}).Return([]*products.Product{{ID: 1, Name: "Product 1"}, {ID: 2, Name: "Product 2"}}, nil)
+	productRepo.On("ListProducts", mock.Anything, products.ListFilter{
+		SortBy: products.SortByPrice, Limit: 2,
+		After: &products.Cursor{SortBy: products.SortByPrice, Price: 100, ID: 2},
+	}).Return([]*products.Product{{ID: 3, Price: products.Money{Amount: 150, Currency: "USD"}}, {ID: 4, Price: products.Money{Amount: 200, Currency: "USD"}}}, nil)
+
+	type args struct {
+		filter products.ListFilter
+		after  string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     []*products.Product
+		wantNext *products.Cursor
+		wantErr  bool
+	}{
+		{
+			name:    "invalid sort field",
+			args:    args{filter: products.ListFilter{SortBy: "merchant_id"}},
+			wantErr: true,
+		},
+		{
+			name:    "tampered cursor",
+			args:    args{filter: products.ListFilter{SortBy: products.SortByPrice}, after: priceCursor + "x"},
+			wantErr: true,
+		},
+		{
+			name:    "cursor with another sort field",
+			args:    args{filter: products.ListFilter{SortBy: products.SortByName}, after: priceCursor},
+			wantErr: true,
+		},
+		{
+			name:    "ListProducts repo implementation with error",
+			args:    args{filter: products.ListFilter{Brand: "Broken"}},
+			wantErr: true,
+		},
+		{
+			name: "last page",
+			args: args{filter: products.ListFilter{Brand: "Nike", Limit: 2}},
+			want: []*products.Product{{ID: 1, Name: "Product 1"}, {ID: 2, Name: "Product 2"}},
+		},
+		{
+			name:     "page with next cursor",
+			args:     args{filter: products.ListFilter{SortBy: products.SortByPrice, Limit: 1}, after: priceCursor},
+			want:     []*products.Product{{ID: 3, Price: products.Money{Amount: 150, Currency: "USD"}}},
+			wantNext: &products.Cursor{SortBy: products.SortByPrice, Price: 150, ID: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(productRepo, nil, trace.NewNoopTracerProvider().Tracer(""), nil, codec)
+			got, next, err := s.ListProducts(context.Background(), tt.args.filter, tt.args.after)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServiceImpl.ListProducts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductServiceImpl.ListProducts() = %v, want %v", got, tt.want)
+			}
+			if tt.wantNext == nil {
+				if next != "" {
+					t.Errorf("ProductServiceImpl.ListProducts() next = %v, want empty cursor", next)
+				}
+				return
+			}
+			gotNext, err := codec.Decode(next)
+			if err != nil || !reflect.DeepEqual(gotNext, tt.wantNext) {
+				t.Errorf("ProductServiceImpl.ListProducts() next = %v, want %v", gotNext, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestProductServiceImpl_GenerateVariants(t *testing.T) {
+	sizes := []products.Option{{Name: "size", Values: products.StringList{"S", "M"}}}
+	productRepo := &mocks.Repository{}
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.error", false).Return(&products.Product{
+		ID: 1, Sku: "sku.error", MerchantID: "valid.user",
+	}, nil)
+	productRepo.On("GetProductBySKU", mock.Anything, "sku.valid", false).Return(&products.Product{
+		ID: 2, Sku: "sku.valid", MerchantID: "valid.user",
+		Options: []products.Option{{Name: "size", Values: products.StringList{"S"}}},
+		Variants: []products.Variant{
+			{ID: 5, ProductID: 2, Sku: "variant.s", Options: products.OptionValues{"size": "S"}},
+		},
+	}, nil)
+	productRepo.On("ReplaceVariants", mock.Anything, mock.MatchedBy(func(p *products.Product) bool {
+		return p.Sku == "sku.error"
+	}), sizes, mock.Anything, eventSubjects("products.v1.updated")).Return(errors.New("an error occured"))
+	productRepo.On("ReplaceVariants", mock.Anything, mock.MatchedBy(func(p *products.Product) bool {
+		return p.Sku == "sku.valid"
+	}), sizes, mock.MatchedBy(func(variants []products.Variant) bool {
+		// existing variants keep their sku, new ones get a generated sku.
+		return len(variants) == 2 &&
+			variants[0].ID == 5 && variants[0].Sku == "variant.s" &&
+			variants[1].ID == 0 && variants[1].Sku != "" && variants[1].Options["size"] == "M"
+	}), eventSubjects("products.v1.updated")).Return(nil)
+
+	userServiceClient := &mocks.UserServiceClient{}
+	userServiceClient.On("GetUserFromJWT", mock.Anything, &proto.GetUserFromJWTInput{JwtToken: "validJwt"}).
+		Return(&proto.GetUserFromJWTResponse{User: &proto.User{Id: "valid.user"}}, nil)
+
+	type args struct {
+		sku     string
+		options []products.Option
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "empty sku",
+			args:    args{options: sizes},
+			wantErr: true,
+		},
+		{
+			name:    "no options",
+			args:    args{sku: "sku.valid"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid options",
+			args:    args{sku: "sku.valid", options: []products.Option{{Name: "size"}}},
+			wantErr: true,
+		},
+		{
+			name:    "ReplaceVariants repo implementation with error",
+			args:    args{sku: "sku.error", options: sizes},
+			wantErr: true,
+		},
+		{
+			name: "ReplaceVariants repo implementation without error",
+			args: args{sku: "sku.valid", options: sizes},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(productRepo, userServiceClient, trace.NewNoopTracerProvider().Tracer(""), nil, nil)
+			_, err := s.GenerateVariants(context.Background(), "validJwt", tt.args.sku, tt.args.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProductServiceImpl.GenerateVariants() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProductServiceImpl_UnpublishMerchantProducts(t *testing.T) {
+	batch := []*products.Product{
+		{ID: 1, Sku: "sku.1", MerchantID: "merchant.valid"},
+		{ID: 2, Sku: "sku.2", MerchantID: "merchant.valid"},
+	}
+	merchantFilter := func(merchantID string) products.ListFilter {
+		return products.ListFilter{MerchantID: merchantID, SortBy: products.SortByTimeAdded, Limit: unpublishBatchSize}
+	}
+	productRepo := &mocks.Repository{}
+	productRepo.On("ListProducts", mock.Anything, merchantFilter("merchant.listError")).
+		Return(nil, errors.New("an error occured"))
+	deleteErrorBatch := []*products.Product{{ID: 3, Sku: "sku.3", MerchantID: "merchant.deleteError"}}
+	productRepo.On("ListProducts", mock.Anything, merchantFilter("merchant.deleteError")).Return(deleteErrorBatch, nil)
+	productRepo.On("DeleteProducts", mock.Anything, deleteErrorBatch, mock.Anything).Return(errors.New("an error occured"))
+	// the second batch is empty since the first batch was deleted.
+	productRepo.On("ListProducts", mock.Anything, merchantFilter("merchant.valid")).Return(batch, nil).Once()
+	productRepo.On("ListProducts", mock.Anything, merchantFilter("merchant.valid")).Return([]*products.Product{}, nil)
+	productRepo.On("DeleteProducts", mock.Anything, batch, eventSubjects(
+		"products.ProductDeleted", "products.v1.deleted", "products.ProductDeleted", "products.v1.deleted",
+	)).Return(nil)
+
+	tests := []struct {
+		name       string
+		merchantID string
+		want       int
+		wantCode   ErrorCode
+	}{
+		{name: "empty merchant id", wantCode: ErrorCodeInvalidArgument},
+		{name: "ListProducts repo implementation with error", merchantID: "merchant.listError", wantCode: ErrorCodeUnavailable},
+		{name: "DeleteProducts repo implementation with error", merchantID: "merchant.deleteError", wantCode: ErrorCodeUnavailable},
+		{name: "products unpublished in batches", merchantID: "merchant.valid", want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewProductService(productRepo, nil, trace.NewNoopTracerProvider().Tracer(""), nil, nil)
+			got, err := s.UnpublishMerchantProducts(context.Background(), tt.merchantID)
+			var serviceErr *Error
+			if tt.wantCode != "" {
+				if !errors.As(err, &serviceErr) || serviceErr.Code != tt.wantCode {
+					t.Errorf("ProductServiceImpl.UnpublishMerchantProducts() error = %v, wantCode %v", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ProductServiceImpl.UnpublishMerchantProducts() = %v, %v, want %v", got, err, tt.want)
 			}
 		})
 	}